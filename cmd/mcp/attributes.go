@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/storage"
+)
+
+// ingredientDetailsFromRequest reads the optional ingredient fields from the
+// request, merging them on top of the current values of base, which may be
+// nil. Fields that were not provided are left nil, and so untouched.
+func ingredientDetailsFromRequest(request mcp.CallToolRequest, base *models.Ingredient) (storage.IngredientDetails, error) {
+	var details storage.IngredientDetails
+	var baseNutrition *models.NutritionFacts
+	var baseConversion models.UnitConversion
+	if base != nil {
+		baseNutrition = base.Nutrition
+		baseConversion = base.UnitConversion
+	}
+
+	facts, hasNutrition, err := nutritionFromRequest(request, baseNutrition)
+	if err != nil {
+		return details, err
+	}
+	if hasNutrition {
+		details.Nutrition = &facts
+	}
+
+	conversion, hasConversion, err := unitConversionFromRequest(request, baseConversion)
+	if err != nil {
+		return details, err
+	}
+	if hasConversion {
+		details.UnitConversion = &conversion
+	}
+
+	if _, ok := request.GetArguments()["category"]; ok {
+		value, err := request.RequireString("category")
+		if err != nil {
+			return details, err
+		}
+		category := models.Category(value)
+		details.Category = &category
+	}
+
+	return details, nil
+}
+
+// noIngredientDetails reports whether none of the optional ingredient fields
+// was provided.
+func noIngredientDetails(details storage.IngredientDetails) bool {
+	return details.Nutrition == nil && details.UnitConversion == nil && details.Category == nil
+}
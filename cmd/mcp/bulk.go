@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/storage"
+)
+
+const (
+	bulkActionCreate = "create"
+	bulkActionRename = "rename"
+	bulkActionDelete = "delete"
+)
+
+var bulkItemsSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"name": map[string]any{
+			"type":        "string",
+			"description": "Name of the ingredient to create, or name or alias of the ingredient to rename or delete",
+		},
+		"new_name": map[string]any{
+			"type":        "string",
+			"description": "New name of the ingredient, only for the rename action",
+		},
+	},
+	"required": []string{"name"},
+}
+
+type bulkItemArgument struct {
+	Name    string `json:"name"`
+	NewName string `json:"new_name"`
+}
+
+func addBulkTools(mcpServer *server.MCPServer, ingredientStorage storage.IngredientStorage) {
+	// Tools
+	bulkIngredientsTool := mcp.NewTool("bulk_ingredients",
+		mcp.WithDescription("Create, rename or delete many ingredients in a single call, such as a whole grocery haul. Either every item is applied or, if any item fails, none is, and the result tells which items failed and why."),
+		mcp.WithString("action",
+			mcp.Required(),
+			mcp.Description("What to do with every item"),
+			mcp.Enum(bulkActionCreate, bulkActionRename, bulkActionDelete),
+		),
+		mcp.WithArray("items",
+			mcp.Required(),
+			mcp.Description("Ingredients to act on, each one with a name and, to rename it, a new_name"),
+			mcp.Items(bulkItemsSchema),
+		),
+	)
+
+	// Tool handlers
+	mcpServer.AddTool(bulkIngredientsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		action, err := request.RequireString("action")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		var args struct {
+			Items []bulkItemArgument `json:"items"`
+		}
+		if err := request.BindArguments(&args); err != nil || len(args.Items) == 0 {
+			return mcp.NewToolResultText(`❌ Error: argument "items" must be a non-empty list of objects with name and new_name`), nil
+		}
+
+		names := make([]string, len(args.Items))
+		for i, item := range args.Items {
+			names[i] = item.Name
+		}
+
+		var result strings.Builder
+		switch action {
+		case bulkActionCreate:
+			ingredients, err := ingredientStorage.CreateMany(ctx, names)
+			if err != nil {
+				return mcp.NewToolResultText(bulkErrorMessage(err, args.Items, "Failed to create ingredients")), nil
+			}
+			result.WriteString(fmt.Sprintf("✅ Created %d ingredients:\n", len(ingredients)))
+			writeBulkIngredients(&result, ingredients)
+
+		case bulkActionRename:
+			renames := make([]storage.IngredientRename, len(args.Items))
+			for i, item := range args.Items {
+				renames[i] = storage.IngredientRename{Name: item.Name, NewName: item.NewName}
+			}
+			ingredients, err := ingredientStorage.UpdateMany(ctx, renames)
+			if err != nil {
+				return mcp.NewToolResultText(bulkErrorMessage(err, args.Items, "Failed to rename ingredients")), nil
+			}
+			result.WriteString(fmt.Sprintf("✅ Renamed %d ingredients:\n", len(ingredients)))
+			writeBulkIngredients(&result, ingredients)
+
+		case bulkActionDelete:
+			if err := ingredientStorage.DeleteMany(ctx, names); err != nil {
+				return mcp.NewToolResultText(bulkErrorMessage(err, args.Items, "Failed to delete ingredients")), nil
+			}
+			result.WriteString(fmt.Sprintf("✅ Moved %d ingredients to the trash:\n", len(names)))
+			for _, name := range names {
+				result.WriteString(fmt.Sprintf("- %s\n", name))
+			}
+
+		default:
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: unknown action %q, use create, rename or delete", action)), nil
+		}
+
+		return mcp.NewToolResultText(result.String()), nil
+	})
+}
+
+func writeBulkIngredients(result *strings.Builder, ingredients []*models.Ingredient) {
+	for _, ingredient := range ingredients {
+		result.WriteString(fmt.Sprintf("- %s (ID %d)\n", ingredient.Name, ingredient.ID))
+	}
+}
+
+// bulkErrorMessage lists the outcome of every item of a failed batch, or falls
+// back to ingredientErrorMessage for errors that are not about the items.
+func bulkErrorMessage(err error, items []bulkItemArgument, fallback string) string {
+	var batchErr *storage.BatchError
+	if !errors.As(err, &batchErr) {
+		return ingredientErrorMessage(err, fallback)
+	}
+
+	failures := make(map[int]error, len(batchErr.Items))
+	for _, item := range batchErr.Items {
+		failures[item.Index] = item.Err
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("❌ Error: nothing was changed, %d of %d items failed:\n", len(failures), len(items)))
+	for i, item := range items {
+		label := item.Name
+		if item.NewName != "" {
+			label = fmt.Sprintf("%s → %s", item.Name, item.NewName)
+		}
+
+		if err, failed := failures[i]; failed {
+			result.WriteString(fmt.Sprintf("%d. ❌ %s: %v\n", i+1, label, err))
+		} else {
+			result.WriteString(fmt.Sprintf("%d. ✅ %s\n", i+1, label))
+		}
+	}
+	return result.String()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// ingredientGroup is a set of ingredients sharing the same category.
+type ingredientGroup struct {
+	category    models.Category
+	ingredients []*models.Ingredient
+}
+
+// withCategoryArgument adds the category argument to a tool.
+func withCategoryArgument() mcp.ToolOption {
+	return mcp.WithString("category",
+		mcp.Description("Category of the ingredient; use 'uncategorized' to clear it"),
+		mcp.Enum(append(categoryNames(), models.CategoryUncategorized.String())...),
+	)
+}
+
+// categoryNames returns the names of every known category in display order.
+func categoryNames() []string {
+	names := make([]string, 0, len(models.Categories))
+	for _, category := range models.Categories {
+		names = append(names, category.String())
+	}
+	return names
+}
+
+// groupByCategory splits ingredients by category, following the taxonomy order
+// and leaving uncategorized ingredients last. Empty groups are omitted.
+func groupByCategory(ingredients []*models.Ingredient) []ingredientGroup {
+	byCategory := make(map[models.Category][]*models.Ingredient)
+	for _, ingredient := range ingredients {
+		byCategory[ingredient.Category] = append(byCategory[ingredient.Category], ingredient)
+	}
+
+	order := append(append([]models.Category{}, models.Categories...), models.CategoryUncategorized)
+	groups := make([]ingredientGroup, 0, len(order))
+	for _, category := range order {
+		if len(byCategory[category]) == 0 {
+			continue
+		}
+		groups = append(groups, ingredientGroup{category: category, ingredients: byCategory[category]})
+	}
+	return groups
+}
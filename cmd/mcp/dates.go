@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// dateLayout is the format of the dates accepted and shown by the tools.
+const dateLayout = "2006-01-02"
+
+// parseDate parses a YYYY-MM-DD date. An empty value gives the zero time.
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, errors.New("dates must be formatted as YYYY-MM-DD")
+	}
+	return date, nil
+}
+
+// requireDate reads a mandatory YYYY-MM-DD date argument.
+func requireDate(request mcp.CallToolRequest, name string) (time.Time, error) {
+	value, err := request.RequireString(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date, err := parseDate(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if date.IsZero() {
+		return time.Time{}, fmt.Errorf("required argument %q is empty", name)
+	}
+	return date, nil
+}
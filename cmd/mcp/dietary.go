@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/victorcete/recipe-manager/internal/dietary"
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/storage"
+)
+
+func addDietaryTools(mcpServer *server.MCPServer, recipeStorage storage.RecipeStorage, ingredientStorage storage.IngredientStorage) {
+	allergenNames := make([]string, 0, len(models.Allergens))
+	for _, allergen := range models.Allergens {
+		allergenNames = append(allergenNames, string(allergen))
+	}
+	dietNames := make([]string, 0, len(models.Diets))
+	for _, diet := range models.Diets {
+		dietNames = append(dietNames, string(diet))
+	}
+
+	// Tools
+	setDietaryInfoTool := mcp.NewTool("set_ingredient_dietary_info",
+		mcp.WithDescription("Set the allergens exactly one ingredient contains and the diets it is suitable for. Omitted lists are left untouched; pass an empty list of allergens to declare the ingredient contains none."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the ingredient"),
+		),
+		mcp.WithArray("allergens",
+			mcp.Description("Every allergen the ingredient contains, replacing the current ones"),
+			mcp.WithStringEnumItems(allergenNames),
+		),
+		mcp.WithArray("diets",
+			mcp.Description("Every diet the ingredient is suitable for, replacing the current ones. Vegan implies vegetarian"),
+			mcp.WithStringEnumItems(dietNames),
+		),
+		withExpectedVersionArgument(),
+	)
+
+	getRecipeDietaryLabelsTool := mcp.NewTool("get_recipe_dietary_labels",
+		mcp.WithDescription("Show which allergens a recipe contains, which ones it is free from and which diets it suits, derived from its ingredients. Use this to answer questions like 'is this safe for a celiac'."),
+		mcp.WithString("title",
+			mcp.Required(),
+			mcp.Description("Title of the recipe"),
+		),
+	)
+
+	// Tool handlers
+	mcpServer.AddTool(setDietaryInfoTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		expectedVersion, err := expectedVersionFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		ingredient, err := ingredientStorage.GetByName(ctx, name)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to fetch ingredient")), nil
+		}
+		// the given lists are merged on top of this version, and the change
+		// checks the version again when it is applied
+		if expectedVersion != storage.AnyVersion && ingredient.Version != expectedVersion {
+			return mcp.NewToolResultText(ingredientErrorMessage(storage.ErrIngredientVersionConflict, "Failed to save dietary information")), nil
+		}
+
+		info := models.DietaryInfo{Allergens: []models.Allergen{}, Diets: []models.Diet{}}
+		if ingredient.Dietary != nil {
+			info = *ingredient.Dietary
+		}
+
+		args := request.GetArguments()
+		_, hasAllergens := args["allergens"]
+		_, hasDiets := args["diets"]
+		if !hasAllergens && !hasDiets {
+			return mcp.NewToolResultText("❌ Error: nothing to update, provide allergens or diets"), nil
+		}
+
+		if hasAllergens {
+			values, err := request.RequireStringSlice("allergens")
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+			}
+			info.Allergens = make([]models.Allergen, 0, len(values))
+			for _, value := range values {
+				info.Allergens = append(info.Allergens, models.Allergen(value))
+			}
+		}
+
+		if hasDiets {
+			values, err := request.RequireStringSlice("diets")
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+			}
+			info.Diets = make([]models.Diet, 0, len(values))
+			for _, value := range values {
+				info.Diets = append(info.Diets, models.Diet(value))
+			}
+		}
+
+		ingredient, err = ingredientStorage.SetDietaryInfo(ctx, ingredient.Name, info, expectedVersion)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to save dietary information")), nil
+		}
+
+		successMsg := fmt.Sprintf("✅ Updated dietary information of %s: contains %s; suitable for %s",
+			ingredient.Name, formatAllergens(ingredient.Dietary.Allergens), formatDiets(ingredient.Dietary.Diets))
+		return mcp.NewToolResultText(successMsg), nil
+	})
+
+	mcpServer.AddTool(getRecipeDietaryLabelsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		title, err := request.RequireString("title")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		recipe, err := recipeStorage.Get(title)
+		if err != nil {
+			return mcp.NewToolResultText(recipeErrorMessage(err, "Failed to fetch recipe")), nil
+		}
+
+		ingredients, err := ingredientsByID(ctx, ingredientStorage)
+		if err != nil {
+			return mcp.NewToolResultText("❌ Error: Failed to fetch ingredients"), nil
+		}
+
+		labels := dietary.ForRecipe(recipe, ingredients)
+
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("🏷️ Dietary labels for %s\n", recipe.Title))
+		result.WriteString("Contains: " + formatAllergens(labels.Contains) + "\n")
+		if labels.Complete() {
+			result.WriteString("Free from: " + formatAllergens(labels.FreeFrom) + "\n")
+			result.WriteString("Suitable for: " + formatDiets(labels.SuitableFor) + "\n")
+		} else {
+			result.WriteString(fmt.Sprintf("⚠️ Cannot guarantee the absence of any allergen or suitability for any diet: no dietary information for %s\n",
+				strings.Join(labels.Unreviewed, ", ")))
+		}
+		return mcp.NewToolResultText(result.String()), nil
+	})
+}
+
+func formatAllergens(allergens []models.Allergen) string {
+	if len(allergens) == 0 {
+		return "none"
+	}
+
+	names := make([]string, 0, len(allergens))
+	for _, allergen := range allergens {
+		names = append(names, string(allergen))
+	}
+	return strings.Join(names, ", ")
+}
+
+func formatDiets(diets []models.Diet) string {
+	if len(diets) == 0 {
+		return "none"
+	}
+
+	names := make([]string, 0, len(diets))
+	for _, diet := range diets {
+		names = append(names, string(diet))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/storage"
+)
+
+// revisionTimeLayout is the format of the dates shown in ingredient histories.
+const revisionTimeLayout = "2006-01-02 15:04:05"
+
+// withSession tags every change made by a tool with the MCP session that
+// called it, so the history shows who made it and undo_last_change only
+// reverts the changes of the calling session.
+func withSession(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			ctx = storage.WithSession(ctx, session.SessionID())
+		}
+		return next(ctx, request)
+	}
+}
+
+func addHistoryTools(mcpServer *server.MCPServer, ingredientStorage storage.IngredientStorage) {
+	// Tools
+	getIngredientHistoryTool := mcp.NewTool("get_ingredient_history",
+		mcp.WithDescription("Show every change made to exactly one ingredient, oldest first, with the previous and new values, when it was made and by which session. Ingredients in the trash can be found by their ID."),
+		mcp.WithNumber("id",
+			mcp.Description("ID of the ingredient"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Name or alias of the ingredient, used when no ID is given"),
+		),
+	)
+
+	undoLastChangeTool := mcp.NewTool("undo_last_change",
+		mcp.WithDescription("Revert the most recent ingredient change made in this session. Call it again to walk further back. A change is not undone once another session has changed the same ingredient since."),
+	)
+
+	// Tool handlers
+	mcpServer.AddTool(getIngredientHistoryTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var id int
+		if _, ok := request.GetArguments()["id"]; ok {
+			var err error
+			id, err = request.RequireInt("id")
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+			}
+		} else {
+			name, err := request.RequireString("name")
+			if err != nil {
+				return mcp.NewToolResultText("❌ Error: either id or name is required"), nil
+			}
+			ingredient, err := ingredientStorage.GetByName(ctx, name)
+			if err != nil {
+				return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to fetch ingredient")), nil
+			}
+			id = ingredient.ID
+		}
+
+		revisions, err := ingredientStorage.History(ctx, id)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to fetch the ingredient history")), nil
+		}
+
+		if len(revisions) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("📋 No changes recorded for ingredient %d", id)), nil
+		}
+
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("📋 History of %s (ID %d):\n", revisions[len(revisions)-1].After.Name, id))
+		for _, revision := range revisions {
+			result.WriteString(fmt.Sprintf("- #%d %s: %s%s\n", revision.ID, revision.CreatedAt.Format(revisionTimeLayout),
+				describeRevision(revision), formatRevisionSession(revision.SessionID)))
+		}
+		return mcp.NewToolResultText(result.String()), nil
+	})
+
+	mcpServer.AddTool(undoLastChangeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		revision, err := ingredientStorage.UndoLastChange(ctx)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to undo the last change")), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("✅ Undid change #%d to %s (ID %d)", revision.Reverts, revision.Before.Name, revision.IngredientID)), nil
+	})
+}
+
+// describeRevision summarizes what a revision changed.
+func describeRevision(revision *models.Revision) string {
+	before, after := revision.Before, revision.After
+	switch revision.Change {
+	case models.RevisionCreate:
+		return fmt.Sprintf("created %s", after.Name)
+	case models.RevisionRename:
+		return fmt.Sprintf("renamed %s to %s", before.Name, after.Name)
+	case models.RevisionDelete:
+		return "moved to the trash"
+	case models.RevisionRestore:
+		return "restored from the trash"
+	case models.RevisionAddAlias, models.RevisionRemoveAlias:
+		return fmt.Sprintf("aliases changed from %s to %s", formatRevisionAliases(before.Aliases), formatRevisionAliases(after.Aliases))
+	case models.RevisionSetCategory:
+		return fmt.Sprintf("category changed from %s to %s", before.Category, after.Category)
+	case models.RevisionSetDietaryInfo:
+		return "dietary info changed"
+	case models.RevisionSetNutrition:
+		return "nutrition facts changed"
+	case models.RevisionSetUnitConversion:
+		return "unit conversion changed"
+	case models.RevisionUndo:
+		return fmt.Sprintf("undid change #%d", revision.Reverts)
+	default:
+		return string(revision.Change)
+	}
+}
+
+func formatRevisionAliases(aliases []models.Alias) string {
+	if len(aliases) == 0 {
+		return "none"
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(formatAliases(aliases), " ("), ")")
+}
+
+func formatRevisionSession(sessionID string) string {
+	if sessionID == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (session %s)", sessionID)
+}
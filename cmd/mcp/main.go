@@ -2,56 +2,153 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
+	"github.com/victorcete/recipe-manager/internal/models"
 	"github.com/victorcete/recipe-manager/internal/storage"
 )
 
 func main() {
-	// TODO: add Recipe model and recipe management tools
-	// TODO: add search_recipes_by_ingredient tool
+	var config storageConfig
+	flag.StringVar(&config.backend, "storage", envOrDefault("INGREDIENT_STORAGE", storageBackendMemory),
+		"ingredient storage backend, either memory, journal, file or sqlite (env INGREDIENT_STORAGE)")
+	flag.StringVar(&config.journalDir, "journal-dir", envOrDefault("INGREDIENTS_JOURNAL_DIR", "ingredients-journal"),
+		"directory holding the journal and snapshots of the journal storage backend (env INGREDIENTS_JOURNAL_DIR)")
+	flag.StringVar(&config.ingredientsFile, "ingredients-file", envOrDefault("INGREDIENTS_FILE", "ingredients.json"),
+		"JSON file used by the file storage backend (env INGREDIENTS_FILE)")
+	flag.StringVar(&config.ingredientsDatabase, "ingredients-db", envOrDefault("INGREDIENTS_DB", "ingredients.db"),
+		"SQLite database used by the sqlite storage backend (env INGREDIENTS_DB)")
+	flag.StringVar(&config.ingredientIDs, "ingredient-ids", envOrDefault("INGREDIENT_IDS", ingredientIDsSequential),
+		"how new ingredients get their IDs, either sequential or time-ordered, which keeps IDs of storages with different -ingredient-id-node values apart; sqlite is always sequential (env INGREDIENT_IDS)")
+	flag.StringVar(&config.ingredientIDNode, "ingredient-id-node", envOrDefault("INGREDIENT_ID_NODE", "0"),
+		"node, from 0 to 63, written into time-ordered ingredient IDs; give each storage sharing IDs its own (env INGREDIENT_ID_NODE)")
+	flag.Parse()
 
-	ingredientStorage := storage.NewMemoryStorage()
-	ingredientStorage.SeedTestData()
-	mcpServer := server.NewMCPServer("ingredient-server", "0.1.0")
+	ingredientStorage, err := newIngredientStorage(context.Background(), config)
+	if err != nil {
+		log.Fatalf("Failed to open ingredient storage: %v", err)
+	}
+	recipeStorage := storage.NewRecipeMemoryStorage()
+	pantryStorage := storage.NewPantryMemoryStorage()
+	shoppingStorage := storage.NewShoppingListMemoryStorage()
+	mealPlanStorage := storage.NewMealPlanMemoryStorage()
+	mcpServer := server.NewMCPServer("ingredient-server", "0.1.0",
+		server.WithToolHandlerMiddleware(withSession),
+		server.WithResourceCapabilities(true, true),
+	)
 
 	// Tools
 	createIngredientTool := mcp.NewTool("create_ingredient",
-		mcp.WithDescription("Add exactly one ingredient to your collection. Call this tool separately for each ingredient you want to add. Do not try to add multiple ingredients in a single call."),
+		mcp.WithDescription("Add exactly one ingredient to your collection, with its optional attributes. To add several ingredients by name at once, use bulk_ingredients instead."),
 		mcp.WithString("name",
 			mcp.Required(),
 			mcp.Description("Name of the single ingredient to add (e.g., 'tomato', 'salt', 'chicken breast')"),
 		),
+		withCategoryArgument(),
+		withNutritionArguments(),
+		withUnitConversionArguments(),
 	)
 
 	deleteIngredientTool := mcp.NewTool("delete_ingredient",
-		mcp.WithDescription("Delete exactly one ingredient from your collection. Call this tool separately for each ingredient you want to delete. Do not try to delete multiple ingredients in a single call."),
+		mcp.WithDescription("Move exactly one ingredient from your collection to the trash, from where it can be restored. To delete several ingredients at once, use bulk_ingredients instead."),
 		mcp.WithString("name",
 			mcp.Required(),
 			mcp.Description("Name of the single ingredient to delete (e.g., 'tomato', 'salt', 'chicken breast')"),
 		),
+		withExpectedVersionArgument(),
 	)
 
 	listIngredientsTool := mcp.NewTool("list_ingredients",
-		mcp.WithDescription("List all existing ingredients from my collection."),
+		mcp.WithDescription("List existing ingredients from my collection in a stable order, optionally filtered by category or grouped by category. Long lists can be read a page at a time with limit and cursor."),
+		mcp.WithString("category",
+			mcp.Description("Only list ingredients from this category"),
+			mcp.Enum(categoryNames()...),
+		),
+		mcp.WithBoolean("group_by_category",
+			mcp.Description("Group the listed ingredients under their category"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("sort_by",
+			mcp.Description("Field to sort the ingredients by, ties are broken by ID"),
+			mcp.Enum(string(storage.SortByName), string(storage.SortByID), string(storage.SortByCreatedAt), string(storage.SortByUpdatedAt)),
+			mcp.DefaultString(string(storage.SortByName)),
+		),
+		mcp.WithString("order",
+			mcp.Description("Sort order"),
+			mcp.Enum("asc", "desc"),
+			mcp.DefaultString("asc"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of ingredients to list, all of them if omitted"),
+			mcp.Min(1),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Cursor returned by the previous page, to list the next one with the same category, sort_by and order"),
+		),
+	)
+
+	getIngredientTool := mcp.NewTool("get_ingredient",
+		mcp.WithDescription("Show every detail of exactly one ingredient, found by its ID or by its name or alias."),
+		mcp.WithNumber("id",
+			mcp.Description("ID of the ingredient"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Name or alias of the ingredient, used when no ID is given"),
+		),
 	)
 
 	updateIngredientTool := mcp.NewTool("update_ingredient",
-		mcp.WithDescription("Update exactly one ingredient from your collection. Call this tool separately for each ingredient you want to update. Do not try to update multiple ingredients in a single call."),
+		mcp.WithDescription("Update exactly one ingredient from your collection. Only the provided fields are changed. To rename several ingredients at once, use bulk_ingredients instead."),
 		mcp.WithString("original_name",
 			mcp.Required(),
 			mcp.Description("Name of the already-existing single ingredient"),
 		),
 		mcp.WithString("new_name",
-			mcp.Required(),
 			mcp.Description("New name for the single ingredient"),
 		),
+		withCategoryArgument(),
+		withNutritionArguments(),
+		withUnitConversionArguments(),
+		withExpectedVersionArgument(),
+	)
+
+	addIngredientAliasTool := mcp.NewTool("add_ingredient_alias",
+		mcp.WithDescription("Add an alternative name, such as a translation, to exactly one ingredient. The ingredient can then be found, updated or deleted by that name too."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name or existing alias of the ingredient (e.g., 'pimienta negra')"),
+		),
+		mcp.WithString("alias",
+			mcp.Required(),
+			mcp.Description("Alternative name for the ingredient (e.g., 'black pepper')"),
+		),
+		mcp.WithString("locale",
+			mcp.Description("Language tag of the alias (e.g., 'en', 'es-MX')"),
+		),
+		withExpectedVersionArgument(),
+	)
+
+	removeIngredientAliasTool := mcp.NewTool("remove_ingredient_alias",
+		mcp.WithDescription("Remove an alternative name from exactly one ingredient."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the ingredient"),
+		),
+		mcp.WithString("alias",
+			mcp.Required(),
+			mcp.Description("Alias to remove"),
+		),
+		withExpectedVersionArgument(),
 	)
 
 	// Tool handlers
@@ -61,22 +158,14 @@ func main() {
 			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
 		}
 
-		ingredient, err := ingredientStorage.Create(name)
-		if err != nil {
-			var errorMsg string
-			switch err {
-			// user-friendly storage errors.
-			case storage.ErrIngredientNameCannotBeEmpty,
-				storage.ErrIngredientNameContainsInvalidChars,
-				storage.ErrIngredientNameIsTooShort,
-				storage.ErrIngredientNameIsTooLong,
-				storage.ErrIngredientNameExists:
-				errorMsg = "❌ Error: " + err.Error()
-			// default catch for database or system errors, etc.
-			default:
-				errorMsg = "❌ Error: Failed to create ingredient"
-			}
-			return mcp.NewToolResultText(errorMsg), nil
+		details, err := ingredientDetailsFromRequest(request, nil)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		ingredient, err := ingredientStorage.CreateWithDetails(ctx, name, details)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to create ingredient")), nil
 		}
 
 		successMsg := fmt.Sprintf("✅ Added %s to your ingredients", ingredient.Name)
@@ -89,85 +178,353 @@ func main() {
 			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
 		}
 
-		err = ingredientStorage.Delete(name)
-		if err != nil {
-			var errorMsg string
-			switch err {
-			// user-friendly storage errors.
-			case storage.ErrIngredientNameCannotBeEmpty,
-				storage.ErrIngredientNameContainsInvalidChars,
-				storage.ErrIngredientNotFound,
-				storage.ErrIngredientNameIsTooShort,
-				storage.ErrIngredientNameIsTooLong,
-				storage.ErrIngredientNameExists:
-				errorMsg = "❌ Error: " + err.Error()
-			// default catch for database or system errors, etc.
-			default:
-				errorMsg = "❌ Error: Failed to delete ingredient"
-			}
-			return mcp.NewToolResultText(errorMsg), nil
+		expectedVersion, err := expectedVersionFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		err = ingredientStorage.Delete(ctx, name, expectedVersion)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to delete ingredient")), nil
 		}
 
-		successMsg := fmt.Sprintf("✅ Deleted %s from your ingredients", name)
+		successMsg := fmt.Sprintf("✅ Moved %s to the trash, restore it with restore_ingredient if needed", name)
 		return mcp.NewToolResultText(successMsg), nil
 	})
 
 	mcpServer.AddTool(listIngredientsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		ingredients, err := ingredientStorage.List()
+		options := storage.ListOptions{
+			SortBy:     storage.IngredientSortField(request.GetString("sort_by", string(storage.SortByName))),
+			Descending: request.GetString("order", "asc") == "desc",
+			Limit:      request.GetInt("limit", 0),
+			Cursor:     request.GetString("cursor", ""),
+		}
+		if _, ok := request.GetArguments()["category"]; ok {
+			value, err := request.RequireString("category")
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+			}
+			category := models.Category(value)
+			options.Category = &category
+		}
+
+		page, err := ingredientStorage.ListPage(ctx, options)
 		if err != nil {
-			return mcp.NewToolResultText("❌ Error: Failed to fetch ingredients"), nil
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to fetch ingredients")), nil
 		}
 
+		ingredients := page.Ingredients
 		if len(ingredients) == 0 {
 			return mcp.NewToolResultText("No ingredients found"), nil
 		}
 
 		var result strings.Builder
-		result.WriteString(fmt.Sprintf("📋 Your ingredients (%d total):\n", len(ingredients)))
+		if len(ingredients) == page.Total {
+			result.WriteString(fmt.Sprintf("📋 Your ingredients (%d total):\n", page.Total))
+		} else {
+			result.WriteString(fmt.Sprintf("📋 Your ingredients (%d-%d of %d total):\n", page.Offset+1, page.Offset+len(ingredients), page.Total))
+		}
+
+		// items are numbered by their position in the whole listing, which
+		// grouping leaves as it is
+		positions := make(map[int]int, len(ingredients))
 		for i, ingredient := range ingredients {
-			result.WriteString(fmt.Sprintf("%d. %s\n", i+1, ingredient.Name))
+			positions[ingredient.ID] = page.Offset + i + 1
+		}
+		if request.GetBool("group_by_category", false) {
+			for _, group := range groupByCategory(ingredients) {
+				result.WriteString(fmt.Sprintf("\n%s:\n", group.category))
+				for _, ingredient := range group.ingredients {
+					result.WriteString(fmt.Sprintf("%d. %s%s\n", positions[ingredient.ID], ingredient.Name, formatAliases(ingredient.Aliases)))
+				}
+			}
+		} else {
+			for _, ingredient := range ingredients {
+				result.WriteString(fmt.Sprintf("%d. %s%s\n", positions[ingredient.ID], ingredient.Name, formatAliases(ingredient.Aliases)))
+			}
+		}
+
+		if page.NextCursor != "" {
+			result.WriteString(fmt.Sprintf("\nMore ingredients follow, list them with cursor %q\n", page.NextCursor))
 		}
 		return mcp.NewToolResultText(result.String()), nil
 	})
 
+	mcpServer.AddTool(getIngredientTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var ingredient *models.Ingredient
+		if _, ok := request.GetArguments()["id"]; ok {
+			id, err := request.RequireInt("id")
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+			}
+			ingredient, err = ingredientStorage.Get(ctx, id)
+			if err != nil {
+				return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to fetch ingredient")), nil
+			}
+		} else {
+			name, err := request.RequireString("name")
+			if err != nil {
+				return mcp.NewToolResultText("❌ Error: either id or name is required"), nil
+			}
+			ingredient, err = ingredientStorage.GetByName(ctx, name)
+			if err != nil {
+				return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to fetch ingredient")), nil
+			}
+		}
+
+		return mcp.NewToolResultText(formatIngredient(ingredient)), nil
+	})
+
 	mcpServer.AddTool(updateIngredientTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		originalName, err := request.RequireString("original_name")
 		if err != nil {
 			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
 		}
+		_, hasNewName := request.GetArguments()["new_name"]
 		newName, err := request.RequireString("new_name")
+		if hasNewName && err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		expectedVersion, err := expectedVersionFromRequest(request)
 		if err != nil {
 			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
 		}
 
-		ingredient, err := ingredientStorage.Update(originalName, newName)
-		if err != nil {
-			var errorMsg string
-			switch err {
-			// user-friendly storage errors.
-			case storage.ErrIngredientNameCannotBeEmpty,
-				storage.ErrIngredientNameContainsInvalidChars,
-				storage.ErrIngredientNotFound,
-				storage.ErrIngredientNameIsTooShort,
-				storage.ErrIngredientNameIsTooLong,
-				storage.ErrIngredientNameExists:
-				errorMsg = "❌ Error: " + err.Error()
-			// default catch for database or system errors, etc.
-			default:
-				errorMsg = "❌ Error: Failed to update ingredient"
-			}
-			return mcp.NewToolResultText(errorMsg), nil
+		ingredient, err := ingredientStorage.GetByName(ctx, originalName)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to update ingredient")), nil
+		}
+		// the given fields are merged on top of this version, and the update
+		// checks the version again when it is applied
+		if expectedVersion != storage.AnyVersion && ingredient.Version != expectedVersion {
+			return mcp.NewToolResultText(ingredientErrorMessage(storage.ErrIngredientVersionConflict, "Failed to update ingredient")), nil
 		}
 
-		successMsg := fmt.Sprintf("✅ Updated ingredient %s to %s", originalName, ingredient.Name)
+		details, err := ingredientDetailsFromRequest(request, ingredient)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		if !hasNewName && noIngredientDetails(details) {
+			return mcp.NewToolResultText("❌ Error: nothing to update, provide a new name or any other ingredient field"), nil
+		}
+		// an empty new name keeps the current one in the storage, but here
+		// it was asked for
+		if hasNewName && newName == "" {
+			return mcp.NewToolResultText(ingredientErrorMessage(storage.ErrIngredientNameCannotBeEmpty, "Failed to update ingredient")), nil
+		}
+
+		ingredient, err = ingredientStorage.UpdateWithDetails(ctx, originalName, newName, details, expectedVersion)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to update ingredient")), nil
+		}
+
+		successMsg := fmt.Sprintf("✅ Updated ingredient %s (version %d)", ingredient.Name, ingredient.Version)
+		if hasNewName {
+			successMsg = fmt.Sprintf("✅ Updated ingredient %s to %s (version %d)", originalName, ingredient.Name, ingredient.Version)
+		}
+		return mcp.NewToolResultText(successMsg), nil
+	})
+
+	mcpServer.AddTool(addIngredientAliasTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		alias, err := request.RequireString("alias")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		locale := request.GetString("locale", "")
+		expectedVersion, err := expectedVersionFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		ingredient, err := ingredientStorage.AddAlias(ctx, name, alias, locale, expectedVersion)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to add alias")), nil
+		}
+
+		successMsg := fmt.Sprintf("✅ Added alias %s to %s", alias, ingredient.Name)
 		return mcp.NewToolResultText(successMsg), nil
 	})
 
+	mcpServer.AddTool(removeIngredientAliasTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		alias, err := request.RequireString("alias")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		expectedVersion, err := expectedVersionFromRequest(request)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		ingredient, err := ingredientStorage.RemoveAlias(ctx, name, alias, expectedVersion)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to remove alias")), nil
+		}
+
+		successMsg := fmt.Sprintf("✅ Removed alias %s from %s", alias, ingredient.Name)
+		return mcp.NewToolResultText(successMsg), nil
+	})
+
+	addBulkTools(mcpServer, ingredientStorage)
+	addTrashTools(mcpServer, ingredientStorage)
+	addHistoryTools(mcpServer, ingredientStorage)
+	addRecipeTools(mcpServer, recipeStorage, ingredientStorage)
+	addNutritionTools(mcpServer, recipeStorage, ingredientStorage)
+	addUnitTools(mcpServer, ingredientStorage)
+	addDietaryTools(mcpServer, recipeStorage, ingredientStorage)
+	addPantryTools(mcpServer, pantryStorage, ingredientStorage)
+	addShoppingTools(mcpServer, shoppingStorage, recipeStorage, ingredientStorage, pantryStorage)
+	addMealPlanTools(mcpServer, mealPlanStorage, recipeStorage, ingredientStorage)
+
+	// Resources
+	subscriptions := newIngredientSubscriptions(ingredientStorage)
+	if err := addIngredientResources(context.Background(), mcpServer, ingredientStorage, subscriptions); err != nil {
+		log.Fatalf("Failed to expose ingredients as resources: %v", err)
+	}
+
 	// create server and start listening
 	log.Println("Starting MCP server for ingredient management...")
 	stdioServer := server.NewStdioServer(mcpServer)
 
-	if err := stdioServer.Listen(context.Background(), os.Stdin, os.Stdout); err != nil {
+	// subscriptions are answered before the messages reach mcp-go
+	stdin := subscriptions.serve(context.Background(), os.Stdin, os.Stdout)
+	if err := stdioServer.Listen(context.Background(), stdin, os.Stdout); err != nil {
 		log.Fatalf("MCP server failed: %v", err)
 	}
 }
+
+func ingredientErrorMessage(err error, fallback string) string {
+	switch err {
+	// user-friendly storage errors.
+	case storage.ErrIngredientNameCannotBeEmpty,
+		storage.ErrIngredientNameContainsInvalidChars,
+		storage.ErrIngredientNotFound,
+		storage.ErrIngredientNotInTrash,
+		storage.ErrNothingToUndo,
+		storage.ErrUndoConflict,
+		storage.ErrIngredientVersionConflict,
+		storage.ErrIngredientNameIsTooShort,
+		storage.ErrIngredientNameIsTooLong,
+		storage.ErrIngredientNameExists,
+		storage.ErrIngredientCategoryInvalid,
+		storage.ErrListSortInvalid,
+		storage.ErrListLimitInvalid,
+		storage.ErrListCursorInvalid,
+		storage.ErrIngredientAliasExists,
+		storage.ErrIngredientAliasNotFound,
+		storage.ErrIngredientAliasLocaleInvalid,
+		storage.ErrIngredientTooManyAliases,
+		storage.ErrDietaryAllergenInvalid,
+		storage.ErrDietaryDietInvalid,
+		storage.ErrDietaryDietConflict,
+		storage.ErrNutritionValueIsNegative,
+		storage.ErrNutritionCaloriesAreTooHigh,
+		storage.ErrNutritionNutrientsExceedMass,
+		storage.ErrNutritionSugarExceedsCarbs,
+		storage.ErrNutritionSodiumIsTooHigh,
+		storage.ErrPieceWeightIsNegative,
+		storage.ErrPieceWeightIsTooHigh,
+		storage.ErrPieceNameRequiresWeight,
+		storage.ErrPieceNameIsTooLong,
+		storage.ErrPieceNameContainsInvalidChars,
+		storage.ErrDensityIsNegative,
+		storage.ErrDensityIsTooHigh:
+		return "❌ Error: " + err.Error()
+	// default catch for database or system errors, etc.
+	default:
+		return "❌ Error: " + fallback
+	}
+}
+
+// formatIngredient renders every field of an ingredient, one per line.
+func formatIngredient(ingredient *models.Ingredient) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("📋 %s (ID %d)\n", ingredient.Name, ingredient.ID))
+
+	aliases := "none"
+	if len(ingredient.Aliases) > 0 {
+		aliases = strings.TrimSuffix(strings.TrimPrefix(formatAliases(ingredient.Aliases), " ("), ")")
+	}
+	result.WriteString(fmt.Sprintf("- Aliases: %s\n", aliases))
+	result.WriteString(fmt.Sprintf("- Category: %s\n", ingredient.Category))
+
+	if ingredient.Dietary == nil {
+		result.WriteString("- Dietary info: not reviewed\n")
+	} else {
+		result.WriteString(fmt.Sprintf("- Allergens: %s\n", formatAllergens(ingredient.Dietary.Allergens)))
+		result.WriteString(fmt.Sprintf("- Diets: %s\n", formatDiets(ingredient.Dietary.Diets)))
+	}
+
+	if facts := ingredient.Nutrition; facts == nil {
+		result.WriteString("- Nutrition per 100g: unknown\n")
+	} else {
+		result.WriteString(fmt.Sprintf("- Nutrition per 100g: %.0f kcal, protein %.1fg, fat %.1fg, carbs %.1fg, fiber %.1fg, sugar %.1fg, sodium %.0fmg\n",
+			facts.CaloriesPer100g, facts.ProteinPer100g, facts.FatPer100g, facts.CarbsPer100g, facts.FiberPer100g, facts.SugarPer100g, facts.SodiumMgPer100g))
+	}
+
+	if ingredient.PieceWeightGrams > 0 {
+		piece := ingredient.PieceName
+		if piece == "" {
+			piece = "piece"
+		}
+		result.WriteString(fmt.Sprintf("- Weight per %s: %sg\n", piece, strconv.FormatFloat(ingredient.PieceWeightGrams, 'f', -1, 64)))
+	}
+	if ingredient.DensityGPerMl > 0 {
+		result.WriteString(fmt.Sprintf("- Density: %s g/ml\n", strconv.FormatFloat(ingredient.DensityGPerMl, 'f', -1, 64)))
+	}
+
+	result.WriteString(fmt.Sprintf("- Created: %s\n", ingredient.CreatedAt.Format(time.RFC3339)))
+	result.WriteString(fmt.Sprintf("- Updated: %s\n", ingredient.UpdatedAt.Format(time.RFC3339)))
+	result.WriteString(fmt.Sprintf("- Version: %d\n", ingredient.Version))
+	return result.String()
+}
+
+// withExpectedVersionArgument declares the optional expected_version argument
+// of the tools changing a single ingredient.
+func withExpectedVersionArgument() mcp.ToolOption {
+	return mcp.WithNumber("expected_version",
+		mcp.Description("Version of the ingredient as last seen with get_ingredient. The change is refused if someone else changed the ingredient since; fetch it again and retry"),
+		mcp.Min(1),
+	)
+}
+
+// expectedVersionFromRequest reads the optional expected_version argument,
+// giving storage.AnyVersion when it is missing.
+func expectedVersionFromRequest(request mcp.CallToolRequest) (int, error) {
+	if _, ok := request.GetArguments()["expected_version"]; !ok {
+		return storage.AnyVersion, nil
+	}
+
+	version, err := request.RequireInt("expected_version")
+	if err != nil {
+		return 0, err
+	}
+	if version < 1 {
+		return 0, errors.New("expected_version must be at least 1")
+	}
+	return version, nil
+}
+
+// formatAliases renders aliases as a parenthesized suffix, e.g. " (en: black pepper)".
+func formatAliases(aliases []models.Alias) string {
+	if len(aliases) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		if alias.Locale == "" {
+			parts = append(parts, alias.Name)
+		} else {
+			parts = append(parts, alias.Locale+": "+alias.Name)
+		}
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/shopping"
+	"github.com/victorcete/recipe-manager/internal/storage"
+)
+
+// withMealSlotArgument adds a required meal slot argument to a tool.
+func withMealSlotArgument(name, description string) mcp.ToolOption {
+	slots := make([]string, 0, len(models.MealSlots))
+	for _, slot := range models.MealSlots {
+		slots = append(slots, string(slot))
+	}
+
+	return mcp.WithString(name,
+		mcp.Required(),
+		mcp.Description(description),
+		mcp.Enum(slots...),
+	)
+}
+
+// requireMealSlot reads a mandatory meal slot argument.
+func requireMealSlot(request mcp.CallToolRequest, name string) (models.MealSlot, error) {
+	value, err := request.RequireString(name)
+	if err != nil {
+		return "", err
+	}
+
+	slot, ok := models.ParseMealSlot(value)
+	if !ok {
+		return "", storage.ErrMealPlanSlotInvalid
+	}
+	return slot, nil
+}
+
+func addMealPlanTools(mcpServer *server.MCPServer, mealPlanStorage storage.MealPlanStorage, recipeStorage storage.RecipeStorage, ingredientStorage storage.IngredientStorage) {
+	// Tools
+	planMealTool := mcp.NewTool("plan_meal",
+		mcp.WithDescription("Plan exactly one recipe for a meal of a given day. Whatever was planned for that meal is replaced."),
+		mcp.WithString("date",
+			mcp.Required(),
+			mcp.Description("Day of the meal as YYYY-MM-DD"),
+		),
+		withMealSlotArgument("slot", "Meal of the day"),
+		mcp.WithString("recipe",
+			mcp.Required(),
+			mcp.Description("Title of a recipe that already exists in your collection"),
+		),
+		mcp.WithNumber("servings",
+			mcp.Description("Number of servings to cook, defaults to the servings of the recipe"),
+		),
+	)
+
+	moveMealTool := mcp.NewTool("move_meal",
+		mcp.WithDescription("Move a planned meal to another day or meal. The target meal must not have anything planned yet."),
+		mcp.WithString("from_date",
+			mcp.Required(),
+			mcp.Description("Day the meal is currently planned for, as YYYY-MM-DD"),
+		),
+		withMealSlotArgument("from_slot", "Meal it is currently planned for"),
+		mcp.WithString("to_date",
+			mcp.Required(),
+			mcp.Description("Day to move the meal to, as YYYY-MM-DD"),
+		),
+		withMealSlotArgument("to_slot", "Meal to move it to"),
+	)
+
+	clearMealTool := mcp.NewTool("clear_meal",
+		mcp.WithDescription("Remove whatever is planned for a meal of a given day."),
+		mcp.WithString("date",
+			mcp.Required(),
+			mcp.Description("Day of the meal as YYYY-MM-DD"),
+		),
+		withMealSlotArgument("slot", "Meal of the day"),
+	)
+
+	viewWeekTool := mcp.NewTool("view_meal_plan_week",
+		mcp.WithDescription("Show the meals planned from Monday to Sunday of a week, along with the combined ingredients needed to cook them."),
+		mcp.WithString("date",
+			mcp.Description("Any day of the week to show, as YYYY-MM-DD. Defaults to the current week"),
+		),
+	)
+
+	// Tool handlers
+	mcpServer.AddTool(planMealTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		date, err := requireDate(request, "date")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		slot, err := requireMealSlot(request, "slot")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		title, err := request.RequireString("recipe")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		recipe, err := recipeStorage.Get(title)
+		if err != nil {
+			return mcp.NewToolResultText(recipeErrorMessage(err, "Failed to fetch recipe")), nil
+		}
+
+		meal, err := mealPlanStorage.Assign(date, slot, recipe.ID, request.GetInt("servings", recipe.Servings))
+		if err != nil {
+			return mcp.NewToolResultText(mealPlanErrorMessage(err, "Failed to plan the meal")), nil
+		}
+
+		successMsg := fmt.Sprintf("✅ Planned %s (%d servings) for the %s of %s", recipe.Title, meal.Servings, meal.Slot, formatMealDate(meal.Date))
+		return mcp.NewToolResultText(successMsg), nil
+	})
+
+	mcpServer.AddTool(moveMealTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		fromDate, err := requireDate(request, "from_date")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		fromSlot, err := requireMealSlot(request, "from_slot")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		toDate, err := requireDate(request, "to_date")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		toSlot, err := requireMealSlot(request, "to_slot")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		meal, err := mealPlanStorage.Move(fromDate, fromSlot, toDate, toSlot)
+		if err != nil {
+			return mcp.NewToolResultText(mealPlanErrorMessage(err, "Failed to move the meal")), nil
+		}
+
+		successMsg := fmt.Sprintf("✅ Moved the %s of %s to the %s of %s", fromSlot, formatMealDate(fromDate), meal.Slot, formatMealDate(meal.Date))
+		return mcp.NewToolResultText(successMsg), nil
+	})
+
+	mcpServer.AddTool(clearMealTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		date, err := requireDate(request, "date")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		slot, err := requireMealSlot(request, "slot")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		if err := mealPlanStorage.Clear(date, slot); err != nil {
+			return mcp.NewToolResultText(mealPlanErrorMessage(err, "Failed to clear the meal")), nil
+		}
+
+		successMsg := fmt.Sprintf("✅ Cleared the %s of %s", slot, formatMealDate(date))
+		return mcp.NewToolResultText(successMsg), nil
+	})
+
+	mcpServer.AddTool(viewWeekTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		date, err := parseDate(request.GetString("date", ""))
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: invalid date: %v", err)), nil
+		}
+		if date.IsZero() {
+			date = time.Now()
+		}
+
+		monday := models.WeekStart(date)
+		sunday := monday.AddDate(0, 0, 6)
+		meals, err := mealPlanStorage.Between(monday, sunday)
+		if err != nil {
+			return mcp.NewToolResultText(mealPlanErrorMessage(err, "Failed to fetch the meal plan")), nil
+		}
+
+		recipes, err := recipeStorage.List()
+		if err != nil {
+			return mcp.NewToolResultText("❌ Error: Failed to fetch recipes"), nil
+		}
+		recipesByID := make(map[int]*models.Recipe, len(recipes))
+		for _, recipe := range recipes {
+			recipesByID[recipe.ID] = recipe
+		}
+
+		ingredients, err := ingredientsByID(ctx, ingredientStorage)
+		if err != nil {
+			return mcp.NewToolResultText("❌ Error: Failed to fetch ingredients"), nil
+		}
+
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("📅 Meal plan from %s to %s:\n", formatMealDate(monday), formatMealDate(sunday)))
+		if len(meals) == 0 {
+			result.WriteString("Nothing planned yet\n")
+			return mcp.NewToolResultText(result.String()), nil
+		}
+
+		portions := make([]shopping.Portion, 0, len(meals))
+		var day time.Time
+		for _, meal := range meals {
+			if !meal.Date.Equal(day) {
+				day = meal.Date
+				result.WriteString("\n" + formatMealDate(day) + "\n")
+			}
+
+			recipe, ok := recipesByID[meal.RecipeID]
+			if !ok {
+				result.WriteString(fmt.Sprintf("- %s: unknown recipe #%d\n", meal.Slot, meal.RecipeID))
+				continue
+			}
+			result.WriteString(fmt.Sprintf("- %s: %s (%d servings)\n", meal.Slot, recipe.Title, meal.Servings))
+			portions = append(portions, shopping.Portion{Recipe: recipe, Servings: meal.Servings})
+		}
+
+		result.WriteString("\n🛒 Ingredients needed:\n")
+		for _, need := range shopping.Needs(portions, ingredients) {
+			name := fmt.Sprintf("unknown ingredient #%d", need.IngredientID)
+			if ingredient, ok := ingredients[need.IngredientID]; ok {
+				name = ingredient.Name
+			}
+			result.WriteString(fmt.Sprintf("- %s %s\n", formatQuantity(math.Round(need.Quantity*100)/100, need.Unit), name))
+		}
+		return mcp.NewToolResultText(result.String()), nil
+	})
+}
+
+func formatMealDate(date time.Time) string {
+	return date.Format("Monday " + dateLayout)
+}
+
+func mealPlanErrorMessage(err error, fallback string) string {
+	switch {
+	// user-friendly storage errors.
+	case errors.Is(err, storage.ErrMealPlanSlotInvalid),
+		errors.Is(err, storage.ErrMealPlanRecipeInvalidID),
+		errors.Is(err, storage.ErrMealPlanServingsOutOfRange),
+		errors.Is(err, storage.ErrMealPlanSlotEmpty),
+		errors.Is(err, storage.ErrMealPlanSlotTaken),
+		errors.Is(err, storage.ErrMealPlanDateRangeIsInverted):
+		return "❌ Error: " + err.Error()
+	// default catch for database or system errors, etc.
+	default:
+		return "❌ Error: " + fallback
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/nutrition"
+	"github.com/victorcete/recipe-manager/internal/storage"
+)
+
+// nutritionArguments lists the optional nutrition arguments accepted by the
+// ingredient tools, along with the field each one sets.
+var nutritionArguments = []struct {
+	name        string
+	description string
+	field       func(facts *models.NutritionFacts) *float64
+}{
+	{"calories_per_100g", "Calories (kcal) per 100g", func(f *models.NutritionFacts) *float64 { return &f.CaloriesPer100g }},
+	{"protein_per_100g", "Protein in grams per 100g", func(f *models.NutritionFacts) *float64 { return &f.ProteinPer100g }},
+	{"fat_per_100g", "Fat in grams per 100g", func(f *models.NutritionFacts) *float64 { return &f.FatPer100g }},
+	{"carbs_per_100g", "Carbohydrates in grams per 100g", func(f *models.NutritionFacts) *float64 { return &f.CarbsPer100g }},
+	{"fiber_per_100g", "Fiber in grams per 100g", func(f *models.NutritionFacts) *float64 { return &f.FiberPer100g }},
+	{"sugar_per_100g", "Sugar in grams per 100g", func(f *models.NutritionFacts) *float64 { return &f.SugarPer100g }},
+	{"sodium_mg_per_100g", "Sodium in milligrams per 100g", func(f *models.NutritionFacts) *float64 { return &f.SodiumMgPer100g }},
+}
+
+// withNutritionArguments adds every nutrition argument to a tool.
+func withNutritionArguments() mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		for _, argument := range nutritionArguments {
+			mcp.WithNumber(argument.name,
+				mcp.Description(argument.description),
+				mcp.Min(0),
+			)(tool)
+		}
+	}
+}
+
+// nutritionFromRequest applies the nutrition arguments present in the request
+// on top of base, which may be nil. It reports whether any argument was given.
+func nutritionFromRequest(request mcp.CallToolRequest, base *models.NutritionFacts) (models.NutritionFacts, bool, error) {
+	var facts models.NutritionFacts
+	if base != nil {
+		facts = *base
+	}
+
+	provided := false
+	args := request.GetArguments()
+	for _, argument := range nutritionArguments {
+		if _, ok := args[argument.name]; !ok {
+			continue
+		}
+		value, err := request.RequireFloat(argument.name)
+		if err != nil {
+			return facts, false, err
+		}
+		*argument.field(&facts) = value
+		provided = true
+	}
+
+	return facts, provided, nil
+}
+
+func addNutritionTools(mcpServer *server.MCPServer, recipeStorage storage.RecipeStorage, ingredientStorage storage.IngredientStorage) {
+	// Tools
+	getRecipeNutritionTool := mcp.NewTool("get_recipe_nutrition",
+		mcp.WithDescription("Compute the nutrition totals of a recipe, for the whole recipe and per serving, from the nutrition facts of its ingredients."),
+		mcp.WithString("title",
+			mcp.Required(),
+			mcp.Description("Title of the recipe"),
+		),
+	)
+
+	// Tool handlers
+	mcpServer.AddTool(getRecipeNutritionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		title, err := request.RequireString("title")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		recipe, err := recipeStorage.Get(title)
+		if err != nil {
+			return mcp.NewToolResultText(recipeErrorMessage(err, "Failed to fetch recipe")), nil
+		}
+
+		ingredients, err := ingredientsByID(ctx, ingredientStorage)
+		if err != nil {
+			return mcp.NewToolResultText("❌ Error: Failed to fetch ingredients"), nil
+		}
+
+		summary := nutrition.ForRecipe(recipe, ingredients)
+
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("🥗 Nutrition for %s (%d servings)\n", recipe.Title, recipe.Servings))
+		result.WriteString("Total: " + formatNutritionTotals(summary.Total) + "\n")
+		result.WriteString("Per serving: " + formatNutritionTotals(summary.PerServing) + "\n")
+		if len(summary.Skipped) > 0 {
+			result.WriteString("⚠️ Not included:\n")
+			for _, skipped := range summary.Skipped {
+				result.WriteString(fmt.Sprintf("- %s: %s\n", skipped.Name, skipped.Reason))
+			}
+		}
+		return mcp.NewToolResultText(result.String()), nil
+	})
+}
+
+func formatNutritionTotals(totals nutrition.Totals) string {
+	return fmt.Sprintf("%.0f kcal, protein %.1fg, fat %.1fg, carbs %.1fg, fiber %.1fg, sugar %.1fg, sodium %.0fmg",
+		totals.Calories, totals.Protein, totals.Fat, totals.Carbs, totals.Fiber, totals.Sugar, totals.SodiumMg)
+}
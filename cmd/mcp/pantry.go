@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/storage"
+	"github.com/victorcete/recipe-manager/internal/units"
+)
+
+func addPantryTools(mcpServer *server.MCPServer, pantryStorage storage.PantryStorage, ingredientStorage storage.IngredientStorage) {
+	// Tools
+	stockPantryTool := mcp.NewTool("stock_pantry",
+		mcp.WithDescription("Add a batch of exactly one ingredient to the pantry, e.g. after going shopping. Buying the same ingredient again adds a new batch with its own dates."),
+		mcp.WithString("ingredient",
+			mcp.Required(),
+			mcp.Description("Name of the ingredient bought"),
+		),
+		mcp.WithNumber("quantity",
+			mcp.Required(),
+			mcp.Description("Amount bought, greater than zero"),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit of the quantity (e.g., 'g', 'l'). Leave empty to count pieces"),
+		),
+		mcp.WithString("purchased_on",
+			mcp.Description("Purchase date as YYYY-MM-DD, defaults to today"),
+		),
+		mcp.WithString("best_before",
+			mcp.Description("Best-before date as YYYY-MM-DD, if the product has one"),
+		),
+	)
+
+	consumePantryTool := mcp.NewTool("consume_pantry",
+		mcp.WithDescription("Take a quantity of exactly one ingredient out of the pantry, e.g. after cooking. The batches closest to their best-before date are used first. Nothing is taken if there is not enough."),
+		mcp.WithString("ingredient",
+			mcp.Required(),
+			mcp.Description("Name of the ingredient used"),
+		),
+		mcp.WithNumber("quantity",
+			mcp.Required(),
+			mcp.Description("Amount used, greater than zero"),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit of the quantity (e.g., 'g', 'l'). Leave empty to count pieces"),
+		),
+	)
+
+	adjustPantryItemTool := mcp.NewTool("adjust_pantry_item",
+		mcp.WithDescription("Correct the quantity of exactly one pantry batch to what is really left, keeping its unit. Setting it to zero removes the batch."),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("ID of the pantry batch, as shown by list_pantry"),
+		),
+		mcp.WithNumber("quantity",
+			mcp.Required(),
+			mcp.Description("Quantity actually left, in the unit of the batch"),
+			mcp.Min(0),
+		),
+	)
+
+	listPantryTool := mcp.NewTool("list_pantry",
+		mcp.WithDescription("List what is in the pantry, the batches closest to their best-before date first. Optionally only list what expires soon."),
+		mcp.WithNumber("expiring_within_days",
+			mcp.Description("Only list batches reaching their best-before date within this many days, including those already past it"),
+			mcp.Min(0),
+		),
+	)
+
+	// Tool handlers
+	mcpServer.AddTool(stockPantryTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("ingredient")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		quantity, err := request.RequireFloat("quantity")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		unit := request.GetString("unit", "")
+
+		purchasedOn, err := parseDate(request.GetString("purchased_on", ""))
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: invalid purchased_on: %v", err)), nil
+		}
+
+		var bestBefore *time.Time
+		if value := request.GetString("best_before", ""); value != "" {
+			date, err := parseDate(value)
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("❌ Error: invalid best_before: %v", err)), nil
+			}
+			bestBefore = &date
+		}
+
+		ingredient, err := ingredientStorage.GetByName(ctx, name)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to fetch ingredient")), nil
+		}
+
+		item, err := pantryStorage.Stock(ingredient.ID, quantity, unit, purchasedOn, bestBefore)
+		if err != nil {
+			return mcp.NewToolResultText(pantryErrorMessage(err, "Failed to stock the pantry")), nil
+		}
+
+		successMsg := fmt.Sprintf("✅ Stocked %s %s in the pantry", formatQuantity(item.Quantity, item.Unit), ingredient.Name)
+		return mcp.NewToolResultText(successMsg), nil
+	})
+
+	mcpServer.AddTool(consumePantryTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("ingredient")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		quantity, err := request.RequireFloat("quantity")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		unit := request.GetString("unit", "")
+
+		ingredient, err := ingredientStorage.GetByName(ctx, name)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to fetch ingredient")), nil
+		}
+
+		left, err := pantryStorage.Consume(ingredient.ID, quantity, unit, ingredient.UnitConversion)
+		if err != nil {
+			return mcp.NewToolResultText(pantryErrorMessage(err, "Failed to consume from the pantry")), nil
+		}
+
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("✅ Consumed %s %s from the pantry\n", formatQuantity(quantity, strings.ToLower(strings.TrimSpace(unit))), ingredient.Name))
+		if len(left) == 0 {
+			result.WriteString("None left\n")
+		}
+		for _, item := range left {
+			result.WriteString("Left: " + formatPantryItem(item, ingredient.Name) + "\n")
+		}
+		return mcp.NewToolResultText(result.String()), nil
+	})
+
+	mcpServer.AddTool(adjustPantryItemTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, err := request.RequireInt("id")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		quantity, err := request.RequireFloat("quantity")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		item, err := pantryStorage.Adjust(id, quantity)
+		if err != nil {
+			return mcp.NewToolResultText(pantryErrorMessage(err, "Failed to adjust the pantry item")), nil
+		}
+
+		if item.Quantity == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Removed pantry batch #%d", item.ID)), nil
+		}
+		successMsg := fmt.Sprintf("✅ Adjusted pantry batch #%d to %s", item.ID, formatQuantity(item.Quantity, item.Unit))
+		return mcp.NewToolResultText(successMsg), nil
+	})
+
+	mcpServer.AddTool(listPantryTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		items, err := pantryStorage.List()
+		if err != nil {
+			return mcp.NewToolResultText("❌ Error: Failed to fetch the pantry"), nil
+		}
+
+		header := fmt.Sprintf("📋 Your pantry (%d batches):\n", len(items))
+		if _, ok := request.GetArguments()["expiring_within_days"]; ok {
+			days, err := request.RequireInt("expiring_within_days")
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+			}
+			if days < 0 {
+				return mcp.NewToolResultText("❌ Error: expiring_within_days cannot be negative"), nil
+			}
+			items = storage.PantryItemsExpiringBy(items, time.Now().AddDate(0, 0, days))
+			header = fmt.Sprintf("📋 Expiring within %d days (%d batches):\n", days, len(items))
+		}
+
+		if len(items) == 0 {
+			return mcp.NewToolResultText("No pantry items found"), nil
+		}
+
+		ingredientNames, err := ingredientNamesByID(ctx, ingredientStorage)
+		if err != nil {
+			return mcp.NewToolResultText("❌ Error: Failed to fetch ingredients"), nil
+		}
+
+		var result strings.Builder
+		result.WriteString(header)
+		for _, item := range items {
+			name, ok := ingredientNames[item.IngredientID]
+			if !ok {
+				name = fmt.Sprintf("unknown ingredient #%d", item.IngredientID)
+			}
+			result.WriteString(fmt.Sprintf("#%d. %s\n", item.ID, formatPantryItem(item, name)))
+		}
+		return mcp.NewToolResultText(result.String()), nil
+	})
+}
+
+func formatPantryItem(item *models.PantryItem, name string) string {
+	quantity := formatQuantity(math.Round(item.Quantity*100)/100, item.Unit)
+	formatted := fmt.Sprintf("%s %s, bought %s", quantity, name, item.PurchasedOn.Format(dateLayout))
+	if item.BestBefore == nil {
+		return formatted
+	}
+
+	formatted += ", best before " + item.BestBefore.Format(dateLayout)
+	if item.ExpiresBy(time.Now().AddDate(0, 0, -1)) {
+		formatted += " ⚠️ past its best-before date"
+	}
+	return formatted
+}
+
+func pantryErrorMessage(err error, fallback string) string {
+	switch {
+	// user-friendly storage and conversion errors.
+	case errors.Is(err, storage.ErrPantryIngredientInvalidID),
+		errors.Is(err, storage.ErrPantryItemNotFound),
+		errors.Is(err, storage.ErrPantryIngredientNotStocked),
+		errors.Is(err, storage.ErrPantryInsufficientStock),
+		errors.Is(err, storage.ErrPantryQuantityInvalid),
+		errors.Is(err, storage.ErrPantryQuantityIsNegative),
+		errors.Is(err, storage.ErrPantryQuantityIsTooHigh),
+		errors.Is(err, storage.ErrPantryUnitIsTooLong),
+		errors.Is(err, storage.ErrPantryBestBeforeBeforePurchase),
+		errors.Is(err, units.ErrUnknownUnit),
+		errors.Is(err, units.ErrDensityRequired),
+		errors.Is(err, units.ErrPieceWeightRequired),
+		errors.Is(err, units.ErrPieceNameMismatch):
+		return "❌ Error: " + err.Error()
+	// default catch for database or system errors, etc.
+	default:
+		return "❌ Error: " + fallback
+	}
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/storage"
+)
+
+// recipeIngredientArgument is a single ingredient line as sent by MCP clients.
+type recipeIngredientArgument struct {
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+}
+
+var recipeIngredientsSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"name": map[string]any{
+			"type":        "string",
+			"description": "Name of an ingredient that already exists in your collection",
+		},
+		"quantity": map[string]any{
+			"type":        "number",
+			"description": "Amount of the ingredient, greater than zero",
+		},
+		"unit": map[string]any{
+			"type":        "string",
+			"description": "Unit for the quantity (e.g., 'g', 'ml', 'tbsp'). Leave empty for whole pieces",
+		},
+	},
+	"required": []string{"name", "quantity"},
+}
+
+func addRecipeTools(mcpServer *server.MCPServer, recipeStorage storage.RecipeStorage, ingredientStorage storage.IngredientStorage) {
+	// Tools
+	createRecipeTool := mcp.NewTool("create_recipe",
+		mcp.WithDescription("Add exactly one recipe to your collection. Every ingredient used by the recipe must already exist in your ingredient collection."),
+		mcp.WithString("title",
+			mcp.Required(),
+			mcp.Description("Title of the recipe (e.g., 'tortilla de patatas')"),
+		),
+		mcp.WithNumber("servings",
+			mcp.Required(),
+			mcp.Description("Number of servings the recipe yields"),
+		),
+		mcp.WithArray("steps",
+			mcp.Required(),
+			mcp.Description("Ordered list of preparation steps"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("ingredients",
+			mcp.Required(),
+			mcp.Description("Ingredient lines of the recipe, each one with name, quantity and unit"),
+			mcp.Items(recipeIngredientsSchema),
+		),
+	)
+
+	getRecipeTool := mcp.NewTool("get_recipe",
+		mcp.WithDescription("Show a single recipe from your collection with its ingredients and steps."),
+		mcp.WithString("title",
+			mcp.Required(),
+			mcp.Description("Title of the recipe to show"),
+		),
+	)
+
+	updateRecipeTool := mcp.NewTool("update_recipe",
+		mcp.WithDescription("Update exactly one recipe from your collection. Only the provided fields are changed; steps and ingredients are replaced as a whole."),
+		mcp.WithString("title",
+			mcp.Required(),
+			mcp.Description("Title of the already-existing recipe"),
+		),
+		mcp.WithString("new_title",
+			mcp.Description("New title for the recipe"),
+		),
+		mcp.WithNumber("servings",
+			mcp.Description("New number of servings"),
+		),
+		mcp.WithArray("steps",
+			mcp.Description("New ordered list of preparation steps, replacing the current ones"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("ingredients",
+			mcp.Description("New ingredient lines, replacing the current ones"),
+			mcp.Items(recipeIngredientsSchema),
+		),
+	)
+
+	deleteRecipeTool := mcp.NewTool("delete_recipe",
+		mcp.WithDescription("Delete exactly one recipe from your collection."),
+		mcp.WithString("title",
+			mcp.Required(),
+			mcp.Description("Title of the recipe to delete"),
+		),
+	)
+
+	listRecipesTool := mcp.NewTool("list_recipes",
+		mcp.WithDescription("List all existing recipes from my collection."),
+	)
+
+	searchRecipesTool := mcp.NewTool("search_recipes_by_ingredient",
+		mcp.WithDescription("Find recipes that use the given ingredients, best matches first. Use this to answer questions like 'what can I make with pollo and arroz'."),
+		mcp.WithArray("ingredients",
+			mcp.Required(),
+			mcp.Description("Names of the ingredients the recipes should use (e.g., ['pollo', 'arroz'])"),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString("match",
+			mcp.Description("Use 'any' to return recipes with at least one of the ingredients, or 'all' to require every one of them"),
+			mcp.Enum(string(storage.RecipeMatchAny), string(storage.RecipeMatchAll)),
+			mcp.DefaultString(string(storage.RecipeMatchAny)),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Names of ingredients the recipes must not use"),
+			mcp.WithStringItems(),
+		),
+	)
+
+	// Tool handlers
+	mcpServer.AddTool(createRecipeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		title, err := request.RequireString("title")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		servings, err := request.RequireInt("servings")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		steps, err := request.RequireStringSlice("steps")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		ingredients, err := parseRecipeIngredients(ctx, request, ingredientStorage)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		if ingredients == nil {
+			return mcp.NewToolResultText(`❌ Error: required argument "ingredients" not found`), nil
+		}
+
+		recipe, err := recipeStorage.Create(title, servings, steps, ingredients)
+		if err != nil {
+			return mcp.NewToolResultText(recipeErrorMessage(err, "Failed to create recipe")), nil
+		}
+
+		successMsg := fmt.Sprintf("✅ Added recipe %s to your collection", recipe.Title)
+		return mcp.NewToolResultText(successMsg), nil
+	})
+
+	mcpServer.AddTool(getRecipeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		title, err := request.RequireString("title")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		recipe, err := recipeStorage.Get(title)
+		if err != nil {
+			return mcp.NewToolResultText(recipeErrorMessage(err, "Failed to fetch recipe")), nil
+		}
+
+		ingredientNames, err := ingredientNamesByID(ctx, ingredientStorage)
+		if err != nil {
+			return mcp.NewToolResultText("❌ Error: Failed to fetch ingredients"), nil
+		}
+
+		return mcp.NewToolResultText(formatRecipe(recipe, ingredientNames)), nil
+	})
+
+	mcpServer.AddTool(updateRecipeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		title, err := request.RequireString("title")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		var changes storage.RecipeUpdate
+		args := request.GetArguments()
+		if _, ok := args["new_title"]; ok {
+			newTitle, err := request.RequireString("new_title")
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+			}
+			changes.Title = &newTitle
+		}
+		if _, ok := args["servings"]; ok {
+			servings, err := request.RequireInt("servings")
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+			}
+			changes.Servings = &servings
+		}
+		if _, ok := args["steps"]; ok {
+			steps, err := request.RequireStringSlice("steps")
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+			}
+			// an explicit empty list must reach validation instead of meaning "untouched"
+			if steps == nil {
+				steps = []string{}
+			}
+			changes.Steps = steps
+		}
+		if _, ok := args["ingredients"]; ok {
+			ingredients, err := parseRecipeIngredients(ctx, request, ingredientStorage)
+			if err != nil {
+				return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+			}
+			if ingredients == nil {
+				ingredients = []models.RecipeIngredient{}
+			}
+			changes.Ingredients = ingredients
+		}
+
+		recipe, err := recipeStorage.Update(title, changes)
+		if err != nil {
+			return mcp.NewToolResultText(recipeErrorMessage(err, "Failed to update recipe")), nil
+		}
+
+		successMsg := fmt.Sprintf("✅ Updated recipe %s", recipe.Title)
+		return mcp.NewToolResultText(successMsg), nil
+	})
+
+	mcpServer.AddTool(deleteRecipeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		title, err := request.RequireString("title")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		err = recipeStorage.Delete(title)
+		if err != nil {
+			return mcp.NewToolResultText(recipeErrorMessage(err, "Failed to delete recipe")), nil
+		}
+
+		successMsg := fmt.Sprintf("✅ Deleted recipe %s from your collection", title)
+		return mcp.NewToolResultText(successMsg), nil
+	})
+
+	mcpServer.AddTool(listRecipesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		recipes, err := recipeStorage.List()
+		if err != nil {
+			return mcp.NewToolResultText("❌ Error: Failed to fetch recipes"), nil
+		}
+
+		if len(recipes) == 0 {
+			return mcp.NewToolResultText("No recipes found"), nil
+		}
+
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("📋 Your recipes (%d total):\n", len(recipes)))
+		for i, recipe := range recipes {
+			result.WriteString(fmt.Sprintf("%d. %s (%d servings, %d ingredients)\n", i+1, recipe.Title, recipe.Servings, len(recipe.Ingredients)))
+		}
+		return mcp.NewToolResultText(result.String()), nil
+	})
+
+	mcpServer.AddTool(searchRecipesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ingredients, err := request.RequireStringSlice("ingredients")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		query := storage.RecipeSearchQuery{
+			Ingredients: ingredients,
+			Exclude:     request.GetStringSlice("exclude", nil),
+			Mode:        storage.RecipeMatchMode(request.GetString("match", string(storage.RecipeMatchAny))),
+		}
+
+		matches, err := storage.SearchRecipesByIngredient(ctx, recipeStorage, ingredientStorage, query)
+		if err != nil {
+			var errorMsg string
+			switch err {
+			// user-friendly storage errors.
+			case storage.ErrRecipeSearchIngredientsCannotBeEmpty,
+				storage.ErrRecipeSearchInvalidMatchMode:
+				errorMsg = "❌ Error: " + err.Error()
+			// default catch for database or system errors, etc.
+			default:
+				errorMsg = "❌ Error: Failed to search recipes"
+			}
+			return mcp.NewToolResultText(errorMsg), nil
+		}
+
+		if len(matches) == 0 {
+			return mcp.NewToolResultText("No recipes found using those ingredients"), nil
+		}
+
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("🔍 Found %d recipes:\n", len(matches)))
+		for i, match := range matches {
+			requested := len(match.Matched) + len(match.Missing)
+			result.WriteString(fmt.Sprintf("%d. %s — uses %d/%d (%s)", i+1, match.Recipe.Title, len(match.Matched), requested, strings.Join(match.Matched, ", ")))
+			if len(match.Missing) > 0 {
+				result.WriteString(fmt.Sprintf(", missing: %s", strings.Join(match.Missing, ", ")))
+			}
+			result.WriteString("\n")
+		}
+		return mcp.NewToolResultText(result.String()), nil
+	})
+}
+
+// parseRecipeIngredients reads the "ingredients" argument and resolves every
+// ingredient name to the ID of an existing ingredient. It returns nil when the
+// argument is missing.
+func parseRecipeIngredients(ctx context.Context, request mcp.CallToolRequest, ingredientStorage storage.IngredientStorage) ([]models.RecipeIngredient, error) {
+	var args struct {
+		Ingredients []recipeIngredientArgument `json:"ingredients"`
+	}
+	if err := request.BindArguments(&args); err != nil {
+		return nil, errors.New(`argument "ingredients" must be a list of objects with name, quantity and unit`)
+	}
+	if args.Ingredients == nil {
+		return nil, nil
+	}
+
+	results := make([]models.RecipeIngredient, 0, len(args.Ingredients))
+	for _, line := range args.Ingredients {
+		ingredient, err := ingredientStorage.GetByName(ctx, line.Name)
+		if err != nil {
+			switch err {
+			case storage.ErrIngredientNameCannotBeEmpty,
+				storage.ErrIngredientNameContainsInvalidChars,
+				storage.ErrIngredientNameIsTooShort,
+				storage.ErrIngredientNameIsTooLong:
+				return nil, err
+			case storage.ErrIngredientNotFound:
+				return nil, fmt.Errorf("ingredient %q not found, add it with create_ingredient first", line.Name)
+			default:
+				return nil, errors.New("failed to fetch ingredients")
+			}
+		}
+		results = append(results, models.RecipeIngredient{
+			IngredientID: ingredient.ID,
+			Quantity:     line.Quantity,
+			Unit:         line.Unit,
+		})
+	}
+
+	return results, nil
+}
+
+// ingredientNamesByID maps every stored ingredient ID to its name.
+func ingredientNamesByID(ctx context.Context, ingredientStorage storage.IngredientStorage) (map[int]string, error) {
+	ingredients, err := ingredientStorage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int]string, len(ingredients))
+	for _, ingredient := range ingredients {
+		names[ingredient.ID] = ingredient.Name
+	}
+	return names, nil
+}
+
+// ingredientsByID indexes every stored ingredient by its ID.
+func ingredientsByID(ctx context.Context, ingredientStorage storage.IngredientStorage) (map[int]*models.Ingredient, error) {
+	ingredients, err := ingredientStorage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[int]*models.Ingredient, len(ingredients))
+	for _, ingredient := range ingredients {
+		results[ingredient.ID] = ingredient
+	}
+	return results, nil
+}
+
+func formatRecipe(recipe *models.Recipe, ingredientNames map[int]string) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("📖 %s (%d servings)\n", recipe.Title, recipe.Servings))
+
+	result.WriteString("\nIngredients:\n")
+	for _, line := range recipe.Ingredients {
+		name, ok := ingredientNames[line.IngredientID]
+		if !ok {
+			name = fmt.Sprintf("unknown ingredient #%d", line.IngredientID)
+		}
+		result.WriteString(fmt.Sprintf("- %s %s\n", formatQuantity(line.Quantity, line.Unit), name))
+	}
+
+	result.WriteString("\nSteps:\n")
+	for i, step := range recipe.Steps {
+		result.WriteString(fmt.Sprintf("%d. %s\n", i+1, step))
+	}
+	return result.String()
+}
+
+func formatQuantity(quantity float64, unit string) string {
+	formatted := strconv.FormatFloat(quantity, 'f', -1, 64)
+	if unit == "" {
+		return formatted
+	}
+	return formatted + " " + unit
+}
+
+func recipeErrorMessage(err error, fallback string) string {
+	switch err {
+	// user-friendly storage errors.
+	case storage.ErrRecipeTitleCannotBeEmpty,
+		storage.ErrRecipeTitleContainsInvalidChars,
+		storage.ErrRecipeTitleExists,
+		storage.ErrRecipeTitleIsTooLong,
+		storage.ErrRecipeTitleIsTooShort,
+		storage.ErrRecipeNotFound,
+		storage.ErrRecipeServingsOutOfRange,
+		storage.ErrRecipeStepsCannotBeEmpty,
+		storage.ErrRecipeStepCannotBeEmpty,
+		storage.ErrRecipeStepIsTooLong,
+		storage.ErrRecipeTooManySteps,
+		storage.ErrRecipeIngredientsCannotBeEmpty,
+		storage.ErrRecipeTooManyIngredients,
+		storage.ErrRecipeIngredientInvalidID,
+		storage.ErrRecipeIngredientDuplicated,
+		storage.ErrRecipeIngredientInvalidQuantity,
+		storage.ErrRecipeIngredientUnitIsTooLong:
+		return "❌ Error: " + err.Error()
+	// default catch for database or system errors, etc.
+	default:
+		return "❌ Error: " + fallback
+	}
+}
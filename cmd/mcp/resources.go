@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/storage"
+)
+
+// addIngredientResources exposes every ingredient as a resource and keeps the
+// resources in step with the storage until ctx is done, so clients sharing the
+// server learn about changes made by the others: adding, renaming or removing
+// an ingredient sends resources/list_changed, and changes to an ingredient
+// send resources/updated for its URI if the client subscribed to it.
+func addIngredientResources(ctx context.Context, mcpServer *server.MCPServer, ingredientStorage storage.IngredientStorage,
+	subscriptions *ingredientSubscriptions) error {
+	// watch first, so no change is missed between listing and watching
+	events := ingredientStorage.Watch(ctx)
+	if err := setIngredientResources(ctx, mcpServer, ingredientStorage); err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			for event := range events {
+				applyIngredientEvent(mcpServer, ingredientStorage, subscriptions, event)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			// the watcher fell behind, so start over from the current ingredients
+			events = ingredientStorage.Watch(ctx)
+			if err := setIngredientResources(ctx, mcpServer, ingredientStorage); err != nil {
+				log.Printf("Failed to refresh the ingredient resources: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// setIngredientResources replaces the resources with the current ingredients.
+func setIngredientResources(ctx context.Context, mcpServer *server.MCPServer, ingredientStorage storage.IngredientStorage) error {
+	ingredients, err := ingredientStorage.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	resources := make([]server.ServerResource, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		resources = append(resources, ingredientResource(ingredientStorage, ingredient))
+	}
+	mcpServer.SetResources(resources...)
+	return nil
+}
+
+// applyIngredientEvent updates the resources after a change to an ingredient.
+func applyIngredientEvent(mcpServer *server.MCPServer, ingredientStorage storage.IngredientStorage,
+	subscriptions *ingredientSubscriptions, event models.IngredientEvent) {
+	uri := ingredientResourceURI(event.Ingredient.ID)
+
+	switch event.Kind {
+	case models.IngredientCreated, models.IngredientRestored, models.IngredientRenamed:
+		// the listed name changes on renames too
+		resource := ingredientResource(ingredientStorage, event.Ingredient)
+		mcpServer.AddResource(resource.Resource, resource.Handler)
+	case models.IngredientDeleted, models.IngredientPurged:
+		mcpServer.RemoveResource(uri)
+	}
+
+	if (event.Kind == models.IngredientRenamed || event.Kind == models.IngredientUpdated) && subscriptions.subscribed(uri) {
+		mcpServer.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+	}
+}
+
+// ingredientResource returns the resource exposing an ingredient as JSON.
+func ingredientResource(ingredientStorage storage.IngredientStorage, ingredient *models.Ingredient) server.ServerResource {
+	uri := ingredientResourceURI(ingredient.ID)
+	id := ingredient.ID
+
+	return server.ServerResource{
+		Resource: mcp.NewResource(uri, ingredient.Name,
+			mcp.WithResourceDescription(fmt.Sprintf("Ingredient %s, with its aliases and attributes", ingredient.Name)),
+			mcp.WithMIMEType("application/json"),
+		),
+		Handler: func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			ingredient, err := ingredientStorage.Get(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+
+			data, err := json.MarshalIndent(ingredient, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{URI: uri, MIMEType: "application/json", Text: string(data)},
+			}, nil
+		},
+	}
+}
+
+// ingredientResourceURI returns the URI of the resource exposing an ingredient.
+func ingredientResourceURI(id int) string {
+	return fmt.Sprintf("ingredient://%d", id)
+}
+
+// ingredientIDFromURI returns the ID of the ingredient a resource URI exposes.
+func ingredientIDFromURI(uri string) (int, bool) {
+	rest, ok := strings.CutPrefix(uri, "ingredient://")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(rest)
+	return id, err == nil
+}
+
+// Subscription methods of MCP, which mcp-go does not handle itself.
+const (
+	methodResourcesSubscribe   = "resources/subscribe"
+	methodResourcesUnsubscribe = "resources/unsubscribe"
+)
+
+// ingredientSubscriptions holds the ingredient resources the client subscribed
+// to. The server speaks stdio to a single client, so the subscriptions are all
+// its own.
+type ingredientSubscriptions struct {
+	ingredientStorage storage.IngredientStorage
+
+	mu   sync.Mutex
+	uris map[string]bool
+}
+
+func newIngredientSubscriptions(ingredientStorage storage.IngredientStorage) *ingredientSubscriptions {
+	return &ingredientSubscriptions{ingredientStorage: ingredientStorage, uris: make(map[string]bool)}
+}
+
+// subscribed reports whether the client subscribed to a resource.
+func (s *ingredientSubscriptions) subscribed(uri string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.uris[uri]
+}
+
+// serve answers the subscription requests read from in, writing the responses
+// to out, and returns a reader with every other message for the MCP server.
+func (s *ingredientSubscriptions) serve(ctx context.Context, in io.Reader, out io.Writer) io.Reader {
+	reader, writer := io.Pipe()
+
+	go func() {
+		lines := bufio.NewReader(in)
+		for {
+			line, err := lines.ReadBytes('\n')
+			if len(line) > 0 {
+				if response := s.handle(ctx, line); response != nil {
+					data, marshalErr := json.Marshal(response)
+					if marshalErr == nil {
+						// a single write, so the line is never mixed with the server's
+						_, marshalErr = out.Write(append(data, '\n'))
+					}
+					if marshalErr != nil {
+						log.Printf("Failed to answer a subscription request: %v", marshalErr)
+					}
+				} else if _, writeErr := writer.Write(line); writeErr != nil {
+					return
+				}
+			}
+			if err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+	}()
+
+	return reader
+}
+
+// handle answers a subscription request, or returns nil for any other message.
+func (s *ingredientSubscriptions) handle(ctx context.Context, line []byte) mcp.JSONRPCMessage {
+	var request struct {
+		ID     mcp.RequestId       `json:"id"`
+		Method string              `json:"method"`
+		Params mcp.SubscribeParams `json:"params"`
+	}
+	if err := json.Unmarshal(line, &request); err != nil || request.ID.IsNil() {
+		return nil
+	}
+
+	switch request.Method {
+	case methodResourcesSubscribe:
+		id, ok := ingredientIDFromURI(request.Params.URI)
+		if ok {
+			_, err := s.ingredientStorage.Get(ctx, id)
+			ok = err == nil
+		}
+		if !ok {
+			return mcp.NewJSONRPCError(request.ID, mcp.RESOURCE_NOT_FOUND, "Resource not found", map[string]any{"uri": request.Params.URI})
+		}
+
+		s.mu.Lock()
+		s.uris[request.Params.URI] = true
+		s.mu.Unlock()
+	case methodResourcesUnsubscribe:
+		s.mu.Lock()
+		delete(s.uris, request.Params.URI)
+		s.mu.Unlock()
+	default:
+		return nil
+	}
+
+	return mcp.NewJSONRPCResponse(request.ID, mcp.Result{})
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/shopping"
+	"github.com/victorcete/recipe-manager/internal/storage"
+)
+
+// shoppingRecipeArgument is a recipe to shop for as sent by MCP clients.
+type shoppingRecipeArgument struct {
+	Title    string `json:"title"`
+	Servings int    `json:"servings"`
+}
+
+var shoppingRecipesSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"title": map[string]any{
+			"type":        "string",
+			"description": "Title of a recipe that already exists in your collection",
+		},
+		"servings": map[string]any{
+			"type":        "number",
+			"description": "Number of servings to cook, defaults to the servings of the recipe",
+		},
+	},
+	"required": []string{"title"},
+}
+
+func addShoppingTools(mcpServer *server.MCPServer, shoppingStorage storage.ShoppingListStorage, recipeStorage storage.RecipeStorage, ingredientStorage storage.IngredientStorage, pantryStorage storage.PantryStorage) {
+	// Tools
+	generateShoppingListTool := mcp.NewTool("generate_shopping_list",
+		mcp.WithDescription("Build a shopping list for cooking a set of recipes. Quantities of the same ingredient are merged across recipes and what the pantry already holds is subtracted. The list is saved so items can be checked off later."),
+		mcp.WithArray("recipes",
+			mcp.Required(),
+			mcp.Description("Recipes to cook, each with an optional number of servings"),
+			mcp.Items(shoppingRecipesSchema),
+		),
+		mcp.WithBoolean("use_pantry",
+			mcp.Description("Subtract what the pantry already holds"),
+			mcp.DefaultBool(true),
+		),
+	)
+
+	getShoppingListTool := mcp.NewTool("get_shopping_list",
+		mcp.WithDescription("Show a saved shopping list and which items have been checked off."),
+		mcp.WithNumber("list_id",
+			mcp.Description("ID of the shopping list, defaults to the latest one"),
+		),
+	)
+
+	checkShoppingListItemTool := mcp.NewTool("check_shopping_list_item",
+		mcp.WithDescription("Check off exactly one ingredient from a saved shopping list once it is in the basket, or uncheck it again."),
+		mcp.WithString("ingredient",
+			mcp.Required(),
+			mcp.Description("Name of the ingredient on the list"),
+		),
+		mcp.WithNumber("list_id",
+			mcp.Description("ID of the shopping list, defaults to the latest one"),
+		),
+		mcp.WithBoolean("checked",
+			mcp.Description("Whether the ingredient is in the basket"),
+			mcp.DefaultBool(true),
+		),
+	)
+
+	// Tool handlers
+	mcpServer.AddTool(generateShoppingListTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var args struct {
+			Recipes []shoppingRecipeArgument `json:"recipes"`
+		}
+		if err := request.BindArguments(&args); err != nil || len(args.Recipes) == 0 {
+			return mcp.NewToolResultText(`❌ Error: argument "recipes" must be a non-empty list of objects with title and servings`), nil
+		}
+
+		portions := make([]shopping.Portion, 0, len(args.Recipes))
+		for _, argument := range args.Recipes {
+			recipe, err := recipeStorage.Get(argument.Title)
+			if err != nil {
+				return mcp.NewToolResultText(recipeErrorMessage(err, "Failed to fetch recipe")), nil
+			}
+			if argument.Servings != 0 && (argument.Servings < storage.RecipeServingsMin || argument.Servings > storage.RecipeServingsMax) {
+				return mcp.NewToolResultText("❌ Error: " + storage.ErrRecipeServingsOutOfRange.Error()), nil
+			}
+			portions = append(portions, shopping.Portion{Recipe: recipe, Servings: argument.Servings})
+		}
+
+		ingredients, err := ingredientsByID(ctx, ingredientStorage)
+		if err != nil {
+			return mcp.NewToolResultText("❌ Error: Failed to fetch ingredients"), nil
+		}
+
+		needs := shopping.Needs(portions, ingredients)
+		if request.GetBool("use_pantry", true) {
+			pantry, err := pantryStorage.List()
+			if err != nil {
+				return mcp.NewToolResultText("❌ Error: Failed to fetch the pantry"), nil
+			}
+			needs = shopping.SubtractPantry(needs, pantry, ingredients)
+		}
+
+		if len(needs) == 0 {
+			return mcp.NewToolResultText("✅ Nothing to buy, the pantry already has everything"), nil
+		}
+
+		items := make([]models.ShoppingListItem, 0, len(needs))
+		for _, need := range needs {
+			items = append(items, models.ShoppingListItem{
+				IngredientID: need.IngredientID,
+				Quantity:     need.Quantity,
+				Unit:         need.Unit,
+			})
+		}
+
+		list, err := shoppingStorage.Create(items)
+		if err != nil {
+			return mcp.NewToolResultText(shoppingErrorMessage(err, "Failed to save the shopping list")), nil
+		}
+
+		return mcp.NewToolResultText(formatShoppingList(list, ingredients)), nil
+	})
+
+	mcpServer.AddTool(getShoppingListTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		list, err := findShoppingList(shoppingStorage, request.GetInt("list_id", 0))
+		if err != nil {
+			return mcp.NewToolResultText(shoppingErrorMessage(err, "Failed to fetch the shopping list")), nil
+		}
+
+		ingredients, err := ingredientsByID(ctx, ingredientStorage)
+		if err != nil {
+			return mcp.NewToolResultText("❌ Error: Failed to fetch ingredients"), nil
+		}
+
+		return mcp.NewToolResultText(formatShoppingList(list, ingredients)), nil
+	})
+
+	mcpServer.AddTool(checkShoppingListItemTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("ingredient")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		checked := request.GetBool("checked", true)
+
+		list, err := findShoppingList(shoppingStorage, request.GetInt("list_id", 0))
+		if err != nil {
+			return mcp.NewToolResultText(shoppingErrorMessage(err, "Failed to fetch the shopping list")), nil
+		}
+
+		ingredient, err := ingredientStorage.GetByName(ctx, name)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to fetch ingredient")), nil
+		}
+
+		list, err = shoppingStorage.SetChecked(list.ID, ingredient.ID, checked)
+		if err != nil {
+			return mcp.NewToolResultText(shoppingErrorMessage(err, "Failed to update the shopping list")), nil
+		}
+
+		action := "Checked off"
+		if !checked {
+			action = "Unchecked"
+		}
+		successMsg := fmt.Sprintf("✅ %s %s on shopping list #%d, %d of %d items left", action, ingredient.Name, list.ID, list.Remaining(), len(list.Items))
+		return mcp.NewToolResultText(successMsg), nil
+	})
+}
+
+// findShoppingList returns the shopping list with the given ID, or the latest
+// one when the ID is zero.
+func findShoppingList(shoppingStorage storage.ShoppingListStorage, id int) (*models.ShoppingList, error) {
+	if id != 0 {
+		return shoppingStorage.Get(id)
+	}
+
+	lists, err := shoppingStorage.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) == 0 {
+		return nil, storage.ErrShoppingListNotFound
+	}
+	return lists[len(lists)-1], nil
+}
+
+func formatShoppingList(list *models.ShoppingList, ingredients map[int]*models.Ingredient) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("🛒 Shopping list #%d (%d of %d items left):\n", list.ID, list.Remaining(), len(list.Items)))
+	for _, item := range list.Items {
+		name := fmt.Sprintf("unknown ingredient #%d", item.IngredientID)
+		if ingredient, ok := ingredients[item.IngredientID]; ok {
+			name = ingredient.Name
+		}
+
+		box := "[ ]"
+		if item.Checked {
+			box = "[x]"
+		}
+		result.WriteString(fmt.Sprintf("- %s %s %s\n", box, formatQuantity(math.Round(item.Quantity*100)/100, item.Unit), name))
+	}
+	return result.String()
+}
+
+func shoppingErrorMessage(err error, fallback string) string {
+	switch {
+	// user-friendly storage errors.
+	case errors.Is(err, storage.ErrShoppingListNotFound),
+		errors.Is(err, storage.ErrShoppingListItemsCannotBeEmpty),
+		errors.Is(err, storage.ErrShoppingListTooManyItems),
+		errors.Is(err, storage.ErrShoppingListItemInvalidID),
+		errors.Is(err, storage.ErrShoppingListItemInvalidQuantity),
+		errors.Is(err, storage.ErrShoppingListItemUnitIsTooLong),
+		errors.Is(err, storage.ErrShoppingListItemNotFound):
+		return "❌ Error: " + err.Error()
+	// default catch for database or system errors, etc.
+	default:
+		return "❌ Error: " + fallback
+	}
+}
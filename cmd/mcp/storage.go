@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/victorcete/recipe-manager/internal/storage"
+)
+
+// Ingredient storage backends selectable with the -storage flag.
+const (
+	storageBackendMemory  = "memory"
+	storageBackendJournal = "journal"
+	storageBackendFile    = "file"
+	storageBackendSQLite  = "sqlite"
+)
+
+// Ways of assigning ingredient IDs selectable with the -ingredient-ids flag.
+const (
+	ingredientIDsSequential  = "sequential"
+	ingredientIDsTimeOrdered = "time-ordered"
+)
+
+// storageConfig holds the ingredient storage settings given on the command line.
+type storageConfig struct {
+	backend             string
+	journalDir          string
+	ingredientsFile     string
+	ingredientsDatabase string
+	ingredientIDs       string
+	ingredientIDNode    string
+}
+
+// envOrDefault returns the value of an environment variable, or fallback when
+// it is unset or empty.
+func envOrDefault(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// newIngredientStorage opens the ingredient storage for the configured backend.
+// The sample ingredients are seeded into empty storages only, so curated data
+// is never mixed with them.
+func newIngredientStorage(ctx context.Context, config storageConfig) (storage.IngredientStorage, error) {
+	var options []storage.MemoryOption
+	switch config.ingredientIDs {
+	case ingredientIDsSequential:
+	case ingredientIDsTimeOrdered:
+		if config.backend == storageBackendSQLite {
+			return nil, fmt.Errorf("the %q storage backend only assigns %s IDs", storageBackendSQLite, ingredientIDsSequential)
+		}
+		node, err := strconv.Atoi(config.ingredientIDNode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ingredient ID node %q: %w", config.ingredientIDNode, err)
+		}
+		generator, err := storage.NewTimeOrderedIDGenerator(node)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, storage.WithIDGenerator(generator))
+	default:
+		return nil, fmt.Errorf("unknown ingredient IDs %q, expected %q or %q", config.ingredientIDs,
+			ingredientIDsSequential, ingredientIDsTimeOrdered)
+	}
+
+	var ingredientStorage storage.IngredientStorage
+	switch config.backend {
+	case storageBackendMemory:
+		ingredientStorage = storage.NewMemoryStorage(options...)
+	case storageBackendJournal:
+		// an existing journal whose ingredients were all deleted stays empty
+		exists, err := storage.JournalExists(config.journalDir)
+		if err != nil {
+			return nil, err
+		}
+
+		journaled, err := storage.NewJournaledMemoryStorage(config.journalDir, options...)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return journaled, nil
+		}
+		ingredientStorage = journaled
+	case storageBackendFile:
+		// an existing file that was emptied on purpose stays empty
+		_, err := os.Stat(config.ingredientsFile)
+		fresh := errors.Is(err, os.ErrNotExist)
+
+		fileStorage, err := storage.NewFileStorage(config.ingredientsFile, options...)
+		if err != nil {
+			return nil, err
+		}
+		if !fresh {
+			return fileStorage, nil
+		}
+		ingredientStorage = fileStorage
+	case storageBackendSQLite:
+		// an existing database whose ingredients were all deleted stays empty
+		_, err := os.Stat(config.ingredientsDatabase)
+		fresh := errors.Is(err, os.ErrNotExist)
+
+		sqlStorage, err := storage.OpenSQLiteStorage(ctx, config.ingredientsDatabase)
+		if err != nil {
+			return nil, err
+		}
+		if !fresh {
+			return sqlStorage, nil
+		}
+		ingredientStorage = sqlStorage
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q, expected %q, %q, %q or %q", config.backend,
+			storageBackendMemory, storageBackendJournal, storageBackendFile, storageBackendSQLite)
+	}
+
+	ingredients, err := ingredientStorage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(ingredients) == 0 {
+		if _, err := ingredientStorage.SeedTestData(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return ingredientStorage, nil
+}
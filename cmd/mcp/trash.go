@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/victorcete/recipe-manager/internal/storage"
+)
+
+// trashRetentionDays is how long purge_trash keeps deleted ingredients by default.
+const trashRetentionDays = 30
+
+func addTrashTools(mcpServer *server.MCPServer, ingredientStorage storage.IngredientStorage) {
+	// Tools
+	listTrashTool := mcp.NewTool("list_trash",
+		mcp.WithDescription("List the deleted ingredients that are still in the trash and can be restored, with their IDs and deletion dates."),
+	)
+
+	restoreIngredientTool := mcp.NewTool("restore_ingredient",
+		mcp.WithDescription("Take exactly one deleted ingredient out of the trash, with its aliases and attributes. Find its ID with list_trash first."),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("ID of the deleted ingredient, as shown by list_trash"),
+		),
+	)
+
+	purgeTrashTool := mcp.NewTool("purge_trash",
+		mcp.WithDescription("Permanently remove the ingredients deleted more than a number of days ago. Purged ingredients cannot be restored."),
+		mcp.WithNumber("older_than_days",
+			mcp.Description(fmt.Sprintf("Only purge ingredients deleted more than this many days ago; 0 empties the whole trash (default %d)", trashRetentionDays)),
+			mcp.Min(0),
+			mcp.DefaultNumber(trashRetentionDays),
+		),
+	)
+
+	// Tool handlers
+	mcpServer.AddTool(listTrashTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ingredients, err := ingredientStorage.ListDeleted(ctx)
+		if err != nil {
+			return mcp.NewToolResultText("❌ Error: Failed to fetch the trash"), nil
+		}
+
+		if len(ingredients) == 0 {
+			return mcp.NewToolResultText("📋 The trash is empty"), nil
+		}
+
+		var result strings.Builder
+		result.WriteString(fmt.Sprintf("📋 Trash (%d):\n", len(ingredients)))
+		for _, ingredient := range ingredients {
+			result.WriteString(fmt.Sprintf("- %s (ID %d)%s, deleted %s\n", ingredient.Name, ingredient.ID,
+				formatAliases(ingredient.Aliases), ingredient.DeletedAt.Format(dateLayout)))
+		}
+		return mcp.NewToolResultText(result.String()), nil
+	})
+
+	mcpServer.AddTool(restoreIngredientTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, err := request.RequireInt("id")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		ingredient, err := ingredientStorage.Restore(ctx, id)
+		if err != nil {
+			return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to restore ingredient")), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("✅ Restored %s from the trash", ingredient.Name)), nil
+	})
+
+	mcpServer.AddTool(purgeTrashTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		days := request.GetInt("older_than_days", trashRetentionDays)
+		if days < 0 {
+			return mcp.NewToolResultText("❌ Error: older_than_days cannot be negative"), nil
+		}
+
+		purged, err := ingredientStorage.Purge(ctx, time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			return mcp.NewToolResultText("❌ Error: Failed to purge the trash"), nil
+		}
+
+		if len(purged) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("✅ Nothing in the trash was deleted more than %d days ago", days)), nil
+		}
+
+		names := make([]string, 0, len(purged))
+		for _, ingredient := range purged {
+			names = append(names, ingredient.Name)
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("✅ Permanently removed %d ingredients: %s", len(purged), strings.Join(names, ", "))), nil
+	})
+}
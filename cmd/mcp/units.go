@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/storage"
+	"github.com/victorcete/recipe-manager/internal/units"
+)
+
+// withUnitConversionArguments adds the unit conversion arguments to a tool.
+func withUnitConversionArguments() mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		mcp.WithNumber("piece_weight_grams",
+			mcp.Description("Weight in grams of a single piece of the ingredient (e.g., 5 for a garlic clove)"),
+			mcp.Min(0),
+		)(tool)
+		mcp.WithString("piece_name",
+			mcp.Description("Name of a single piece of the ingredient (e.g., 'diente' for garlic, 'huevo' for eggs)"),
+		)(tool)
+		mcp.WithNumber("density_g_per_ml",
+			mcp.Description("Density in grams per milliliter, used to convert volumes into weights (e.g., 0.92 for olive oil)"),
+			mcp.Min(0),
+		)(tool)
+	}
+}
+
+// unitConversionFromRequest applies the unit conversion arguments present in
+// the request on top of base. It reports whether any argument was given.
+func unitConversionFromRequest(request mcp.CallToolRequest, base models.UnitConversion) (models.UnitConversion, bool, error) {
+	conversion := base
+	provided := false
+	args := request.GetArguments()
+
+	if _, ok := args["piece_weight_grams"]; ok {
+		value, err := request.RequireFloat("piece_weight_grams")
+		if err != nil {
+			return conversion, false, err
+		}
+		conversion.PieceWeightGrams = value
+		provided = true
+	}
+
+	if _, ok := args["piece_name"]; ok {
+		value, err := request.RequireString("piece_name")
+		if err != nil {
+			return conversion, false, err
+		}
+		conversion.PieceName = value
+		provided = true
+	}
+
+	if _, ok := args["density_g_per_ml"]; ok {
+		value, err := request.RequireFloat("density_g_per_ml")
+		if err != nil {
+			return conversion, false, err
+		}
+		conversion.DensityGPerMl = value
+		provided = true
+	}
+
+	return conversion, provided, nil
+}
+
+func addUnitTools(mcpServer *server.MCPServer, ingredientStorage storage.IngredientStorage) {
+	// Tools
+	convertQuantityTool := mcp.NewTool("convert_quantity",
+		mcp.WithDescription("Convert a quantity between units (g, kg, mg, oz, lb, ml, l, cup, tbsp, tsp, pizca, diente, pieza). Converting between weight, volume and pieces requires an ingredient with density or piece weight set."),
+		mcp.WithNumber("quantity",
+			mcp.Required(),
+			mcp.Description("Amount to convert, greater than zero"),
+		),
+		mcp.WithString("from_unit",
+			mcp.Required(),
+			mcp.Description("Unit of the given quantity (e.g., 'tbsp')"),
+		),
+		mcp.WithString("to_unit",
+			mcp.Required(),
+			mcp.Description("Unit to convert to (e.g., 'g')"),
+		),
+		mcp.WithString("ingredient",
+			mcp.Description("Name of the ingredient being measured, needed to convert between weight, volume and pieces"),
+		),
+	)
+
+	// Tool handlers
+	mcpServer.AddTool(convertQuantityTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		quantity, err := request.RequireFloat("quantity")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		fromUnit, err := request.RequireString("from_unit")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+		toUnit, err := request.RequireString("to_unit")
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("❌ Error: %v", err)), nil
+		}
+
+		var conversion models.UnitConversion
+		subject := ""
+		if name := request.GetString("ingredient", ""); name != "" {
+			ingredient, err := ingredientStorage.GetByName(ctx, name)
+			if err != nil {
+				return mcp.NewToolResultText(ingredientErrorMessage(err, "Failed to fetch ingredient")), nil
+			}
+			conversion = ingredient.UnitConversion
+			subject = " of " + ingredient.Name
+		}
+
+		result, err := units.Convert(quantity, fromUnit, toUnit, conversion)
+		if err != nil {
+			return mcp.NewToolResultText(unitErrorMessage(err)), nil
+		}
+
+		successMsg := fmt.Sprintf("⚖️ %s%s = %s", formatQuantity(quantity, fromUnit), subject, formatQuantity(math.Round(result*100)/100, toUnit))
+		return mcp.NewToolResultText(successMsg), nil
+	})
+}
+
+func unitErrorMessage(err error) string {
+	switch {
+	// user-friendly conversion errors.
+	case errors.Is(err, units.ErrUnknownUnit),
+		errors.Is(err, units.ErrDensityRequired),
+		errors.Is(err, units.ErrPieceWeightRequired),
+		errors.Is(err, units.ErrPieceNameMismatch),
+		errors.Is(err, units.ErrQuantityMustBePositive):
+		return "❌ Error: " + err.Error()
+	// default catch for unexpected errors.
+	default:
+		return "❌ Error: Failed to convert quantity"
+	}
+}
@@ -0,0 +1,79 @@
+package dietary
+
+import (
+	"fmt"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// Labels holds the allergen and diet labels of a recipe, derived from its ingredients.
+type Labels struct {
+	// Contains lists the allergens declared by at least one ingredient.
+	Contains []models.Allergen `json:"contains"`
+	// FreeFrom lists the allergens guaranteed to be absent. It is only filled
+	// in when every ingredient has been reviewed.
+	FreeFrom []models.Allergen `json:"free_from"`
+	// SuitableFor lists the diets every ingredient is suitable for. It is only
+	// filled in when every ingredient has been reviewed.
+	SuitableFor []models.Diet `json:"suitable_for"`
+	// Unreviewed lists the ingredients without dietary information.
+	Unreviewed []string `json:"unreviewed"`
+}
+
+// Complete reports whether every ingredient of the recipe has been reviewed, so
+// FreeFrom and SuitableFor can be relied upon.
+func (l Labels) Complete() bool {
+	return len(l.Unreviewed) == 0
+}
+
+// ForRecipe derives the labels of a recipe from the dietary information of its
+// ingredients. An ingredient without dietary information may contain anything,
+// so a single unreviewed ingredient prevents any "free from" or "suitable for" label.
+func ForRecipe(recipe *models.Recipe, ingredients map[int]*models.Ingredient) Labels {
+	labels := Labels{
+		Contains:    make([]models.Allergen, 0),
+		FreeFrom:    make([]models.Allergen, 0),
+		SuitableFor: make([]models.Diet, 0),
+		Unreviewed:  make([]string, 0),
+	}
+
+	contains := make(map[models.Allergen]bool)
+	suitable := make(map[models.Diet]int)
+	for _, line := range recipe.Ingredients {
+		ingredient, ok := ingredients[line.IngredientID]
+		if !ok {
+			labels.Unreviewed = append(labels.Unreviewed, fmt.Sprintf("#%d", line.IngredientID))
+			continue
+		}
+
+		if ingredient.Dietary == nil {
+			labels.Unreviewed = append(labels.Unreviewed, ingredient.Name)
+			continue
+		}
+
+		for _, allergen := range ingredient.Dietary.Allergens {
+			contains[allergen] = true
+		}
+		for _, diet := range ingredient.Dietary.Diets {
+			suitable[diet]++
+		}
+	}
+
+	for _, allergen := range models.Allergens {
+		if contains[allergen] {
+			labels.Contains = append(labels.Contains, allergen)
+		} else if labels.Complete() {
+			labels.FreeFrom = append(labels.FreeFrom, allergen)
+		}
+	}
+
+	if labels.Complete() {
+		for _, diet := range models.Diets {
+			if suitable[diet] == len(recipe.Ingredients) {
+				labels.SuitableFor = append(labels.SuitableFor, diet)
+			}
+		}
+	}
+
+	return labels
+}
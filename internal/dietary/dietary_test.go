@@ -0,0 +1,85 @@
+package dietary
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+func TestForRecipe(t *testing.T) {
+	flour := &models.Ingredient{ID: 1, Name: "harina", Dietary: &models.DietaryInfo{
+		Allergens: []models.Allergen{models.AllergenGluten},
+		Diets:     []models.Diet{models.DietVegan, models.DietVegetarian, models.DietHalal},
+	}}
+	milk := &models.Ingredient{ID: 2, Name: "leche", Dietary: &models.DietaryInfo{
+		Allergens: []models.Allergen{models.AllergenDairy},
+		Diets:     []models.Diet{models.DietVegetarian, models.DietHalal},
+	}}
+	rice := &models.Ingredient{ID: 3, Name: "arroz", Dietary: &models.DietaryInfo{
+		Allergens: []models.Allergen{},
+		Diets:     []models.Diet{models.DietVegan, models.DietVegetarian, models.DietHalal},
+	}}
+	salt := &models.Ingredient{ID: 4, Name: "sal"}
+	ingredients := map[int]*models.Ingredient{1: flour, 2: milk, 3: rice, 4: salt}
+
+	recipeWith := func(ids ...int) *models.Recipe {
+		recipe := &models.Recipe{Servings: 1}
+		for _, id := range ids {
+			recipe.Ingredients = append(recipe.Ingredients, models.RecipeIngredient{IngredientID: id, Quantity: 1})
+		}
+		return recipe
+	}
+
+	t.Run("fully reviewed recipe", func(t *testing.T) {
+		labels := ForRecipe(recipeWith(1, 2), ingredients)
+
+		expectedContains := []models.Allergen{models.AllergenGluten, models.AllergenDairy}
+		if !reflect.DeepEqual(labels.Contains, expectedContains) {
+			t.Errorf("expected contains %v, got %v", expectedContains, labels.Contains)
+		}
+
+		if len(labels.FreeFrom) != len(models.Allergens)-2 {
+			t.Errorf("expected %d free from labels, got %v", len(models.Allergens)-2, labels.FreeFrom)
+		}
+
+		expectedDiets := []models.Diet{models.DietVegetarian, models.DietHalal}
+		if !reflect.DeepEqual(labels.SuitableFor, expectedDiets) {
+			t.Errorf("expected suitable for %v, got %v", expectedDiets, labels.SuitableFor)
+		}
+
+		if !labels.Complete() {
+			t.Errorf("expected labels to be complete, unreviewed: %v", labels.Unreviewed)
+		}
+	})
+
+	t.Run("safe for celiacs", func(t *testing.T) {
+		labels := ForRecipe(recipeWith(2, 3), ingredients)
+
+		found := false
+		for _, allergen := range labels.FreeFrom {
+			if allergen == models.AllergenGluten {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected recipe to be gluten free, got %v", labels.FreeFrom)
+		}
+	})
+
+	t.Run("unreviewed ingredients prevent guarantees", func(t *testing.T) {
+		labels := ForRecipe(recipeWith(1, 3, 4, 99), ingredients)
+
+		if !reflect.DeepEqual(labels.Contains, []models.Allergen{models.AllergenGluten}) {
+			t.Errorf("expected known allergens to be reported, got %v", labels.Contains)
+		}
+
+		if len(labels.FreeFrom) != 0 || len(labels.SuitableFor) != 0 {
+			t.Errorf("expected no guarantees, got free from %v and suitable for %v", labels.FreeFrom, labels.SuitableFor)
+		}
+
+		if !reflect.DeepEqual(labels.Unreviewed, []string{"sal", "#99"}) {
+			t.Errorf("expected unreviewed [sal #99], got %v", labels.Unreviewed)
+		}
+	})
+}
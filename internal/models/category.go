@@ -0,0 +1,62 @@
+package models
+
+import "strings"
+
+// Category groups ingredients by kind, the way a pantry or a shop would.
+type Category string
+
+const (
+	CategoryUncategorized Category = ""
+	CategorySpices        Category = "spices"
+	CategoryHerbs         Category = "herbs"
+	CategoryOils          Category = "oils"
+	CategoryCondiments    Category = "condiments"
+	CategoryDairy         Category = "dairy"
+	CategoryProteins      Category = "proteins"
+	CategoryLegumes       Category = "legumes"
+	CategoryProduce       Category = "produce"
+	CategoryGrains        Category = "grains"
+	CategoryBaking        Category = "baking"
+	CategorySweeteners    Category = "sweeteners"
+	CategoryCanned        Category = "canned"
+)
+
+// Categories lists every known category in display order.
+var Categories = []Category{
+	CategorySpices,
+	CategoryHerbs,
+	CategoryOils,
+	CategoryCondiments,
+	CategoryDairy,
+	CategoryProteins,
+	CategoryLegumes,
+	CategoryProduce,
+	CategoryGrains,
+	CategoryBaking,
+	CategorySweeteners,
+	CategoryCanned,
+}
+
+// ParseCategory returns the category matching the given name, ignoring case and
+// surrounding spaces. An empty name or "uncategorized" yields CategoryUncategorized.
+func ParseCategory(name string) (Category, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(name))
+	if normalized == "" || normalized == "uncategorized" {
+		return CategoryUncategorized, true
+	}
+
+	for _, category := range Categories {
+		if string(category) == normalized {
+			return category, true
+		}
+	}
+	return CategoryUncategorized, false
+}
+
+// String returns the category name, using "uncategorized" for the empty category.
+func (c Category) String() string {
+	if c == CategoryUncategorized {
+		return "uncategorized"
+	}
+	return string(c)
+}
@@ -0,0 +1,40 @@
+package models
+
+import "testing"
+
+func TestParseCategory(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Category
+		ok       bool
+	}{
+		{"spices", CategorySpices, true},
+		{"  Dairy ", CategoryDairy, true},
+		{"PRODUCE", CategoryProduce, true},
+		{"", CategoryUncategorized, true},
+		{"uncategorized", CategoryUncategorized, true},
+		{"snacks", CategoryUncategorized, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			category, ok := ParseCategory(tc.input)
+			if ok != tc.ok {
+				t.Fatalf("expected ok to be %v, got %v", tc.ok, ok)
+			}
+			if category != tc.expected {
+				t.Errorf("expected category %q, got %q", tc.expected, category)
+			}
+		})
+	}
+}
+
+func TestCategoryString(t *testing.T) {
+	if CategoryUncategorized.String() != "uncategorized" {
+		t.Errorf("expected %q, got %q", "uncategorized", CategoryUncategorized.String())
+	}
+
+	if CategoryOils.String() != "oils" {
+		t.Errorf("expected %q, got %q", "oils", CategoryOils.String())
+	}
+}
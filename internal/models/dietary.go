@@ -0,0 +1,105 @@
+package models
+
+import "strings"
+
+// Allergen is a substance an ingredient may contain that causes allergies or intolerances.
+type Allergen string
+
+const (
+	AllergenGluten    Allergen = "gluten"
+	AllergenDairy     Allergen = "dairy"
+	AllergenEgg       Allergen = "egg"
+	AllergenNut       Allergen = "nut"
+	AllergenPeanut    Allergen = "peanut"
+	AllergenSoy       Allergen = "soy"
+	AllergenFish      Allergen = "fish"
+	AllergenShellfish Allergen = "shellfish"
+	AllergenSesame    Allergen = "sesame"
+	AllergenCelery    Allergen = "celery"
+	AllergenMustard   Allergen = "mustard"
+	AllergenSulphites Allergen = "sulphites"
+)
+
+// Allergens lists every known allergen in display order.
+var Allergens = []Allergen{
+	AllergenGluten,
+	AllergenDairy,
+	AllergenEgg,
+	AllergenNut,
+	AllergenPeanut,
+	AllergenSoy,
+	AllergenFish,
+	AllergenShellfish,
+	AllergenSesame,
+	AllergenCelery,
+	AllergenMustard,
+	AllergenSulphites,
+}
+
+// Diet is a dietary regime an ingredient can be suitable for.
+type Diet string
+
+const (
+	DietVegan      Diet = "vegan"
+	DietVegetarian Diet = "vegetarian"
+	DietHalal      Diet = "halal"
+)
+
+// Diets lists every known diet in display order.
+var Diets = []Diet{
+	DietVegan,
+	DietVegetarian,
+	DietHalal,
+}
+
+// DietaryInfo holds the allergens an ingredient contains and the diets it is
+// suitable for. An ingredient without DietaryInfo has not been reviewed yet,
+// while an empty list of allergens means it was reviewed and contains none.
+type DietaryInfo struct {
+	Allergens []Allergen `json:"allergens"`
+	Diets     []Diet     `json:"diets"`
+}
+
+// ParseAllergen returns the allergen matching the given name, ignoring case and
+// surrounding spaces.
+func ParseAllergen(name string) (Allergen, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(name))
+	for _, allergen := range Allergens {
+		if string(allergen) == normalized {
+			return allergen, true
+		}
+	}
+	return "", false
+}
+
+// ParseDiet returns the diet matching the given name, ignoring case and
+// surrounding spaces.
+func ParseDiet(name string) (Diet, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(name))
+	for _, diet := range Diets {
+		if string(diet) == normalized {
+			return diet, true
+		}
+	}
+	return "", false
+}
+
+// Contains reports whether the allergen is listed.
+func (d DietaryInfo) Contains(allergen Allergen) bool {
+	for _, listed := range d.Allergens {
+		if listed == allergen {
+			return true
+		}
+	}
+	return false
+}
+
+// SuitableFor reports whether the diet is listed.
+func (d DietaryInfo) SuitableFor(diet Diet) bool {
+	for _, listed := range d.Diets {
+		if listed == diet {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package models
+
+import "testing"
+
+func TestParseAllergen(t *testing.T) {
+	allergen, ok := ParseAllergen(" Gluten ")
+	if !ok || allergen != AllergenGluten {
+		t.Errorf("expected %q, got %q (ok=%v)", AllergenGluten, allergen, ok)
+	}
+
+	if _, ok := ParseAllergen("kryptonite"); ok {
+		t.Errorf("expected kryptonite to be an unknown allergen")
+	}
+}
+
+func TestParseDiet(t *testing.T) {
+	diet, ok := ParseDiet("VEGAN")
+	if !ok || diet != DietVegan {
+		t.Errorf("expected %q, got %q (ok=%v)", DietVegan, diet, ok)
+	}
+
+	if _, ok := ParseDiet("carnivore"); ok {
+		t.Errorf("expected carnivore to be an unknown diet")
+	}
+}
+
+func TestDietaryInfo(t *testing.T) {
+	info := DietaryInfo{
+		Allergens: []Allergen{AllergenDairy},
+		Diets:     []Diet{DietVegetarian},
+	}
+
+	if !info.Contains(AllergenDairy) || info.Contains(AllergenGluten) {
+		t.Errorf("unexpected allergens for %+v", info)
+	}
+
+	if !info.SuitableFor(DietVegetarian) || info.SuitableFor(DietVegan) {
+		t.Errorf("unexpected diets for %+v", info)
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+// IngredientEventKind is the kind of change an ingredient event reports.
+type IngredientEventKind string
+
+const (
+	IngredientCreated  IngredientEventKind = "created"
+	IngredientRenamed  IngredientEventKind = "renamed"
+	IngredientUpdated  IngredientEventKind = "updated"
+	IngredientDeleted  IngredientEventKind = "deleted"
+	IngredientRestored IngredientEventKind = "restored"
+	IngredientPurged   IngredientEventKind = "purged"
+)
+
+// IngredientEvent reports a change to an ingredient to the watchers of a
+// storage. Ingredient is the ingredient as it is after the change, or as it
+// was when it was purged.
+type IngredientEvent struct {
+	Kind       IngredientEventKind `json:"kind"`
+	Ingredient *Ingredient         `json:"ingredient"`
+	// OldName is the previous name of a renamed ingredient.
+	OldName string `json:"old_name,omitempty"`
+	// RevisionID is the revision recording the change, zero for purges.
+	RevisionID int `json:"revision_id,omitempty"`
+}
@@ -1,25 +1,112 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Ingredient represents a cooking ingredient.
-// TODO: Add calories_per_100g field for nutritional tracking
-// TODO: Future - add protein, fat, carbs per 100g
-// TODO: Future - add unit conversion fields (piece_weight_grams, piece_name, density_g_per_ml)
 type Ingredient struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	Aliases   []Alias         `json:"aliases,omitempty"`
+	Category  Category        `json:"category,omitempty"`
+	Dietary   *DietaryInfo    `json:"dietary,omitempty"`
+	Nutrition *NutritionFacts `json:"nutrition,omitempty"`
+	UnitConversion
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is set while the ingredient is in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version starts at 1 and grows with every change to the ingredient.
+	Version int `json:"version"`
+}
+
+// Alias is an alternative name for an ingredient, such as a translation.
+// Locale is an optional language tag like "en" or "es-MX".
+type Alias struct {
+	Name   string `json:"name"`
+	Locale string `json:"locale,omitempty"`
+}
+
+// UnitConversion holds what is needed to convert quantities of an ingredient
+// between mass, volume and pieces. Zero values mean the value is unknown.
+type UnitConversion struct {
+	PieceWeightGrams float64 `json:"piece_weight_grams,omitempty"`
+	PieceName        string  `json:"piece_name,omitempty"`
+	DensityGPerMl    float64 `json:"density_g_per_ml,omitempty"`
+}
+
+// NutritionFacts holds the nutritional values of 100 grams of an ingredient.
+// Macronutrients are expressed in grams and sodium in milligrams.
+type NutritionFacts struct {
+	CaloriesPer100g float64 `json:"calories_per_100g"`
+	ProteinPer100g  float64 `json:"protein_per_100g"`
+	FatPer100g      float64 `json:"fat_per_100g"`
+	CarbsPer100g    float64 `json:"carbs_per_100g"`
+	FiberPer100g    float64 `json:"fiber_per_100g"`
+	SugarPer100g    float64 `json:"sugar_per_100g"`
+	SodiumMgPer100g float64 `json:"sodium_mg_per_100g"`
 }
 
 // NewIngredient creates a new ingredient.
 func NewIngredient(id int, name string) *Ingredient {
-	now := time.Now()
+	return NewIngredientAt(id, name, time.Now())
+}
+
+// NewIngredientAt creates a new ingredient, created at the given time.
+func NewIngredientAt(id int, name string, now time.Time) *Ingredient {
 	return &Ingredient{
 		ID:        id,
 		Name:      name,
 		CreatedAt: now,
 		UpdatedAt: now,
+		Version:   1,
+	}
+}
+
+// IsDeleted reports whether the ingredient is in the trash.
+func (i *Ingredient) IsDeleted() bool {
+	return i.DeletedAt != nil
+}
+
+// HasName reports whether the given name matches the ingredient name or any of
+// its aliases, ignoring case.
+func (i *Ingredient) HasName(name string) bool {
+	if strings.EqualFold(i.Name, name) {
+		return true
+	}
+	for _, alias := range i.Aliases {
+		if strings.EqualFold(alias.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a deep copy of the ingredient, so changes to the copy never
+// reach the original.
+func (i *Ingredient) Clone() *Ingredient {
+	clone := *i
+	if i.Aliases != nil {
+		clone.Aliases = append([]Alias(nil), i.Aliases...)
+	}
+	if i.Dietary != nil {
+		dietary := DietaryInfo{
+			Allergens: make([]Allergen, len(i.Dietary.Allergens)),
+			Diets:     make([]Diet, len(i.Dietary.Diets)),
+		}
+		copy(dietary.Allergens, i.Dietary.Allergens)
+		copy(dietary.Diets, i.Dietary.Diets)
+		clone.Dietary = &dietary
+	}
+	if i.Nutrition != nil {
+		nutrition := *i.Nutrition
+		clone.Nutrition = &nutrition
+	}
+	if i.DeletedAt != nil {
+		deletedAt := *i.DeletedAt
+		clone.DeletedAt = &deletedAt
 	}
+	return &clone
 }
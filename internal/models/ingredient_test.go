@@ -36,3 +36,71 @@ func TestNewIngredient(t *testing.T) {
 		}
 	})
 }
+
+func TestIngredientHasName(t *testing.T) {
+	ingredient := NewIngredient(1, "pimienta negra")
+	ingredient.Aliases = []Alias{{Name: "black pepper", Locale: "en"}}
+
+	testCases := []struct {
+		name     string
+		expected bool
+	}{
+		{"pimienta negra", true},
+		{"Pimienta Negra", true},
+		{"black pepper", true},
+		{"BLACK PEPPER", true},
+		{"pepper", false},
+	}
+
+	for _, tc := range testCases {
+		if got := ingredient.HasName(tc.name); got != tc.expected {
+			t.Errorf("HasName(%q): expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestIngredientClone(t *testing.T) {
+	ingredient := NewIngredient(1, "leche")
+	ingredient.Aliases = []Alias{{Name: "milk", Locale: "en"}}
+	ingredient.Dietary = &DietaryInfo{Allergens: []Allergen{AllergenDairy}, Diets: []Diet{}}
+	ingredient.Nutrition = &NutritionFacts{CaloriesPer100g: 64}
+	ingredient.DensityGPerMl = 1.03
+	deletedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ingredient.DeletedAt = &deletedAt
+
+	clone := ingredient.Clone()
+	clone.Aliases[0].Name = "latte"
+	clone.Dietary.Allergens[0] = AllergenEgg
+	clone.Nutrition.CaloriesPer100g = 0
+	clone.DensityGPerMl = 1
+	*clone.DeletedAt = time.Time{}
+
+	if ingredient.Aliases[0].Name != "milk" {
+		t.Errorf("expected original alias to be untouched, got %q", ingredient.Aliases[0].Name)
+	}
+
+	if ingredient.Dietary.Allergens[0] != AllergenDairy {
+		t.Errorf("expected original allergens to be untouched, got %v", ingredient.Dietary.Allergens)
+	}
+
+	if ingredient.Nutrition.CaloriesPer100g != 64 {
+		t.Errorf("expected original nutrition to be untouched, got %v", ingredient.Nutrition.CaloriesPer100g)
+	}
+
+	if ingredient.DensityGPerMl != 1.03 {
+		t.Errorf("expected original density to be untouched, got %v", ingredient.DensityGPerMl)
+	}
+
+	if !ingredient.DeletedAt.Equal(deletedAt) {
+		t.Errorf("expected original deletion date to be untouched, got %v", ingredient.DeletedAt)
+	}
+
+	if clone.Dietary.Diets == nil {
+		t.Error("expected empty diets to stay non-nil")
+	}
+
+	empty := NewIngredient(2, "sal").Clone()
+	if empty.Aliases != nil || empty.Dietary != nil || empty.Nutrition != nil || empty.DeletedAt != nil {
+		t.Errorf("expected unset fields to stay unset, got %+v", empty)
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// MealSlot is the moment of the day a meal is planned for.
+type MealSlot string
+
+const (
+	MealSlotBreakfast MealSlot = "desayuno"
+	MealSlotLunch     MealSlot = "comida"
+	MealSlotDinner    MealSlot = "cena"
+)
+
+// MealSlots lists every meal slot in the order they happen during the day.
+var MealSlots = []MealSlot{
+	MealSlotBreakfast,
+	MealSlotLunch,
+	MealSlotDinner,
+}
+
+// ParseMealSlot returns the meal slot matching the given name, ignoring case
+// and surrounding spaces.
+func ParseMealSlot(name string) (MealSlot, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(name))
+	for _, slot := range MealSlots {
+		if string(slot) == normalized {
+			return slot, true
+		}
+	}
+	return "", false
+}
+
+// Order returns the position of the slot within the day, or -1 for unknown slots.
+func (s MealSlot) Order() int {
+	for i, slot := range MealSlots {
+		if slot == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// PlannedMeal represents a recipe planned for a meal slot on a given day.
+type PlannedMeal struct {
+	ID        int       `json:"id"`
+	Date      time.Time `json:"date"`
+	Slot      MealSlot  `json:"slot"`
+	RecipeID  int       `json:"recipe_id"`
+	Servings  int       `json:"servings"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewPlannedMeal creates a new planned meal.
+func NewPlannedMeal(id int, date time.Time, slot MealSlot, recipeID, servings int) *PlannedMeal {
+	now := time.Now()
+	return &PlannedMeal{
+		ID:        id,
+		Date:      date,
+		Slot:      slot,
+		RecipeID:  recipeID,
+		Servings:  servings,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Clone returns a copy of the planned meal, so changes to the copy never
+// reach the original.
+func (p *PlannedMeal) Clone() *PlannedMeal {
+	clone := *p
+	return &clone
+}
+
+// WeekStart returns the Monday of the week the given date falls in, at midnight UTC.
+func WeekStart(date time.Time) time.Time {
+	year, month, day := date.Date()
+	// weeks start on Monday, while time.Weekday starts on Sunday
+	offset := (int(date.Weekday()) + 6) % 7
+	return time.Date(year, month, day-offset, 0, 0, 0, 0, time.UTC)
+}
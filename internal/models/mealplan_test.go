@@ -0,0 +1,81 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMealSlot(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected MealSlot
+		ok       bool
+	}{
+		{"desayuno", MealSlotBreakfast, true},
+		{"  Comida ", MealSlotLunch, true},
+		{"CENA", MealSlotDinner, true},
+		{"merienda", "", false},
+		{"", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			slot, ok := ParseMealSlot(tc.input)
+			if ok != tc.ok {
+				t.Fatalf("expected ok to be %v, got %v", tc.ok, ok)
+			}
+			if slot != tc.expected {
+				t.Errorf("expected slot %q, got %q", tc.expected, slot)
+			}
+		})
+	}
+}
+
+func TestMealSlotOrder(t *testing.T) {
+	if MealSlotBreakfast.Order() >= MealSlotLunch.Order() || MealSlotLunch.Order() >= MealSlotDinner.Order() {
+		t.Error("expected desayuno, comida and cena in that order")
+	}
+
+	if MealSlot("merienda").Order() != -1 {
+		t.Errorf("expected -1 for an unknown slot, got %d", MealSlot("merienda").Order())
+	}
+}
+
+func TestNewPlannedMeal(t *testing.T) {
+	date := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	meal := NewPlannedMeal(1, date, MealSlotLunch, 7, 4)
+
+	if meal.ID != 1 || meal.RecipeID != 7 || meal.Servings != 4 {
+		t.Errorf("expected ID 1, recipe 7 and 4 servings, got %d, %d and %d", meal.ID, meal.RecipeID, meal.Servings)
+	}
+
+	if !meal.Date.Equal(date) || meal.Slot != MealSlotLunch {
+		t.Errorf("expected comida on %v, got %s on %v", date, meal.Slot, meal.Date)
+	}
+
+	if meal.CreatedAt.IsZero() || !meal.CreatedAt.Equal(meal.UpdatedAt) {
+		t.Errorf("creation and update dates should be set and equal")
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	monday := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name string
+		date time.Time
+	}{
+		{"monday", monday},
+		{"wednesday afternoon", time.Date(2025, 3, 5, 16, 30, 0, 0, time.UTC)},
+		{"sunday", time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := WeekStart(tc.date); !got.Equal(monday) {
+				t.Errorf("expected %v, got %v", monday, got)
+			}
+		})
+	}
+}
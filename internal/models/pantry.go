@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// PantryItem represents one batch of an ingredient we have at home. Buying the
+// same ingredient twice creates two items, so each keeps its own dates.
+type PantryItem struct {
+	ID           int        `json:"id"`
+	IngredientID int        `json:"ingredient_id"`
+	Quantity     float64    `json:"quantity"`
+	Unit         string     `json:"unit"`
+	PurchasedOn  time.Time  `json:"purchased_on"`
+	BestBefore   *time.Time `json:"best_before,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// NewPantryItem creates a new pantry item.
+func NewPantryItem(id, ingredientID int, quantity float64, unit string, purchasedOn time.Time, bestBefore *time.Time) *PantryItem {
+	now := time.Now()
+	return &PantryItem{
+		ID:           id,
+		IngredientID: ingredientID,
+		Quantity:     quantity,
+		Unit:         unit,
+		PurchasedOn:  purchasedOn,
+		BestBefore:   bestBefore,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// Clone returns a deep copy of the item, so changes to the copy never reach
+// the original.
+func (p *PantryItem) Clone() *PantryItem {
+	clone := *p
+	if p.BestBefore != nil {
+		bestBefore := *p.BestBefore
+		clone.BestBefore = &bestBefore
+	}
+	return &clone
+}
+
+// ExpiresBy reports whether the item reaches its best-before date on or before
+// the given date. Items without a best-before date never expire.
+func (p *PantryItem) ExpiresBy(date time.Time) bool {
+	return p.BestBefore != nil && !p.BestBefore.After(date)
+}
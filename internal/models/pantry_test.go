@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewPantryItem(t *testing.T) {
+	purchasedOn := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	bestBefore := time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC)
+
+	item := NewPantryItem(1, 7, 1.5, "l", purchasedOn, &bestBefore)
+
+	if item.ID != 1 || item.IngredientID != 7 {
+		t.Errorf("expected ID 1 and ingredient ID 7, got %d and %d", item.ID, item.IngredientID)
+	}
+
+	if item.Quantity != 1.5 || item.Unit != "l" {
+		t.Errorf("expected 1.5 l, got %v %q", item.Quantity, item.Unit)
+	}
+
+	if !item.PurchasedOn.Equal(purchasedOn) {
+		t.Errorf("expected purchase date %v, got %v", purchasedOn, item.PurchasedOn)
+	}
+
+	if item.BestBefore == nil || !item.BestBefore.Equal(bestBefore) {
+		t.Errorf("expected best-before date %v, got %v", bestBefore, item.BestBefore)
+	}
+
+	if item.CreatedAt.IsZero() || !item.CreatedAt.Equal(item.UpdatedAt) {
+		t.Errorf("creation and update dates should be set and equal")
+	}
+}
+
+func TestPantryItemClone(t *testing.T) {
+	bestBefore := time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC)
+	item := NewPantryItem(1, 7, 1.5, "l", bestBefore.AddDate(0, 0, -7), &bestBefore)
+
+	clone := item.Clone()
+	clone.Quantity = 0
+	*clone.BestBefore = time.Time{}
+
+	if item.Quantity != 1.5 {
+		t.Errorf("expected original quantity to be untouched, got %v", item.Quantity)
+	}
+
+	if !item.BestBefore.Equal(bestBefore) {
+		t.Errorf("expected original best-before date to be untouched, got %v", item.BestBefore)
+	}
+
+	if (&PantryItem{}).Clone().BestBefore != nil {
+		t.Error("expected an unset best-before date to stay unset")
+	}
+}
+
+func TestPantryItemExpiresBy(t *testing.T) {
+	bestBefore := time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC)
+	item := &PantryItem{BestBefore: &bestBefore}
+
+	testCases := []struct {
+		name     string
+		date     time.Time
+		expected bool
+	}{
+		{"before the best-before date", bestBefore.AddDate(0, 0, -1), false},
+		{"on the best-before date", bestBefore, true},
+		{"after the best-before date", bestBefore.AddDate(0, 0, 1), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := item.ExpiresBy(tc.date); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	t.Run("without best-before date", func(t *testing.T) {
+		item := &PantryItem{}
+		if item.ExpiresBy(bestBefore.AddDate(10, 0, 0)) {
+			t.Error("item without best-before date should never expire")
+		}
+	})
+}
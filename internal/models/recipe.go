@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// RecipeIngredient represents a single ingredient line of a recipe.
+type RecipeIngredient struct {
+	IngredientID int     `json:"ingredient_id"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit"`
+}
+
+// Recipe represents a cooking recipe.
+type Recipe struct {
+	ID          int                `json:"id"`
+	Title       string             `json:"title"`
+	Servings    int                `json:"servings"`
+	Steps       []string           `json:"steps"`
+	Ingredients []RecipeIngredient `json:"ingredients"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// NewRecipe creates a new recipe.
+func NewRecipe(id int, title string, servings int, steps []string, ingredients []RecipeIngredient) *Recipe {
+	now := time.Now()
+	return &Recipe{
+		ID:          id,
+		Title:       title,
+		Servings:    servings,
+		Steps:       steps,
+		Ingredients: ingredients,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// Clone returns a deep copy of the recipe, so changes to the copy never reach
+// the original.
+func (r *Recipe) Clone() *Recipe {
+	clone := *r
+	if r.Steps != nil {
+		clone.Steps = append([]string(nil), r.Steps...)
+	}
+	if r.Ingredients != nil {
+		clone.Ingredients = append([]RecipeIngredient(nil), r.Ingredients...)
+	}
+	return &clone
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRecipe(t *testing.T) {
+	t.Run("successful model creation", func(t *testing.T) {
+		id := 1
+		title := "tortilla de patatas"
+		servings := 4
+		steps := []string{"fry the potatoes", "beat the eggs", "cook everything together"}
+		ingredients := []RecipeIngredient{
+			{IngredientID: 1, Quantity: 500, Unit: "g"},
+			{IngredientID: 2, Quantity: 6, Unit: ""},
+		}
+
+		recipe := NewRecipe(id, title, servings, steps, ingredients)
+
+		if recipe.ID != id {
+			t.Errorf("expected ID %d, got %d", id, recipe.ID)
+		}
+
+		if recipe.Title != title {
+			t.Errorf("expected title %q, got %q", title, recipe.Title)
+		}
+
+		if recipe.Servings != servings {
+			t.Errorf("expected %d servings, got %d", servings, recipe.Servings)
+		}
+
+		if len(recipe.Steps) != len(steps) {
+			t.Errorf("expected %d steps, got %d", len(steps), len(recipe.Steps))
+		}
+
+		if len(recipe.Ingredients) != len(ingredients) {
+			t.Errorf("expected %d ingredients, got %d", len(ingredients), len(recipe.Ingredients))
+		}
+
+		if time.Since(recipe.CreatedAt) > time.Second {
+			t.Errorf("expected creation date to be recent, got %v", recipe.CreatedAt)
+		}
+
+		if !recipe.UpdatedAt.Equal(recipe.CreatedAt) {
+			t.Errorf("expected update date %v to match creation date %v", recipe.UpdatedAt, recipe.CreatedAt)
+		}
+	})
+}
+
+func TestRecipeClone(t *testing.T) {
+	recipe := NewRecipe(1, "gazpacho", 4, []string{"blend everything"}, []RecipeIngredient{{IngredientID: 1, Quantity: 1, Unit: "kg"}})
+
+	clone := recipe.Clone()
+	clone.Title = "salmorejo"
+	clone.Steps[0] = "chill"
+	clone.Ingredients[0].Quantity = 2
+
+	if recipe.Title != "gazpacho" {
+		t.Errorf("expected original title to be untouched, got %q", recipe.Title)
+	}
+
+	if recipe.Steps[0] != "blend everything" {
+		t.Errorf("expected original steps to be untouched, got %v", recipe.Steps)
+	}
+
+	if recipe.Ingredients[0].Quantity != 1 {
+		t.Errorf("expected original ingredients to be untouched, got %v", recipe.Ingredients)
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// RevisionChange is the kind of change a revision records.
+type RevisionChange string
+
+const (
+	RevisionCreate            RevisionChange = "create"
+	RevisionRename            RevisionChange = "rename"
+	RevisionDelete            RevisionChange = "delete"
+	RevisionRestore           RevisionChange = "restore"
+	RevisionAddAlias          RevisionChange = "add_alias"
+	RevisionRemoveAlias       RevisionChange = "remove_alias"
+	RevisionSetCategory       RevisionChange = "set_category"
+	RevisionSetDietaryInfo    RevisionChange = "set_dietary_info"
+	RevisionSetNutrition      RevisionChange = "set_nutrition"
+	RevisionSetUnitConversion RevisionChange = "set_unit_conversion"
+	RevisionUndo              RevisionChange = "undo"
+)
+
+// Revision records a single change to an ingredient, holding the ingredient
+// as it was before the change, if it existed, and as it was after it.
+type Revision struct {
+	ID           int            `json:"id"`
+	IngredientID int            `json:"ingredient_id"`
+	Change       RevisionChange `json:"change"`
+	Before       *Ingredient    `json:"before,omitempty"`
+	After        *Ingredient    `json:"after"`
+	// SessionID identifies the client session that made the change, if any.
+	SessionID string `json:"session_id,omitempty"`
+	// Reverts is the ID of the revision an undo revision reverted.
+	Reverts   int       `json:"reverts,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Clone returns a deep copy of the revision, including the ingredients it holds.
+func (r *Revision) Clone() *Revision {
+	clone := *r
+	if r.Before != nil {
+		clone.Before = r.Before.Clone()
+	}
+	if r.After != nil {
+		clone.After = r.After.Clone()
+	}
+	return &clone
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// ShoppingListItem is a quantity of an ingredient still to be bought.
+type ShoppingListItem struct {
+	IngredientID int     `json:"ingredient_id"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit"`
+	Checked      bool    `json:"checked"`
+}
+
+// ShoppingList represents a consolidated list of ingredients to buy.
+type ShoppingList struct {
+	ID        int                `json:"id"`
+	Items     []ShoppingListItem `json:"items"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// NewShoppingList creates a new shopping list.
+func NewShoppingList(id int, items []ShoppingListItem) *ShoppingList {
+	now := time.Now()
+	return &ShoppingList{
+		ID:        id,
+		Items:     items,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Clone returns a deep copy of the list, so changes to the copy never reach
+// the original.
+func (l *ShoppingList) Clone() *ShoppingList {
+	clone := *l
+	if l.Items != nil {
+		clone.Items = append([]ShoppingListItem(nil), l.Items...)
+	}
+	return &clone
+}
+
+// Remaining returns how many items have not been checked off yet.
+func (l *ShoppingList) Remaining() int {
+	remaining := 0
+	for _, item := range l.Items {
+		if !item.Checked {
+			remaining++
+		}
+	}
+	return remaining
+}
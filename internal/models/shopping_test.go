@@ -0,0 +1,47 @@
+package models
+
+import "testing"
+
+func TestNewShoppingList(t *testing.T) {
+	items := []ShoppingListItem{
+		{IngredientID: 1, Quantity: 500, Unit: "g"},
+		{IngredientID: 2, Quantity: 6, Unit: ""},
+	}
+
+	list := NewShoppingList(1, items)
+
+	if list.ID != 1 {
+		t.Errorf("expected ID 1, got %d", list.ID)
+	}
+
+	if len(list.Items) != len(items) {
+		t.Errorf("expected %d items, got %d", len(items), len(list.Items))
+	}
+
+	if list.CreatedAt.IsZero() || !list.CreatedAt.Equal(list.UpdatedAt) {
+		t.Errorf("creation and update dates should be set and equal")
+	}
+}
+
+func TestShoppingListClone(t *testing.T) {
+	list := NewShoppingList(1, []ShoppingListItem{{IngredientID: 1, Quantity: 2, Unit: "kg"}})
+
+	clone := list.Clone()
+	clone.Items[0].Checked = true
+
+	if list.Items[0].Checked {
+		t.Error("expected original items to be untouched")
+	}
+}
+
+func TestShoppingListRemaining(t *testing.T) {
+	list := &ShoppingList{Items: []ShoppingListItem{
+		{IngredientID: 1, Checked: true},
+		{IngredientID: 2},
+		{IngredientID: 3},
+	}}
+
+	if remaining := list.Remaining(); remaining != 2 {
+		t.Errorf("expected 2 remaining items, got %d", remaining)
+	}
+}
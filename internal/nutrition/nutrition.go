@@ -0,0 +1,122 @@
+package nutrition
+
+import (
+	"fmt"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/units"
+)
+
+// Totals holds absolute nutritional values. Macronutrients are expressed in
+// grams and sodium in milligrams.
+type Totals struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Fat      float64 `json:"fat"`
+	Carbs    float64 `json:"carbs"`
+	Fiber    float64 `json:"fiber"`
+	Sugar    float64 `json:"sugar"`
+	SodiumMg float64 `json:"sodium_mg"`
+}
+
+// Skipped describes a recipe ingredient that could not be included in the totals.
+type Skipped struct {
+	IngredientID int    `json:"ingredient_id"`
+	Name         string `json:"name"`
+	Reason       string `json:"reason"`
+}
+
+// Summary holds the nutrition of a whole recipe and of a single serving.
+type Summary struct {
+	Servings   int       `json:"servings"`
+	Total      Totals    `json:"total"`
+	PerServing Totals    `json:"per_serving"`
+	Skipped    []Skipped `json:"skipped"`
+}
+
+// ForRecipe computes the nutrition of a recipe from the nutrition facts of its
+// ingredients. Ingredients without nutrition facts, or whose quantity cannot be
+// converted to grams, are reported as skipped instead of failing the whole computation.
+func ForRecipe(recipe *models.Recipe, ingredients map[int]*models.Ingredient) Summary {
+	summary := Summary{
+		Servings: recipe.Servings,
+		Skipped:  make([]Skipped, 0),
+	}
+
+	for _, line := range recipe.Ingredients {
+		ingredient, ok := ingredients[line.IngredientID]
+		if !ok {
+			summary.Skipped = append(summary.Skipped, Skipped{
+				IngredientID: line.IngredientID,
+				Name:         fmt.Sprintf("#%d", line.IngredientID),
+				Reason:       "unknown ingredient",
+			})
+			continue
+		}
+
+		if ingredient.Nutrition == nil {
+			summary.Skipped = append(summary.Skipped, Skipped{
+				IngredientID: ingredient.ID,
+				Name:         ingredient.Name,
+				Reason:       "no nutrition data",
+			})
+			continue
+		}
+
+		grams, err := units.ToGrams(line.Quantity, line.Unit, ingredient.UnitConversion)
+		if err != nil {
+			summary.Skipped = append(summary.Skipped, Skipped{
+				IngredientID: ingredient.ID,
+				Name:         ingredient.Name,
+				Reason:       err.Error(),
+			})
+			continue
+		}
+
+		summary.Total = summary.Total.add(scale(*ingredient.Nutrition, grams))
+	}
+
+	if recipe.Servings > 0 {
+		summary.PerServing = summary.Total.divide(float64(recipe.Servings))
+	}
+
+	return summary
+}
+
+// scale returns the nutrition of the given amount of grams of an ingredient.
+func scale(facts models.NutritionFacts, grams float64) Totals {
+	ratio := grams / 100
+	return Totals{
+		Calories: facts.CaloriesPer100g * ratio,
+		Protein:  facts.ProteinPer100g * ratio,
+		Fat:      facts.FatPer100g * ratio,
+		Carbs:    facts.CarbsPer100g * ratio,
+		Fiber:    facts.FiberPer100g * ratio,
+		Sugar:    facts.SugarPer100g * ratio,
+		SodiumMg: facts.SodiumMgPer100g * ratio,
+	}
+}
+
+func (t Totals) add(other Totals) Totals {
+	return Totals{
+		Calories: t.Calories + other.Calories,
+		Protein:  t.Protein + other.Protein,
+		Fat:      t.Fat + other.Fat,
+		Carbs:    t.Carbs + other.Carbs,
+		Fiber:    t.Fiber + other.Fiber,
+		Sugar:    t.Sugar + other.Sugar,
+		SodiumMg: t.SodiumMg + other.SodiumMg,
+	}
+}
+
+func (t Totals) divide(divisor float64) Totals {
+	return Totals{
+		Calories: t.Calories / divisor,
+		Protein:  t.Protein / divisor,
+		Fat:      t.Fat / divisor,
+		Carbs:    t.Carbs / divisor,
+		Fiber:    t.Fiber / divisor,
+		Sugar:    t.Sugar / divisor,
+		SodiumMg: t.SodiumMg / divisor,
+	}
+}
@@ -0,0 +1,97 @@
+package nutrition
+
+import (
+	"math"
+	"testing"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestForRecipe(t *testing.T) {
+	rice := &models.Ingredient{ID: 1, Name: "arroz", Nutrition: &models.NutritionFacts{
+		CaloriesPer100g: 360, ProteinPer100g: 7, FatPer100g: 1, CarbsPer100g: 80, FiberPer100g: 1, SugarPer100g: 0, SodiumMgPer100g: 5,
+	}}
+	chicken := &models.Ingredient{ID: 2, Name: "pollo", Nutrition: &models.NutritionFacts{
+		CaloriesPer100g: 120, ProteinPer100g: 22, FatPer100g: 3, SodiumMgPer100g: 70,
+	}}
+	salt := &models.Ingredient{ID: 3, Name: "sal"}
+	oil := &models.Ingredient{ID: 4, Name: "aceite de oliva",
+		Nutrition:      &models.NutritionFacts{CaloriesPer100g: 900, FatPer100g: 100},
+		UnitConversion: models.UnitConversion{DensityGPerMl: 0.92},
+	}
+	ingredients := map[int]*models.Ingredient{1: rice, 2: chicken, 3: salt, 4: oil}
+
+	t.Run("totals and per serving values", func(t *testing.T) {
+		recipe := &models.Recipe{
+			Servings: 4,
+			Ingredients: []models.RecipeIngredient{
+				{IngredientID: 1, Quantity: 300, Unit: "g"},
+				{IngredientID: 2, Quantity: 0.5, Unit: "kg"},
+			},
+		}
+
+		summary := ForRecipe(recipe, ingredients)
+
+		if !almostEqual(summary.Total.Calories, 360*3+120*5) {
+			t.Errorf("expected %v total calories, got %v", 360*3+120*5, summary.Total.Calories)
+		}
+		if !almostEqual(summary.Total.Protein, 7*3+22*5) {
+			t.Errorf("expected %v total protein, got %v", 7*3+22*5, summary.Total.Protein)
+		}
+		if !almostEqual(summary.Total.SodiumMg, 5*3+70*5) {
+			t.Errorf("expected %v total sodium, got %v", 5*3+70*5, summary.Total.SodiumMg)
+		}
+		if !almostEqual(summary.PerServing.Calories, summary.Total.Calories/4) {
+			t.Errorf("expected %v calories per serving, got %v", summary.Total.Calories/4, summary.PerServing.Calories)
+		}
+		if len(summary.Skipped) != 0 {
+			t.Errorf("expected no skipped ingredients, got %v", summary.Skipped)
+		}
+	})
+
+	t.Run("converts volumes through density", func(t *testing.T) {
+		recipe := &models.Recipe{
+			Servings:    1,
+			Ingredients: []models.RecipeIngredient{{IngredientID: 4, Quantity: 1, Unit: "tbsp"}},
+		}
+
+		summary := ForRecipe(recipe, ingredients)
+
+		if !almostEqual(summary.Total.Fat, 15*0.92) {
+			t.Errorf("expected %v total fat, got %v", 15*0.92, summary.Total.Fat)
+		}
+	})
+
+	t.Run("skips ingredients that cannot be computed", func(t *testing.T) {
+		recipe := &models.Recipe{
+			Servings: 2,
+			Ingredients: []models.RecipeIngredient{
+				{IngredientID: 1, Quantity: 100, Unit: "g"},
+				{IngredientID: 2, Quantity: 1, Unit: "cup"},
+				{IngredientID: 3, Quantity: 5, Unit: "g"},
+				{IngredientID: 99, Quantity: 1, Unit: "g"},
+			},
+		}
+
+		summary := ForRecipe(recipe, ingredients)
+
+		if !almostEqual(summary.Total.Calories, 360) {
+			t.Errorf("expected 360 total calories, got %v", summary.Total.Calories)
+		}
+
+		if len(summary.Skipped) != 3 {
+			t.Fatalf("expected 3 skipped ingredients, got %v", summary.Skipped)
+		}
+
+		expectedNames := []string{"pollo", "sal", "#99"}
+		for i, name := range expectedNames {
+			if summary.Skipped[i].Name != name {
+				t.Errorf("expected skipped ingredient %q, got %q", name, summary.Skipped[i].Name)
+			}
+		}
+	})
+}
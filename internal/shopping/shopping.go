@@ -0,0 +1,160 @@
+package shopping
+
+import (
+	"sort"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/units"
+)
+
+// epsilon absorbs floating point noise, so a need left with a few nanograms
+// after subtracting the pantry is not worth buying.
+const epsilon = 1e-9
+
+// Portion is a recipe cooked for a given number of servings.
+type Portion struct {
+	Recipe   *models.Recipe
+	Servings int
+}
+
+// Need is a quantity of an ingredient required by a set of recipes.
+type Need struct {
+	IngredientID int     `json:"ingredient_id"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit"`
+}
+
+// Needs sums the ingredient quantities required to cook every portion, scaling
+// each recipe to the requested servings. Lines of the same ingredient are
+// merged whenever their units can be converted into one another, so "200 g"
+// and "1 kg" of rice become "1200 g"; lines in incompatible units are kept
+// apart. Needs are ordered by category and then by ingredient name, which
+// roughly follows the aisles of a supermarket.
+func Needs(portions []Portion, ingredients map[int]*models.Ingredient) []Need {
+	needs := make([]Need, 0)
+	for _, portion := range portions {
+		scale := 1.0
+		if portion.Servings > 0 && portion.Recipe.Servings > 0 {
+			scale = float64(portion.Servings) / float64(portion.Recipe.Servings)
+		}
+
+		for _, line := range portion.Recipe.Ingredients {
+			needs = merge(needs, Need{
+				IngredientID: line.IngredientID,
+				Quantity:     line.Quantity * scale,
+				Unit:         line.Unit,
+			}, conversionOf(ingredients, line.IngredientID))
+		}
+	}
+
+	sortNeeds(needs, ingredients)
+	return needs
+}
+
+// SubtractPantry takes what the pantry already holds out of the given needs,
+// returning only what still has to be bought. Pantry batches that cannot be
+// converted to the unit of a need are ignored for that need.
+func SubtractPantry(needs []Need, pantry []*models.PantryItem, ingredients map[int]*models.Ingredient) []Need {
+	// every batch can only cover one need, so track what is left of each one
+	left := make(map[int]float64, len(pantry))
+	for _, item := range pantry {
+		left[item.ID] = item.Quantity
+	}
+
+	results := make([]Need, 0, len(needs))
+	for _, need := range needs {
+		conversion := conversionOf(ingredients, need.IngredientID)
+		for _, item := range pantry {
+			if need.Quantity <= epsilon {
+				break
+			}
+			if item.IngredientID != need.IngredientID || left[item.ID] <= epsilon {
+				continue
+			}
+
+			available, ok := convert(left[item.ID], item.Unit, need.Unit, conversion)
+			if !ok {
+				continue
+			}
+
+			taken := min(available, need.Quantity)
+			need.Quantity -= taken
+			left[item.ID] -= left[item.ID] * taken / available
+		}
+
+		if need.Quantity > epsilon {
+			results = append(results, need)
+		}
+	}
+
+	return results
+}
+
+// merge adds a need to the first existing need of the same ingredient whose
+// unit it can be converted to, or appends it otherwise.
+func merge(needs []Need, need Need, conversion models.UnitConversion) []Need {
+	for i := range needs {
+		if needs[i].IngredientID != need.IngredientID {
+			continue
+		}
+		if quantity, ok := convert(need.Quantity, need.Unit, needs[i].Unit, conversion); ok {
+			needs[i].Quantity += quantity
+			return needs
+		}
+	}
+	return append(needs, need)
+}
+
+// convert is like units.Convert, but also accepts identical units that the
+// units package does not know about, such as "manojo".
+func convert(quantity float64, from, to string, conversion models.UnitConversion) (float64, bool) {
+	if from == to {
+		return quantity, true
+	}
+	converted, err := units.Convert(quantity, from, to, conversion)
+	if err != nil {
+		return 0, false
+	}
+	return converted, true
+}
+
+func conversionOf(ingredients map[int]*models.Ingredient, id int) models.UnitConversion {
+	if ingredient, ok := ingredients[id]; ok {
+		return ingredient.UnitConversion
+	}
+	return models.UnitConversion{}
+}
+
+func sortNeeds(needs []Need, ingredients map[int]*models.Ingredient) {
+	categoryOrder := make(map[models.Category]int, len(models.Categories))
+	for i, category := range models.Categories {
+		categoryOrder[category] = i
+	}
+
+	// unknown ingredients sort last, after uncategorized ones
+	rank := func(need Need) (int, string) {
+		ingredient, ok := ingredients[need.IngredientID]
+		if !ok {
+			return len(models.Categories) + 1, ""
+		}
+		if order, ok := categoryOrder[ingredient.Category]; ok {
+			return order, ingredient.Name
+		}
+		return len(models.Categories), ingredient.Name
+	}
+
+	sort.SliceStable(needs, func(i, j int) bool {
+		rankI, nameI := rank(needs[i])
+		rankJ, nameJ := rank(needs[j])
+		if rankI != rankJ {
+			return rankI < rankJ
+		}
+		if nameI != nameJ {
+			return nameI < nameJ
+		}
+		if needs[i].IngredientID != needs[j].IngredientID {
+			return needs[i].IngredientID < needs[j].IngredientID
+		}
+		return needs[i].Unit < needs[j].Unit
+	})
+}
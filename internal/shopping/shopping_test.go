@@ -0,0 +1,150 @@
+package shopping
+
+import (
+	"math"
+	"testing"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+var (
+	testRice  = &models.Ingredient{ID: 1, Name: "arroz", Category: models.CategoryGrains}
+	testOil   = &models.Ingredient{ID: 2, Name: "aceite de oliva", Category: models.CategoryOils}
+	testEggs  = &models.Ingredient{ID: 3, Name: "huevos", Category: models.CategoryProteins}
+	testHerbs = &models.Ingredient{ID: 4, Name: "perejil"}
+
+	testIngredients = map[int]*models.Ingredient{1: testRice, 2: testOil, 3: testEggs, 4: testHerbs}
+)
+
+func TestNeeds(t *testing.T) {
+	paella := &models.Recipe{Servings: 4, Ingredients: []models.RecipeIngredient{
+		{IngredientID: 1, Quantity: 400, Unit: "g"},
+		{IngredientID: 2, Quantity: 2, Unit: "tbsp"},
+		{IngredientID: 4, Quantity: 1, Unit: "manojo"},
+	}}
+	tortilla := &models.Recipe{Servings: 2, Ingredients: []models.RecipeIngredient{
+		{IngredientID: 3, Quantity: 4, Unit: ""},
+		{IngredientID: 2, Quantity: 100, Unit: "ml"},
+		{IngredientID: 1, Quantity: 1, Unit: "cup"},
+	}}
+
+	t.Run("merges lines and scales servings", func(t *testing.T) {
+		needs := Needs([]Portion{
+			{Recipe: paella, Servings: 2},
+			{Recipe: tortilla, Servings: 4},
+		}, testIngredients)
+
+		if len(needs) != 5 {
+			t.Fatalf("expected 5 needs, got %d: %+v", len(needs), needs)
+		}
+
+		// ordered by category: oils, proteins, grains, then uncategorized
+		expected := []Need{
+			{IngredientID: 2, Quantity: 1 + 200.0/15, Unit: "tbsp"},
+			{IngredientID: 3, Quantity: 8, Unit: ""},
+			{IngredientID: 1, Quantity: 2, Unit: "cup"},
+			{IngredientID: 1, Quantity: 200, Unit: "g"},
+			{IngredientID: 4, Quantity: 0.5, Unit: "manojo"},
+		}
+
+		for i, need := range needs {
+			if need.IngredientID != expected[i].IngredientID || need.Unit != expected[i].Unit || !almostEqual(need.Quantity, expected[i].Quantity) {
+				t.Errorf("expected %+v at position %d, got %+v", expected[i], i, need)
+			}
+		}
+	})
+
+	t.Run("zero servings keeps the recipe servings", func(t *testing.T) {
+		needs := Needs([]Portion{{Recipe: paella}}, testIngredients)
+
+		for _, need := range needs {
+			if need.IngredientID == 1 && need.Quantity != 400 {
+				t.Errorf("expected 400 g of rice, got %v", need.Quantity)
+			}
+		}
+	})
+
+	t.Run("unknown ingredients are kept last", func(t *testing.T) {
+		recipe := &models.Recipe{Servings: 1, Ingredients: []models.RecipeIngredient{
+			{IngredientID: 42, Quantity: 1, Unit: "g"},
+			{IngredientID: 4, Quantity: 1, Unit: "manojo"},
+		}}
+
+		needs := Needs([]Portion{{Recipe: recipe}}, testIngredients)
+
+		if len(needs) != 2 || needs[1].IngredientID != 42 {
+			t.Errorf("expected the unknown ingredient last, got %+v", needs)
+		}
+	})
+
+	t.Run("no portions", func(t *testing.T) {
+		needs := Needs(nil, testIngredients)
+		if needs == nil || len(needs) != 0 {
+			t.Errorf("expected empty slice, got %v", needs)
+		}
+	})
+}
+
+func TestSubtractPantry(t *testing.T) {
+	t.Run("subtracts pantry batches across units", func(t *testing.T) {
+		needs := []Need{
+			{IngredientID: 1, Quantity: 1500, Unit: "g"},
+			{IngredientID: 3, Quantity: 6, Unit: ""},
+		}
+		pantry := []*models.PantryItem{
+			{ID: 1, IngredientID: 1, Quantity: 1, Unit: "kg"},
+			{ID: 2, IngredientID: 3, Quantity: 12, Unit: ""},
+		}
+
+		results := SubtractPantry(needs, pantry, testIngredients)
+
+		if len(results) != 1 {
+			t.Fatalf("expected 1 need left, got %d: %+v", len(results), results)
+		}
+
+		if results[0].IngredientID != 1 || !almostEqual(results[0].Quantity, 500) || results[0].Unit != "g" {
+			t.Errorf("expected 500 g of rice, got %+v", results[0])
+		}
+
+		if pantry[0].Quantity != 1 {
+			t.Errorf("pantry items should not be modified, got %v", pantry[0].Quantity)
+		}
+	})
+
+	t.Run("leftovers in incompatible units are not subtracted", func(t *testing.T) {
+		needs := []Need{
+			{IngredientID: 1, Quantity: 600, Unit: "g"},
+			{IngredientID: 1, Quantity: 2, Unit: "cup"},
+		}
+		pantry := []*models.PantryItem{
+			{ID: 1, IngredientID: 1, Quantity: 1, Unit: "kg"},
+		}
+
+		results := SubtractPantry(needs, pantry, testIngredients)
+
+		// the remaining 400 g of rice cannot be expressed in cups without a density
+		if len(results) != 1 || results[0].Unit != "cup" || results[0].Quantity != 2 {
+			t.Errorf("expected 2 cups of rice left, got %+v", results)
+		}
+	})
+
+	t.Run("batches are shared between needs in compatible units", func(t *testing.T) {
+		needs := []Need{
+			{IngredientID: 2, Quantity: 500, Unit: "ml"},
+			{IngredientID: 2, Quantity: 10, Unit: "tbsp"},
+		}
+		pantry := []*models.PantryItem{
+			{ID: 1, IngredientID: 2, Quantity: 0.6, Unit: "l"},
+		}
+
+		results := SubtractPantry(needs, pantry, testIngredients)
+
+		if len(results) != 1 || !almostEqual(results[0].Quantity, 10-100.0/15) {
+			t.Errorf("expected %v tbsp of oil left, got %+v", 10-100.0/15, results)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+const IngredientMaxAliases = 20
+
+var (
+	aliasLocaleRegex = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+	ErrIngredientAliasExists        = errors.New("ingredient alias is already used by an ingredient")
+	ErrIngredientAliasNotFound      = errors.New("ingredient alias not found")
+	ErrIngredientAliasLocaleInvalid = errors.New("alias locale must be a language tag like 'en' or 'es-MX'")
+	ErrIngredientTooManyAliases     = fmt.Errorf("ingredient cannot have more than %d aliases", IngredientMaxAliases)
+)
+
+// normalizeAliasLocale lowercases the language and uppercases the region of a
+// locale tag, accepting both "-" and "_" as separators.
+func normalizeAliasLocale(locale string) (string, error) {
+	trimmed := strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if trimmed == "" {
+		return "", nil
+	}
+
+	language, region, hasRegion := strings.Cut(trimmed, "-")
+	normalized := strings.ToLower(language)
+	if hasRegion {
+		normalized += "-" + strings.ToUpper(region)
+	}
+
+	if !aliasLocaleRegex.MatchString(normalized) {
+		return "", ErrIngredientAliasLocaleInvalid
+	}
+
+	return normalized, nil
+}
+
+// aliasNames returns the names of the given aliases.
+func aliasNames(aliases []models.Alias) []string {
+	names := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		names = append(names, alias.Name)
+	}
+	return names
+}
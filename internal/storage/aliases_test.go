@@ -0,0 +1,33 @@
+package storage
+
+import "testing"
+
+func TestNormalizeAliasLocale(t *testing.T) {
+	testCases := []struct {
+		input       string
+		expected    string
+		expectedErr error
+	}{
+		{"", "", nil},
+		{"en", "en", nil},
+		{" EN ", "en", nil},
+		{"es-mx", "es-MX", nil},
+		{"pt_BR", "pt-BR", nil},
+		{"ast", "ast", nil},
+		{"english", "", ErrIngredientAliasLocaleInvalid},
+		{"e", "", ErrIngredientAliasLocaleInvalid},
+		{"es-MEX", "", ErrIngredientAliasLocaleInvalid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			locale, err := normalizeAliasLocale(tc.input)
+			if err != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if locale != tc.expected {
+				t.Errorf("expected locale %q, got %q", tc.expected, locale)
+			}
+		})
+	}
+}
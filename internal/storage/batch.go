@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// IngredientRename renames the ingredient with the name or alias Name to NewName.
+type IngredientRename struct {
+	Name    string
+	NewName string
+}
+
+// BatchError is returned by the batch methods of IngredientStorage when any
+// item of the batch fails. Nothing in the batch is applied.
+type BatchError struct {
+	// Items holds the error of every failed item, ordered by index.
+	Items []BatchItemError
+}
+
+// BatchItemError is the error of the item at Index in a batch.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	failures := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		failures = append(failures, fmt.Sprintf("item %d: %v", item.Index, item.Err))
+	}
+	return fmt.Sprintf("batch not applied, %d items failed: %s", len(e.Items), strings.Join(failures, "; "))
+}
+
+// Unwrap returns the errors of the failed items.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Items))
+	for _, item := range e.Items {
+		errs = append(errs, item.Err)
+	}
+	return errs
+}
+
+// runBatch calls apply with the index of every item of a batch of n items and
+// collects the errors of the items that fail into a BatchError. Items are
+// applied in order, each one seeing the changes of the items before it. An
+// error that is not about the item itself, such as a failing database or ctx
+// being done before the next item, stops the batch right away and is returned
+// as is.
+func runBatch(ctx context.Context, n int, apply func(i int) error) error {
+	var batchErr BatchError
+	for i := range n {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := apply(i)
+		if err == nil {
+			continue
+		}
+		if !isBatchItemError(err) {
+			return err
+		}
+		batchErr.Items = append(batchErr.Items, BatchItemError{Index: i, Err: err})
+	}
+
+	if len(batchErr.Items) > 0 {
+		return &batchErr
+	}
+	return nil
+}
+
+// isBatchItemError reports whether err is a problem with a single item of a
+// batch rather than with the storage.
+func isBatchItemError(err error) bool {
+	switch err {
+	case ErrIngredientNameCannotBeEmpty,
+		ErrIngredientNameContainsInvalidChars,
+		ErrIngredientNameExists,
+		ErrIngredientNameIsTooLong,
+		ErrIngredientNameIsTooShort,
+		ErrIngredientNotFound:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package storage_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/victorcete/recipe-manager/internal/storage"
+	"github.com/victorcete/recipe-manager/internal/storage/storagetest"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.IngredientStorage {
+		return storage.NewMemoryStorage()
+	})
+}
+
+func TestJournaledMemoryStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.IngredientStorage {
+		s, err := storage.NewJournaledMemoryStorage(t.TempDir())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+
+		return s
+	})
+}
+
+func TestFileStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.IngredientStorage {
+		s, err := storage.NewFileStorage(filepath.Join(t.TempDir(), "ingredients.json"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return s
+	})
+}
+
+func TestSQLStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.IngredientStorage {
+		s, err := storage.OpenSQLiteStorage(t.Context(), filepath.Join(t.TempDir(), "ingredients.db"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+
+		return s
+	})
+}
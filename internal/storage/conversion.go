@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+const (
+	PieceNameMaxLength   = 16
+	PieceWeightMaxGrams  = 100000
+	DensityMaxGramsPerMl = 25
+)
+
+var (
+	pieceNameRegex = regexp.MustCompile(`^[a-zA-Z\sÀ-ÿ]+$`)
+
+	ErrPieceWeightIsNegative         = errors.New("piece weight cannot be negative")
+	ErrPieceWeightIsTooHigh          = fmt.Errorf("piece weight cannot exceed %dg", PieceWeightMaxGrams)
+	ErrPieceNameRequiresWeight       = errors.New("piece name requires a piece weight")
+	ErrPieceNameIsTooLong            = fmt.Errorf("piece name cannot exceed %d characters long", PieceNameMaxLength)
+	ErrPieceNameContainsInvalidChars = errors.New("piece name can only contain letters and spaces")
+	ErrDensityIsNegative             = errors.New("density cannot be negative")
+	ErrDensityIsTooHigh              = fmt.Errorf("density cannot exceed %dg/ml", DensityMaxGramsPerMl)
+)
+
+// ValidateUnitConversion checks the unit conversion fields of an ingredient and
+// returns them with the piece name normalized.
+func ValidateUnitConversion(conversion models.UnitConversion) (models.UnitConversion, error) {
+	if conversion.PieceWeightGrams < 0 {
+		return conversion, ErrPieceWeightIsNegative
+	}
+
+	if conversion.PieceWeightGrams > PieceWeightMaxGrams {
+		return conversion, ErrPieceWeightIsTooHigh
+	}
+
+	if conversion.DensityGPerMl < 0 {
+		return conversion, ErrDensityIsNegative
+	}
+
+	if conversion.DensityGPerMl > DensityMaxGramsPerMl {
+		return conversion, ErrDensityIsTooHigh
+	}
+
+	conversion.PieceName = strings.ToLower(strings.Join(strings.Fields(conversion.PieceName), " "))
+	if conversion.PieceName != "" {
+		if conversion.PieceWeightGrams == 0 {
+			return conversion, ErrPieceNameRequiresWeight
+		}
+		if len(conversion.PieceName) > PieceNameMaxLength {
+			return conversion, ErrPieceNameIsTooLong
+		}
+		if !pieceNameRegex.MatchString(conversion.PieceName) {
+			return conversion, ErrPieceNameContainsInvalidChars
+		}
+	}
+
+	return conversion, nil
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+func TestValidateUnitConversion(t *testing.T) {
+	t.Run("normalizes piece name", func(t *testing.T) {
+		conversion, err := ValidateUnitConversion(models.UnitConversion{PieceWeightGrams: 5, PieceName: "  Diente "})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if conversion.PieceName != "diente" {
+			t.Errorf("expected piece name %q, got %q", "diente", conversion.PieceName)
+		}
+	})
+
+	testCases := []struct {
+		name        string
+		conversion  models.UnitConversion
+		expectedErr error
+	}{
+		{"all zero", models.UnitConversion{}, nil},
+		{"density only", models.UnitConversion{DensityGPerMl: 0.92}, nil},
+		{"negative piece weight", models.UnitConversion{PieceWeightGrams: -1}, ErrPieceWeightIsNegative},
+		{"piece weight too high", models.UnitConversion{PieceWeightGrams: PieceWeightMaxGrams + 1}, ErrPieceWeightIsTooHigh},
+		{"negative density", models.UnitConversion{DensityGPerMl: -0.5}, ErrDensityIsNegative},
+		{"density too high", models.UnitConversion{DensityGPerMl: DensityMaxGramsPerMl + 1}, ErrDensityIsTooHigh},
+		{"piece name without weight", models.UnitConversion{PieceName: "diente"}, ErrPieceNameRequiresWeight},
+		{"piece name too long", models.UnitConversion{PieceWeightGrams: 5, PieceName: "supercalifragilistic"}, ErrPieceNameIsTooLong},
+		{"piece name with invalid chars", models.UnitConversion{PieceWeightGrams: 5, PieceName: "diente!"}, ErrPieceNameContainsInvalidChars},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ValidateUnitConversion(tc.conversion)
+			if err != tc.expectedErr {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// IngredientDetails holds the optional fields of an ingredient that
+// CreateWithDetails and UpdateWithDetails set in the same change as the create
+// or rename. Nil fields are left untouched.
+type IngredientDetails struct {
+	Category       *models.Category
+	Nutrition      *models.NutritionFacts
+	UnitConversion *models.UnitConversion
+}
+
+// normalize checks every given field and returns them normalized, so invalid
+// details are refused before anything is written.
+func (d IngredientDetails) normalize() (IngredientDetails, error) {
+	var normalized IngredientDetails
+
+	if d.Category != nil {
+		category, ok := models.ParseCategory(string(*d.Category))
+		if !ok {
+			return normalized, ErrIngredientCategoryInvalid
+		}
+		normalized.Category = &category
+	}
+
+	if d.Nutrition != nil {
+		if err := ValidateNutritionFacts(*d.Nutrition); err != nil {
+			return normalized, err
+		}
+		facts := *d.Nutrition
+		normalized.Nutrition = &facts
+	}
+
+	if d.UnitConversion != nil {
+		conversion, err := ValidateUnitConversion(*d.UnitConversion)
+		if err != nil {
+			return normalized, err
+		}
+		normalized.UnitConversion = &conversion
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+var (
+	ErrDietaryAllergenInvalid = errors.New("allergen is not a known allergen")
+	ErrDietaryDietInvalid     = errors.New("diet is not a known diet")
+	ErrDietaryDietConflict    = errors.New("diet conflicts with the allergens of the ingredient")
+)
+
+// animalAllergens lists the allergens that rule out a diet.
+var animalAllergens = map[models.Diet][]models.Allergen{
+	models.DietVegan:      {models.AllergenDairy, models.AllergenEgg, models.AllergenFish, models.AllergenShellfish},
+	models.DietVegetarian: {models.AllergenFish, models.AllergenShellfish},
+}
+
+// ValidateDietaryInfo checks the allergens and diets of an ingredient and returns
+// them deduplicated and in display order. Vegan ingredients are always vegetarian too.
+func ValidateDietaryInfo(info models.DietaryInfo) (models.DietaryInfo, error) {
+	allergens := make(map[models.Allergen]bool, len(info.Allergens))
+	for _, allergen := range info.Allergens {
+		parsed, ok := models.ParseAllergen(string(allergen))
+		if !ok {
+			return info, ErrDietaryAllergenInvalid
+		}
+		allergens[parsed] = true
+	}
+
+	diets := make(map[models.Diet]bool, len(info.Diets))
+	for _, diet := range info.Diets {
+		parsed, ok := models.ParseDiet(string(diet))
+		if !ok {
+			return info, ErrDietaryDietInvalid
+		}
+		diets[parsed] = true
+	}
+	if diets[models.DietVegan] {
+		diets[models.DietVegetarian] = true
+	}
+
+	for diet, conflicting := range animalAllergens {
+		if !diets[diet] {
+			continue
+		}
+		for _, allergen := range conflicting {
+			if allergens[allergen] {
+				return info, ErrDietaryDietConflict
+			}
+		}
+	}
+
+	normalized := models.DietaryInfo{
+		Allergens: make([]models.Allergen, 0, len(allergens)),
+		Diets:     make([]models.Diet, 0, len(diets)),
+	}
+	for _, allergen := range models.Allergens {
+		if allergens[allergen] {
+			normalized.Allergens = append(normalized.Allergens, allergen)
+		}
+	}
+	for _, diet := range models.Diets {
+		if diets[diet] {
+			normalized.Diets = append(normalized.Diets, diet)
+		}
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+func TestValidateDietaryInfo(t *testing.T) {
+	t.Run("normalizes values", func(t *testing.T) {
+		info, err := ValidateDietaryInfo(models.DietaryInfo{
+			Allergens: []models.Allergen{"Soy", "gluten", "soy"},
+			Diets:     []models.Diet{"halal", " VEGAN "},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectedAllergens := []models.Allergen{models.AllergenGluten, models.AllergenSoy}
+		if !reflect.DeepEqual(info.Allergens, expectedAllergens) {
+			t.Errorf("expected allergens %v, got %v", expectedAllergens, info.Allergens)
+		}
+
+		expectedDiets := []models.Diet{models.DietVegan, models.DietVegetarian, models.DietHalal}
+		if !reflect.DeepEqual(info.Diets, expectedDiets) {
+			t.Errorf("expected diets %v, got %v", expectedDiets, info.Diets)
+		}
+	})
+
+	t.Run("empty lists stay non-nil", func(t *testing.T) {
+		info, err := ValidateDietaryInfo(models.DietaryInfo{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if info.Allergens == nil || info.Diets == nil {
+			t.Errorf("expected reviewed ingredient to keep non-nil lists, got %+v", info)
+		}
+	})
+
+	testCases := []struct {
+		name        string
+		info        models.DietaryInfo
+		expectedErr error
+	}{
+		{"unknown allergen", models.DietaryInfo{Allergens: []models.Allergen{"kryptonite"}}, ErrDietaryAllergenInvalid},
+		{"unknown diet", models.DietaryInfo{Diets: []models.Diet{"carnivore"}}, ErrDietaryDietInvalid},
+		{"vegan with dairy", models.DietaryInfo{Allergens: []models.Allergen{"dairy"}, Diets: []models.Diet{"vegan"}}, ErrDietaryDietConflict},
+		{"vegetarian with fish", models.DietaryInfo{Allergens: []models.Allergen{"fish"}, Diets: []models.Diet{"vegetarian"}}, ErrDietaryDietConflict},
+		{"vegetarian with egg", models.DietaryInfo{Allergens: []models.Allergen{"egg"}, Diets: []models.Diet{"vegetarian"}}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ValidateDietaryInfo(tc.info)
+			if err != tc.expectedErr {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
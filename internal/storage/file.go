@@ -0,0 +1,556 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// ingredientFileVersion is the version of the snapshot format with the
+// revisions inline, written by the journal and by FileStorage before it kept
+// the revisions in a history file.
+const ingredientFileVersion = 1
+
+// ingredientFileHistoryVersion is the version of the file format written by
+// FileStorage, whose revisions are in the history file.
+const ingredientFileHistoryVersion = 2
+
+var ErrIngredientFileInvalid = errors.New("ingredient file is not a valid ingredient storage file")
+
+// ingredientFile is the JSON document FileStorage persists.
+type ingredientFile struct {
+	Version int `json:"version"`
+	ingredientSnapshot
+	// RevisionCount is how many revisions of the history file were saved along
+	// with the ingredients. Later ones belong to a save that did not complete.
+	RevisionCount int `json:"revision_count,omitempty"`
+}
+
+// FileStorage provides ingredient storage persisted to a JSON file. Ingredients
+// are kept in memory and the whole file is rewritten after every change, first
+// to a temporary file that then replaces the original, so a crash never leaves
+// a half-written file behind. The revisions go to a history file next to it,
+// named after it with ".history" appended, holding a revision per line, to
+// which every change only appends its own. A change that cannot be written is
+// rolled back.
+type FileStorage struct {
+	// mu serializes changes, so each one is written before the next starts.
+	mu          sync.Mutex
+	path        string
+	historyPath string
+	memory      *MemoryStorage
+	// savedRevisions is how many revisions the ingredient file counts, and
+	// historySize the length of the history file holding them.
+	savedRevisions int
+	historySize    int64
+}
+
+// NewFileStorage creates a file-backed storage instance, loading the ingredients
+// already saved at path. A missing file is created on the first change. The
+// options are those of NewMemoryStorage.
+func NewFileStorage(path string, options ...MemoryOption) (*FileStorage, error) {
+	s := &FileStorage{
+		path:        path,
+		historyPath: path + ".history",
+		memory:      NewMemoryStorage(options...),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.load(data); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// AddAlias adds an alternative name to an ingredient and saves the change.
+func (s *FileStorage) AddAlias(ctx context.Context, name, alias, locale string, expectedVersion int) (*models.Ingredient, error) {
+	return s.update(ctx, func() (*models.Ingredient, error) {
+		return s.memory.AddAlias(ctx, name, alias, locale, expectedVersion)
+	})
+}
+
+// Create adds a new ingredient and saves the change.
+func (s *FileStorage) Create(ctx context.Context, name string) (*models.Ingredient, error) {
+	return s.update(ctx, func() (*models.Ingredient, error) {
+		return s.memory.Create(ctx, name)
+	})
+}
+
+// CreateMany adds a new ingredient for every name and saves them all at once,
+// or adds none if any name fails.
+func (s *FileStorage) CreateMany(ctx context.Context, names []string) ([]*models.Ingredient, error) {
+	var results []*models.Ingredient
+	_, err := s.update(ctx, func() (*models.Ingredient, error) {
+		var err error
+		results, err = s.memory.CreateMany(ctx, names)
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CreateWithDetails adds a new ingredient with the given details and saves it,
+// or adds nothing if any detail fails.
+func (s *FileStorage) CreateWithDetails(ctx context.Context, name string, details IngredientDetails) (*models.Ingredient, error) {
+	return s.update(ctx, func() (*models.Ingredient, error) {
+		return s.memory.CreateWithDetails(ctx, name, details)
+	})
+}
+
+// Delete moves an ingredient to the trash and saves the change.
+func (s *FileStorage) Delete(ctx context.Context, name string, expectedVersion int) error {
+	_, err := s.update(ctx, func() (*models.Ingredient, error) {
+		return nil, s.memory.Delete(ctx, name, expectedVersion)
+	})
+	return err
+}
+
+// DeleteMany moves the ingredients with the given names to the trash and saves
+// the change, or moves none if any name fails.
+func (s *FileStorage) DeleteMany(ctx context.Context, names []string) error {
+	_, err := s.update(ctx, func() (*models.Ingredient, error) {
+		return nil, s.memory.DeleteMany(ctx, names)
+	})
+	return err
+}
+
+// Get returns the ingredient with the given ID.
+func (s *FileStorage) Get(ctx context.Context, id int) (*models.Ingredient, error) {
+	return s.memory.Get(ctx, id)
+}
+
+// GetByName returns the ingredient whose name or alias matches the given one.
+func (s *FileStorage) GetByName(ctx context.Context, name string) (*models.Ingredient, error) {
+	return s.memory.GetByName(ctx, name)
+}
+
+// History returns every revision of an ingredient, oldest first.
+func (s *FileStorage) History(ctx context.Context, id int) ([]*models.Revision, error) {
+	return s.memory.History(ctx, id)
+}
+
+// List returns every ingredient, ordered by ID.
+func (s *FileStorage) List(ctx context.Context) ([]*models.Ingredient, error) {
+	return s.memory.List(ctx)
+}
+
+// ListPage returns the page of ingredients selected by options.
+func (s *FileStorage) ListPage(ctx context.Context, options ListOptions) (*IngredientPage, error) {
+	return s.memory.ListPage(ctx, options)
+}
+
+// ListDeleted returns every ingredient in the trash, ordered by ID.
+func (s *FileStorage) ListDeleted(ctx context.Context) ([]*models.Ingredient, error) {
+	return s.memory.ListDeleted(ctx)
+}
+
+// Purge permanently removes the ingredients moved to the trash before the given
+// time and saves the change.
+func (s *FileStorage) Purge(ctx context.Context, deletedBefore time.Time) ([]*models.Ingredient, error) {
+	var results []*models.Ingredient
+	_, err := s.update(ctx, func() (*models.Ingredient, error) {
+		var err error
+		results, err = s.memory.Purge(ctx, deletedBefore)
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// RemoveAlias removes an alternative name from an ingredient and saves the change.
+func (s *FileStorage) RemoveAlias(ctx context.Context, name, alias string, expectedVersion int) (*models.Ingredient, error) {
+	return s.update(ctx, func() (*models.Ingredient, error) {
+		return s.memory.RemoveAlias(ctx, name, alias, expectedVersion)
+	})
+}
+
+// Restore takes an ingredient out of the trash and saves the change.
+func (s *FileStorage) Restore(ctx context.Context, id int) (*models.Ingredient, error) {
+	return s.update(ctx, func() (*models.Ingredient, error) {
+		return s.memory.Restore(ctx, id)
+	})
+}
+
+// SeedTestData adds the sample ingredients and saves them all at once.
+func (s *FileStorage) SeedTestData(ctx context.Context) ([]*models.Ingredient, error) {
+	var results []*models.Ingredient
+	_, err := s.update(ctx, func() (*models.Ingredient, error) {
+		var err error
+		results, err = s.memory.SeedTestData(ctx)
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// SetCategory sets the category of an ingredient and saves the change.
+func (s *FileStorage) SetCategory(ctx context.Context, name string, category models.Category, expectedVersion int) (*models.Ingredient, error) {
+	return s.update(ctx, func() (*models.Ingredient, error) {
+		return s.memory.SetCategory(ctx, name, category, expectedVersion)
+	})
+}
+
+// SetDietaryInfo sets the dietary information of an ingredient and saves the change.
+func (s *FileStorage) SetDietaryInfo(ctx context.Context, name string, info models.DietaryInfo, expectedVersion int) (*models.Ingredient, error) {
+	return s.update(ctx, func() (*models.Ingredient, error) {
+		return s.memory.SetDietaryInfo(ctx, name, info, expectedVersion)
+	})
+}
+
+// SetNutrition sets the nutrition facts of an ingredient and saves the change.
+func (s *FileStorage) SetNutrition(ctx context.Context, name string, facts models.NutritionFacts, expectedVersion int) (*models.Ingredient, error) {
+	return s.update(ctx, func() (*models.Ingredient, error) {
+		return s.memory.SetNutrition(ctx, name, facts, expectedVersion)
+	})
+}
+
+// SetUnitConversion sets the unit conversion fields of an ingredient and saves the change.
+func (s *FileStorage) SetUnitConversion(ctx context.Context, name string, conversion models.UnitConversion, expectedVersion int) (*models.Ingredient, error) {
+	return s.update(ctx, func() (*models.Ingredient, error) {
+		return s.memory.SetUnitConversion(ctx, name, conversion, expectedVersion)
+	})
+}
+
+// UndoLastChange reverts the latest change made by the session of ctx and
+// saves the change.
+func (s *FileStorage) UndoLastChange(ctx context.Context) (*models.Revision, error) {
+	var revision *models.Revision
+	_, err := s.update(ctx, func() (*models.Ingredient, error) {
+		var err error
+		revision, err = s.memory.UndoLastChange(ctx)
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// Update renames an ingredient and saves the change.
+func (s *FileStorage) Update(ctx context.Context, name, newName string, expectedVersion int) (*models.Ingredient, error) {
+	return s.update(ctx, func() (*models.Ingredient, error) {
+		return s.memory.Update(ctx, name, newName, expectedVersion)
+	})
+}
+
+// UpdateMany applies every rename and saves them all at once, or applies none
+// if any rename fails.
+func (s *FileStorage) UpdateMany(ctx context.Context, renames []IngredientRename) ([]*models.Ingredient, error) {
+	var results []*models.Ingredient
+	_, err := s.update(ctx, func() (*models.Ingredient, error) {
+		var err error
+		results, err = s.memory.UpdateMany(ctx, renames)
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// UpdateWithDetails renames an ingredient and sets its details, saving every
+// change at once, or makes none of them if any fails.
+func (s *FileStorage) UpdateWithDetails(ctx context.Context, name, newName string, details IngredientDetails, expectedVersion int) (*models.Ingredient, error) {
+	return s.update(ctx, func() (*models.Ingredient, error) {
+		return s.memory.UpdateWithDetails(ctx, name, newName, details, expectedVersion)
+	})
+}
+
+// Watch returns a channel receiving an event for every change saved from now
+// on, see MemoryStorage.Watch.
+func (s *FileStorage) Watch(ctx context.Context) <-chan models.IngredientEvent {
+	return s.memory.Watch(ctx)
+}
+
+// update applies a change to the in-memory ingredients and writes them to the
+// file, restoring the previous ingredients if the file cannot be written. A
+// change is not started once ctx is cancelled, but a started write completes.
+func (s *FileStorage) update(ctx context.Context, change func() (*models.Ingredient, error)) (*models.Ingredient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	previous := s.memory.snapshot()
+
+	// watchers only learn about the change once it is saved
+	s.memory.feed.hold()
+
+	ingredient, err := change()
+	if err != nil {
+		s.memory.feed.discard()
+		return nil, err
+	}
+
+	if err := s.save(); err != nil {
+		s.memory.feed.discard()
+		s.memory.restore(previous)
+		return nil, fmt.Errorf("failed to save ingredients: %w", err)
+	}
+
+	s.memory.feed.release()
+	return ingredient, nil
+}
+
+// save appends the revisions made since the last save to the history file and
+// then rewrites the ingredient file, which only counts them once it is written.
+func (s *FileStorage) save() error {
+	snapshot := s.memory.snapshot()
+
+	historySize, err := s.appendHistory(snapshot.Revisions[s.savedRevisions:])
+	if err != nil {
+		return err
+	}
+
+	savedRevisions := len(snapshot.Revisions)
+	snapshot.Revisions = nil
+	data, err := json.MarshalIndent(ingredientFile{
+		Version:            ingredientFileHistoryVersion,
+		ingredientSnapshot: snapshot,
+		RevisionCount:      savedRevisions,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(s.path, append(data, '\n')); err != nil {
+		return err
+	}
+
+	s.savedRevisions, s.historySize = savedRevisions, historySize
+	return nil
+}
+
+// appendHistory writes revisions after the saved ones in the history file,
+// dropping whatever a save that did not complete left there, and returns the
+// new length of the file.
+func (s *FileStorage) appendHistory(revisions []*models.Revision) (int64, error) {
+	if len(revisions) == 0 {
+		return s.historySize, nil
+	}
+
+	var data []byte
+	for _, revision := range revisions {
+		line, err := json.Marshal(revision)
+		if err != nil {
+			return 0, err
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	history, err := os.OpenFile(s.historyPath, os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := history.Truncate(s.historySize); err != nil {
+		history.Close()
+		return 0, err
+	}
+	if _, err := history.WriteAt(data, s.historySize); err != nil {
+		history.Close()
+		return 0, err
+	}
+	if err := history.Sync(); err != nil {
+		history.Close()
+		return 0, err
+	}
+	if err := history.Close(); err != nil {
+		return 0, err
+	}
+
+	return s.historySize + int64(len(data)), nil
+}
+
+// load restores the ingredients of an ingredient file along with their
+// revisions. Files with the revisions inline move them to the history file on
+// the next save.
+func (s *FileStorage) load(data []byte) error {
+	var file ingredientFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("%w: %v", ErrIngredientFileInvalid, err)
+	}
+
+	switch file.Version {
+	case ingredientFileVersion:
+	case ingredientFileHistoryVersion:
+		revisions, historySize, err := readHistoryFile(s.historyPath, file.RevisionCount)
+		if err != nil {
+			return err
+		}
+		file.Revisions = revisions
+		s.savedRevisions, s.historySize = file.RevisionCount, historySize
+	default:
+		return fmt.Errorf("%w: unsupported version %d", ErrIngredientFileInvalid, file.Version)
+	}
+
+	if err := validateIngredientSnapshot(&file.ingredientSnapshot); err != nil {
+		return err
+	}
+	s.memory.restore(file.ingredientSnapshot)
+	return nil
+}
+
+// readHistoryFile reads the first count revisions of a history file, and
+// returns them along with the length of the file they take.
+func readHistoryFile(path string, count int) ([]*models.Revision, int64, error) {
+	history, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) && count == 0 {
+		return nil, 0, nil
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, fmt.Errorf("%w: history file %s is missing", ErrIngredientFileInvalid, path)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer history.Close()
+
+	reader := bufio.NewReader(history)
+	revisions := make([]*models.Revision, 0, count)
+	var size int64
+	for len(revisions) < count {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return nil, 0, fmt.Errorf("%w: history file holds %d of %d revisions", ErrIngredientFileInvalid, len(revisions), count)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var revision models.Revision
+		if err := json.Unmarshal(line, &revision); err != nil {
+			return nil, 0, fmt.Errorf("%w: revision %d: %v", ErrIngredientFileInvalid, len(revisions)+1, err)
+		}
+		revisions = append(revisions, &revision)
+		size += int64(len(line))
+	}
+
+	return revisions, size, nil
+}
+
+// decodeIngredientFile parses and validates the contents of an ingredient file
+// with the revisions inline.
+func decodeIngredientFile(data []byte) (ingredientSnapshot, error) {
+	var file ingredientFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return ingredientSnapshot{}, fmt.Errorf("%w: %v", ErrIngredientFileInvalid, err)
+	}
+
+	if file.Version != ingredientFileVersion {
+		return ingredientSnapshot{}, fmt.Errorf("%w: unsupported version %d", ErrIngredientFileInvalid, file.Version)
+	}
+
+	if err := validateIngredientSnapshot(&file.ingredientSnapshot); err != nil {
+		return ingredientSnapshot{}, err
+	}
+	return file.ingredientSnapshot, nil
+}
+
+// validateIngredientSnapshot checks the ingredients and revisions read from a
+// file, and makes sure numbering can resume.
+func validateIngredientSnapshot(snapshot *ingredientSnapshot) error {
+	ids := make(map[int]bool, len(snapshot.Ingredients))
+	names := make(map[string]bool, len(snapshot.Ingredients))
+	for _, ingredient := range snapshot.Ingredients {
+		if ingredient == nil || ingredient.ID <= 0 || ids[ingredient.ID] {
+			return fmt.Errorf("%w: missing or duplicated ingredient ID", ErrIngredientFileInvalid)
+		}
+		ids[ingredient.ID] = true
+
+		allNames := []string{ingredient.Name}
+		for _, alias := range ingredient.Aliases {
+			allNames = append(allNames, alias.Name)
+		}
+		for _, name := range allNames {
+			normalizedName, err := validateIngredientName(name)
+			if err != nil {
+				return fmt.Errorf("%w: ingredient %q: %v", ErrIngredientFileInvalid, name, err)
+			}
+			// names in the trash are free to be taken again
+			if ingredient.IsDeleted() {
+				continue
+			}
+			if names[normalizedName] {
+				return fmt.Errorf("%w: ingredient %q is duplicated", ErrIngredientFileInvalid, name)
+			}
+			names[normalizedName] = true
+		}
+	}
+
+	for i, revision := range snapshot.Revisions {
+		if revision == nil || revision.ID != i+1 || revision.After == nil {
+			return fmt.Errorf("%w: revision %d is missing or out of order", ErrIngredientFileInvalid, i+1)
+		}
+	}
+
+	// IDs already taken are skipped when numbering resumes, so next_id only
+	// needs to be valid, not past every ID in the file
+	snapshot.NextID = max(snapshot.NextID, 1)
+
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and then renames
+// it over path, so readers only ever see the old or the new contents.
+func writeFileAtomic(path string, data []byte) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(0o644); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// persist the rename itself; not every platform supports syncing directories
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
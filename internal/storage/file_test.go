@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+var _ IngredientStorage = (*FileStorage)(nil)
+
+func TestNewFileStorage(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("missing file starts empty", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ingredients.json")
+		storage, err := NewFileStorage(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ingredients, _ := storage.List(ctx)
+		if len(ingredients) != 0 {
+			t.Errorf("expected no ingredients, got %d", len(ingredients))
+		}
+
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("file should not be created until the first change, got %v", err)
+		}
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			contents string
+		}{
+			{"not json", "sal, pimienta"},
+			{"unsupported version", `{"version": 99, "next_id": 1, "ingredients": []}`},
+			{"duplicated ID", `{"version": 1, "next_id": 3, "ingredients": [{"id": 1, "name": "sal"}, {"id": 1, "name": "azúcar"}]}`},
+			{"invalid ID", `{"version": 1, "next_id": 3, "ingredients": [{"id": 0, "name": "sal"}]}`},
+			{"invalid name", `{"version": 1, "next_id": 3, "ingredients": [{"id": 1, "name": "s@l"}]}`},
+			{"duplicated name", `{"version": 1, "next_id": 3, "ingredients": [{"id": 1, "name": "sal"}, {"id": 2, "name": "pimienta", "aliases": [{"name": "SAL"}]}]}`},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "ingredients.json")
+				if err := os.WriteFile(path, []byte(tc.contents), 0o644); err != nil {
+					t.Fatal(err)
+				}
+
+				_, err := NewFileStorage(path)
+				if !errors.Is(err, ErrIngredientFileInvalid) {
+					t.Errorf("expected %v, got %v", ErrIngredientFileInvalid, err)
+				}
+			})
+		}
+	})
+
+	t.Run("next ID never reuses a stored ID", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ingredients.json")
+		contents := `{"version": 1, "next_id": 7, "ingredients": [{"id": 7, "name": "sal"}]}`
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		storage, err := NewFileStorage(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ingredient, err := storage.Create(ctx, "pimienta")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.ID != 8 {
+			t.Errorf("expected ID 8, got %d", ingredient.ID)
+		}
+	})
+}
+
+func TestFileStoragePersistence(t *testing.T) {
+	ctx := t.Context()
+
+	path := filepath.Join(t.TempDir(), "ingredients.json")
+	storage, err := NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	storage.Create(ctx, "sal")
+	storage.Create(ctx, "leche")
+	storage.Create(ctx, "pimienta")
+	storage.AddAlias(ctx, "leche", "milk", "en", AnyVersion)
+	storage.SetCategory(ctx, "leche", models.CategoryDairy, AnyVersion)
+	storage.SetNutrition(ctx, "leche", models.NutritionFacts{CaloriesPer100g: 64}, AnyVersion)
+	storage.SetUnitConversion(ctx, "leche", models.UnitConversion{DensityGPerMl: 1.03}, AnyVersion)
+	storage.SetDietaryInfo(ctx, "leche", models.DietaryInfo{Allergens: []models.Allergen{models.AllergenDairy}}, AnyVersion)
+	storage.Update(ctx, "sal", "sal marina", AnyVersion)
+	storage.Delete(ctx, "pimienta", AnyVersion)
+
+	reopened, err := NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("unexpected error reopening the file: %v", err)
+	}
+
+	ingredients, _ := reopened.List(ctx)
+	if len(ingredients) != 2 {
+		t.Fatalf("expected 2 ingredients, got %d", len(ingredients))
+	}
+
+	milk, err := reopened.GetByName(ctx, "milk")
+	if err != nil {
+		t.Fatalf("expected to find the ingredient by its alias, got %v", err)
+	}
+
+	if milk.Category != models.CategoryDairy || milk.Nutrition == nil || milk.DensityGPerMl != 1.03 || milk.Dietary == nil || milk.Version != 6 {
+		t.Errorf("expected every attribute to be persisted, got %+v", milk)
+	}
+
+	if _, err := reopened.GetByName(ctx, "sal marina"); err != nil {
+		t.Errorf("expected renamed ingredient to be persisted, got %v", err)
+	}
+
+	history, _ := reopened.History(ctx, milk.ID)
+	if len(history) != 6 {
+		t.Errorf("expected 6 revisions, got %d", len(history))
+	}
+
+	created, _ := reopened.Create(ctx, "pimienta")
+	if created.ID != 4 {
+		t.Errorf("expected IDs to continue from 4, got %d", created.ID)
+	}
+
+	// the name of an ingredient in the trash can be taken by a new one
+	if _, err := NewFileStorage(path); err != nil {
+		t.Errorf("unexpected error reopening the file: %v", err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 2 {
+		t.Errorf("expected only the ingredient and history files in the directory, got %d entries", len(entries))
+	}
+}
+
+func TestFileStorageHistory(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("changes only append their revisions", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ingredients.json")
+		storage, _ := NewFileStorage(path)
+		storage.Create(ctx, "sal")
+		before, _ := os.ReadFile(path + ".history")
+
+		storage.SetCategory(ctx, "sal", models.CategorySpices, AnyVersion)
+		after, _ := os.ReadFile(path + ".history")
+		if !bytes.HasPrefix(after, before) || bytes.Count(after, []byte("\n")) != 2 {
+			t.Errorf("expected the history file to grow by one revision, got %q", after)
+		}
+
+		data, _ := os.ReadFile(path)
+		if bytes.Contains(data, []byte(`"revisions"`)) {
+			t.Errorf("expected no revisions in the ingredient file, got %s", data)
+		}
+	})
+
+	t.Run("revisions of an unsaved change are ignored", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ingredients.json")
+		storage, _ := NewFileStorage(path)
+		created, _ := storage.Create(ctx, "sal")
+
+		// a revision appended by a save that never wrote the ingredient file
+		history, _ := os.OpenFile(path+".history", os.O_WRONLY|os.O_APPEND, 0o644)
+		history.WriteString(`{"id": 2, "ingredient_id": 1}` + "\n")
+		history.Close()
+
+		reopened, err := NewFileStorage(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		reopened.Update(ctx, "sal", "sal marina", AnyVersion)
+
+		reopened, err = NewFileStorage(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		revisions, _ := reopened.History(ctx, created.ID)
+		if len(revisions) != 2 || revisions[1].After.Name != "sal marina" {
+			t.Errorf("expected the create and the rename, got %+v", revisions)
+		}
+	})
+
+	t.Run("a missing history file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ingredients.json")
+		storage, _ := NewFileStorage(path)
+		storage.Create(ctx, "sal")
+		os.Remove(path + ".history")
+
+		if _, err := NewFileStorage(path); !errors.Is(err, ErrIngredientFileInvalid) {
+			t.Errorf("expected %v, got %v", ErrIngredientFileInvalid, err)
+		}
+	})
+
+	t.Run("revisions inline are moved to the history file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ingredients.json")
+		memory := NewMemoryStorage()
+		created, _ := memory.Create(ctx, "sal")
+		data, _ := json.Marshal(ingredientFile{Version: ingredientFileVersion, ingredientSnapshot: memory.snapshot()})
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		storage, err := NewFileStorage(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		storage.SetCategory(ctx, "sal", models.CategorySpices, AnyVersion)
+
+		reopened, err := NewFileStorage(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		revisions, _ := reopened.History(ctx, created.ID)
+		if len(revisions) != 2 {
+			t.Errorf("expected 2 revisions, got %d", len(revisions))
+		}
+	})
+}
+
+func TestFileStorageErrors(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("validation errors are passed through", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ingredients.json")
+		storage, _ := NewFileStorage(path)
+		storage.Create(ctx, "sal")
+
+		if _, err := storage.Create(ctx, "SAL"); err != ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", ErrIngredientNameExists, err)
+		}
+
+		if err := storage.Delete(ctx, "pimienta", AnyVersion); err != ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", ErrIngredientNotFound, err)
+		}
+	})
+
+	t.Run("changes that cannot be saved are rolled back", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "missing", "ingredients.json")
+		storage, err := NewFileStorage(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		events := storage.Watch(ctx)
+
+		if _, err := storage.Create(ctx, "sal"); err == nil {
+			t.Fatal("expected an error saving to a missing directory")
+		}
+
+		select {
+		case event := <-events:
+			t.Errorf("expected the failed change not to be announced, got %+v", event)
+		default:
+		}
+
+		ingredients, _ := storage.List(ctx)
+		if len(ingredients) != 0 {
+			t.Errorf("expected the failed change to be rolled back, got %d ingredients", len(ingredients))
+		}
+	})
+}
+
+func TestFileStorageSeedTestData(t *testing.T) {
+	ctx := t.Context()
+
+	path := filepath.Join(t.TempDir(), "ingredients.json")
+	storage, _ := NewFileStorage(path)
+
+	seeded, err := storage.SeedTestData(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reopened, err := NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("unexpected error reopening the file: %v", err)
+	}
+
+	ingredients, _ := reopened.List(ctx)
+	if len(ingredients) != len(seeded) {
+		t.Errorf("expected %d ingredients, got %d", len(seeded), len(ingredients))
+	}
+}
@@ -1,11 +1,76 @@
 package storage
 
-import "github.com/victorcete/recipe-manager/internal/models"
+import (
+	"context"
+	"time"
 
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// IngredientStorage stores ingredients. Every method takes the context of the
+// request it serves, so cancellations and deadlines reach the storage. The
+// Many methods apply a whole batch or, returning a *BatchError, none of it.
+// Deleted ingredients go to a trash, where they are left out of every other
+// method and their names are free, until they are restored or purged. Every
+// change is recorded as a revision in the history of its ingredient and bumps
+// its version; the methods changing a single ingredient by name fail with
+// ErrIngredientVersionConflict unless it is still at the version the caller
+// expects, or AnyVersion.
+// Watch announces every change, once it is kept, to the callers watching.
+// List returns every ingredient ordered by ID, and ListPage a sorted page.
 type IngredientStorage interface {
-	Create(name string) (*models.Ingredient, error)
-	Delete(name string) error
-	List() ([]*models.Ingredient, error)
-	SeedTestData() ([]*models.Ingredient, error)
-	Update(name, newName string) (*models.Ingredient, error)
+	AddAlias(ctx context.Context, name, alias, locale string, expectedVersion int) (*models.Ingredient, error)
+	Create(ctx context.Context, name string) (*models.Ingredient, error)
+	CreateMany(ctx context.Context, names []string) ([]*models.Ingredient, error)
+	CreateWithDetails(ctx context.Context, name string, details IngredientDetails) (*models.Ingredient, error)
+	Delete(ctx context.Context, name string, expectedVersion int) error
+	DeleteMany(ctx context.Context, names []string) error
+	Get(ctx context.Context, id int) (*models.Ingredient, error)
+	GetByName(ctx context.Context, name string) (*models.Ingredient, error)
+	History(ctx context.Context, id int) ([]*models.Revision, error)
+	List(ctx context.Context) ([]*models.Ingredient, error)
+	ListPage(ctx context.Context, options ListOptions) (*IngredientPage, error)
+	ListDeleted(ctx context.Context) ([]*models.Ingredient, error)
+	Purge(ctx context.Context, deletedBefore time.Time) ([]*models.Ingredient, error)
+	RemoveAlias(ctx context.Context, name, alias string, expectedVersion int) (*models.Ingredient, error)
+	Restore(ctx context.Context, id int) (*models.Ingredient, error)
+	SeedTestData(ctx context.Context) ([]*models.Ingredient, error)
+	SetCategory(ctx context.Context, name string, category models.Category, expectedVersion int) (*models.Ingredient, error)
+	SetDietaryInfo(ctx context.Context, name string, info models.DietaryInfo, expectedVersion int) (*models.Ingredient, error)
+	SetNutrition(ctx context.Context, name string, facts models.NutritionFacts, expectedVersion int) (*models.Ingredient, error)
+	SetUnitConversion(ctx context.Context, name string, conversion models.UnitConversion, expectedVersion int) (*models.Ingredient, error)
+	UndoLastChange(ctx context.Context) (*models.Revision, error)
+	Update(ctx context.Context, name, newName string, expectedVersion int) (*models.Ingredient, error)
+	UpdateMany(ctx context.Context, renames []IngredientRename) ([]*models.Ingredient, error)
+	UpdateWithDetails(ctx context.Context, name, newName string, details IngredientDetails, expectedVersion int) (*models.Ingredient, error)
+	Watch(ctx context.Context) <-chan models.IngredientEvent
+}
+
+type RecipeStorage interface {
+	Create(title string, servings int, steps []string, ingredients []models.RecipeIngredient) (*models.Recipe, error)
+	Delete(title string) error
+	Get(title string) (*models.Recipe, error)
+	List() ([]*models.Recipe, error)
+	Update(title string, changes RecipeUpdate) (*models.Recipe, error)
+}
+
+type PantryStorage interface {
+	Adjust(id int, quantity float64) (*models.PantryItem, error)
+	Consume(ingredientID int, quantity float64, unit string, conversion models.UnitConversion) ([]*models.PantryItem, error)
+	List() ([]*models.PantryItem, error)
+	Stock(ingredientID int, quantity float64, unit string, purchasedOn time.Time, bestBefore *time.Time) (*models.PantryItem, error)
+}
+
+type ShoppingListStorage interface {
+	Create(items []models.ShoppingListItem) (*models.ShoppingList, error)
+	Get(id int) (*models.ShoppingList, error)
+	List() ([]*models.ShoppingList, error)
+	SetChecked(id, ingredientID int, checked bool) (*models.ShoppingList, error)
+}
+
+type MealPlanStorage interface {
+	Assign(date time.Time, slot models.MealSlot, recipeID, servings int) (*models.PlannedMeal, error)
+	Between(from, to time.Time) ([]*models.PlannedMeal, error)
+	Clear(date time.Time, slot models.MealSlot) error
+	Move(fromDate time.Time, fromSlot models.MealSlot, toDate time.Time, toSlot models.MealSlot) (*models.PlannedMeal, error)
 }
@@ -0,0 +1,334 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+const (
+	// JournalCompactionThreshold is the number of journal records after which
+	// the journal is compacted into a snapshot.
+	JournalCompactionThreshold = 1000
+
+	journalLogFile      = "journal.log"
+	journalSnapshotFile = "snapshot.json"
+
+	journalOpPut    = "put"
+	journalOpDelete = "delete"
+	journalOpBatch  = "batch"
+)
+
+var ErrJournalCorrupt = errors.New("ingredient journal is corrupt")
+
+// journalRecord is a single change in the journal. A put record holds the whole
+// ingredient as it is after the change, so replaying a record twice is harmless.
+// A batch record holds the put and delete records of a batch, which are
+// replayed all together or not at all.
+type journalRecord struct {
+	Op         string             `json:"op"`
+	Ingredient *models.Ingredient `json:"ingredient,omitempty"`
+	ID         int                `json:"id,omitempty"`
+	Batch      []journalRecord    `json:"batch,omitempty"`
+	// Revision is the revision a put record was made by, if any.
+	Revision *models.Revision `json:"revision,omitempty"`
+}
+
+// journal is an append-only log of the changes made to a MemoryStorage since
+// its last snapshot. Each record is a line holding the CRC-32 checksum of its
+// JSON encoding followed by the JSON encoding itself.
+type journal struct {
+	dir  string
+	log  journalLog
+	size int64
+	// records counts the records appended since the last snapshot.
+	records      int
+	compactAfter int
+}
+
+// journalLog is the file a journal is appended to, which tests replace to make
+// writing it fail.
+type journalLog interface {
+	io.ReadWriteCloser
+	Sync() error
+	Truncate(size int64) error
+}
+
+// NewJournaledMemoryStorage creates an in-memory storage instance whose changes
+// are appended to a journal in dir before they are applied, so they survive
+// the process being killed. The journal is compacted into a snapshot every
+// JournalCompactionThreshold records. On startup the snapshot is loaded and
+// the journal replayed on top of it; a record left half-written by a crash is
+// discarded. The options are those of NewMemoryStorage.
+func NewJournaledMemoryStorage(dir string, options ...MemoryOption) (*MemoryStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := NewMemoryStorage(options...)
+
+	data, err := os.ReadFile(filepath.Join(dir, journalSnapshotFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		snapshot, err := decodeIngredientFile(data)
+		if err != nil {
+			return nil, err
+		}
+		s.restore(snapshot)
+	}
+
+	log, err := os.OpenFile(filepath.Join(dir, journalLogFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &journal{dir: dir, log: log, compactAfter: JournalCompactionThreshold}
+	if err := j.replay(s); err != nil {
+		log.Close()
+		return nil, err
+	}
+	s.journal = j
+
+	return s, nil
+}
+
+// JournalExists reports whether dir already holds a journal or a snapshot, so
+// callers can tell a new journaled storage from one emptied on purpose.
+func JournalExists(dir string) (bool, error) {
+	for _, name := range []string{journalSnapshotFile, journalLogFile} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// Close closes the journal, if any. The storage must not be changed afterwards.
+func (s *MemoryStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal.log.Close()
+}
+
+// Compact writes a snapshot of the storage and empties the journal. It does
+// nothing for storages without a journal.
+func (s *MemoryStorage) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal.compact(s.snapshotLocked())
+}
+
+// record appends a change to the journal, if any, before it is applied.
+// Changes made by a batch are collected instead, and recorded together once
+// the batch succeeds.
+func (s *MemoryStorage) record(record journalRecord) error {
+	if s.batch != nil {
+		previous := s.ingredients[record.ID]
+		if record.Op == journalOpPut {
+			previous = s.ingredients[record.Ingredient.ID]
+		}
+		s.batch.records = append(s.batch.records, record)
+		s.batch.previous = append(s.batch.previous, previous)
+		return nil
+	}
+
+	if err := s.compactJournal(); err != nil {
+		return err
+	}
+	return s.appendJournal(record)
+}
+
+// compactJournal compacts a journal grown past its threshold. It must be
+// called before a change or batch is applied, while the storage still matches
+// the journal, so the snapshot never holds a change whose record may yet fail
+// to be written. The change is refused if compacting fails, so a journal that
+// cannot be compacted never grows unnoticed.
+func (s *MemoryStorage) compactJournal() error {
+	if s.journal == nil || s.journal.records < s.journal.compactAfter {
+		return nil
+	}
+
+	// every change so far is durable in the journal, so compacting is simply
+	// tried again on the next change
+	if err := s.journal.compact(s.snapshotLocked()); err != nil {
+		return fmt.Errorf("failed to compact the ingredient journal: %w", err)
+	}
+	return nil
+}
+
+// appendJournal appends a record to the journal, if any, without compacting it.
+func (s *MemoryStorage) appendJournal(record journalRecord) error {
+	if s.journal == nil {
+		return nil
+	}
+
+	if err := s.journal.append(record); err != nil {
+		return fmt.Errorf("failed to write the ingredient journal: %w", err)
+	}
+	return nil
+}
+
+// append writes a record to the end of the log and waits until it is on disk.
+func (j *journal) append(record journalRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line := fmt.Appendf(nil, "%08x %s\n", crc32.ChecksumIEEE(data), data)
+
+	if _, err := j.log.Write(line); err != nil {
+		// drop whatever part of the record was written, so the next
+		// record does not follow a torn one
+		j.log.Truncate(j.size)
+		return err
+	}
+	if err := j.log.Sync(); err != nil {
+		j.log.Truncate(j.size)
+		return err
+	}
+
+	j.size += int64(len(line))
+	j.records++
+	return nil
+}
+
+// compact writes the snapshot and then empties the log. A crash in between
+// only means the log is replayed on top of a snapshot that already has it.
+func (j *journal) compact(snapshot ingredientSnapshot) error {
+	data, err := json.MarshalIndent(ingredientFile{
+		Version:            ingredientFileVersion,
+		ingredientSnapshot: snapshot,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(filepath.Join(j.dir, journalSnapshotFile), append(data, '\n')); err != nil {
+		return err
+	}
+
+	if err := j.log.Truncate(0); err != nil {
+		return err
+	}
+	if err := j.log.Sync(); err != nil {
+		return err
+	}
+
+	j.size = 0
+	j.records = 0
+	return nil
+}
+
+// replay applies every record of the log to the storage. An invalid last
+// record is one a crash left half-written, and is cut off the log; an invalid
+// record anywhere else means the log is corrupt.
+func (j *journal) replay(s *MemoryStorage) error {
+	data, err := io.ReadAll(j.log)
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	for len(data) > 0 {
+		line, rest, complete := bytes.Cut(data, []byte{'\n'})
+		record, err := decodeJournalRecord(line)
+		if err != nil || !complete {
+			if len(rest) > 0 {
+				return fmt.Errorf("%w: invalid record at byte %d: %v", ErrJournalCorrupt, offset, err)
+			}
+			if err := j.log.Truncate(offset); err != nil {
+				return err
+			}
+			break
+		}
+
+		s.applyJournalRecord(record)
+
+		offset += int64(len(line)) + 1
+		data = rest
+		j.records++
+	}
+
+	j.size = offset
+	return nil
+}
+
+// applyJournalRecord applies a single journal record to the storage.
+func (s *MemoryStorage) applyJournalRecord(record journalRecord) {
+	switch record.Op {
+	case journalOpPut:
+		// numbering in order skips the IDs replayed here when it resumes
+		s.store(record.Ingredient)
+		// the revision may already be in the snapshot the log is replayed on
+		if record.Revision != nil && record.Revision.ID > len(s.revisions) {
+			s.appendRevision(record.Revision)
+		}
+	case journalOpDelete:
+		s.drop(record.ID)
+	case journalOpBatch:
+		for _, change := range record.Batch {
+			s.applyJournalRecord(change)
+		}
+	}
+}
+
+func decodeJournalRecord(line []byte) (journalRecord, error) {
+	var record journalRecord
+
+	checksum, data, ok := bytes.Cut(line, []byte{' '})
+	if !ok || fmt.Sprintf("%08x", crc32.ChecksumIEEE(data)) != string(checksum) {
+		return record, errors.New("checksum mismatch")
+	}
+
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, err
+	}
+
+	if !isValidJournalRecord(record, true) {
+		return record, fmt.Errorf("invalid %q record", record.Op)
+	}
+	return record, nil
+}
+
+// isValidJournalRecord reports whether a record holds everything its
+// operation needs. Batches cannot be nested.
+func isValidJournalRecord(record journalRecord, allowBatch bool) bool {
+	switch record.Op {
+	case journalOpPut:
+		return record.Ingredient != nil && record.Ingredient.ID > 0
+	case journalOpDelete:
+		return record.ID > 0
+	case journalOpBatch:
+		if !allowBatch || len(record.Batch) == 0 {
+			return false
+		}
+		for _, change := range record.Batch {
+			if !isValidJournalRecord(change, false) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
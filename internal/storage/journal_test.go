@@ -0,0 +1,300 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+func newTestJournaledMemoryStorage(t *testing.T, dir string) *MemoryStorage {
+	t.Helper()
+
+	storage, err := NewJournaledMemoryStorage(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+
+	return storage
+}
+
+func TestJournalReplay(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("changes survive a restart without closing", func(t *testing.T) {
+		dir := t.TempDir()
+		storage := newTestJournaledMemoryStorage(t, dir)
+		storage.Create(ctx, "sal")
+		storage.Create(ctx, "leche")
+		storage.Create(ctx, "pimienta")
+		storage.AddAlias(ctx, "leche", "milk", "en", AnyVersion)
+		storage.SetCategory(ctx, "leche", models.CategoryDairy, AnyVersion)
+		storage.Update(ctx, "sal", "sal marina", AnyVersion)
+		storage.Delete(ctx, "pimienta", AnyVersion)
+
+		reopened := newTestJournaledMemoryStorage(t, dir)
+
+		ingredients, _ := reopened.List(ctx)
+		if len(ingredients) != 2 {
+			t.Fatalf("expected 2 ingredients, got %d", len(ingredients))
+		}
+
+		milk, err := reopened.GetByName(ctx, "milk")
+		if err != nil {
+			t.Fatalf("expected to find the ingredient by its alias, got %v", err)
+		}
+		if milk.Category != models.CategoryDairy {
+			t.Errorf("expected category %q, got %q", models.CategoryDairy, milk.Category)
+		}
+
+		created, _ := reopened.Create(ctx, "pimienta")
+		if created.ID != 4 {
+			t.Errorf("expected IDs to continue from 4, got %d", created.ID)
+		}
+	})
+
+	t.Run("batches are replayed whole", func(t *testing.T) {
+		dir := t.TempDir()
+		storage := newTestJournaledMemoryStorage(t, dir)
+		storage.CreateMany(ctx, []string{"sal", "leche", "pimienta"})
+		storage.UpdateMany(ctx, []IngredientRename{{Name: "sal", NewName: "sal marina"}})
+
+		// a failed batch leaves nothing in the journal
+		storage.DeleteMany(ctx, []string{"leche", "brotato"})
+		if storage.journal.records != 2 {
+			t.Errorf("expected 2 records, got %d", storage.journal.records)
+		}
+
+		reopened := newTestJournaledMemoryStorage(t, dir)
+		ingredients, _ := reopened.List(ctx)
+		if len(ingredients) != 3 {
+			t.Fatalf("expected 3 ingredients, got %d", len(ingredients))
+		}
+
+		if _, err := reopened.GetByName(ctx, "sal marina"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("revisions are replayed", func(t *testing.T) {
+		dir := t.TempDir()
+		storage := newTestJournaledMemoryStorage(t, dir)
+		sessionCtx := WithSession(ctx, "session-1")
+		created, _ := storage.Create(sessionCtx, "sal")
+		storage.Update(sessionCtx, "sal", "sal marina", AnyVersion)
+		storage.Compact()
+		storage.SetCategory(sessionCtx, "sal marina", models.CategorySpices, AnyVersion)
+
+		reopened := newTestJournaledMemoryStorage(t, dir)
+		history, _ := reopened.History(ctx, created.ID)
+		if len(history) != 3 {
+			t.Fatalf("expected 3 revisions, got %d", len(history))
+		}
+
+		if _, err := reopened.UndoLastChange(sessionCtx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ingredient, _ := reopened.Get(ctx, created.ID)
+		if ingredient.Category != "" {
+			t.Errorf("expected the category to be undone, got %q", ingredient.Category)
+		}
+	})
+
+	t.Run("torn last record is discarded", func(t *testing.T) {
+		dir := t.TempDir()
+		storage := newTestJournaledMemoryStorage(t, dir)
+		storage.Create(ctx, "sal")
+		storage.Close()
+
+		path := filepath.Join(dir, journalLogFile)
+		log, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+		log.WriteString(`1a2b3c4d {"op":"put","ingredient":{"id":2,"na`)
+		log.Close()
+
+		reopened := newTestJournaledMemoryStorage(t, dir)
+		ingredients, _ := reopened.List(ctx)
+		if len(ingredients) != 1 {
+			t.Fatalf("expected 1 ingredient, got %d", len(ingredients))
+		}
+
+		// records appended after the torn one must not be lost on the next start
+		reopened.Create(ctx, "pimienta")
+		reopened.Close()
+
+		again := newTestJournaledMemoryStorage(t, dir)
+		ingredients, _ = again.List(ctx)
+		if len(ingredients) != 2 {
+			t.Errorf("expected 2 ingredients, got %d", len(ingredients))
+		}
+	})
+
+	t.Run("invalid record before the last one", func(t *testing.T) {
+		dir := t.TempDir()
+		storage := newTestJournaledMemoryStorage(t, dir)
+		storage.Create(ctx, "sal")
+		storage.Create(ctx, "pimienta")
+		storage.Close()
+
+		path := filepath.Join(dir, journalLogFile)
+		data, _ := os.ReadFile(path)
+		data[10] ^= 0xff
+		os.WriteFile(path, data, 0o644)
+
+		_, err := NewJournaledMemoryStorage(dir)
+		if !errors.Is(err, ErrJournalCorrupt) {
+			t.Errorf("expected %v, got %v", ErrJournalCorrupt, err)
+		}
+	})
+}
+
+// failingJournalLog is a journal log whose writes fail.
+type failingJournalLog struct {
+	journalLog
+}
+
+func (failingJournalLog) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestJournalBatchFailureAfterCompaction(t *testing.T) {
+	ctx := t.Context()
+
+	dir := t.TempDir()
+	storage := newTestJournaledMemoryStorage(t, dir)
+	storage.journal.compactAfter = 2
+
+	storage.Create(ctx, "sal")
+	storage.Create(ctx, "leche")
+
+	log := storage.journal.log
+	storage.journal.log = failingJournalLog{log}
+
+	if _, err := storage.CreateMany(ctx, []string{"pimienta", "azúcar"}); err == nil {
+		t.Fatal("expected the failed journal write to be reported")
+	}
+	if _, err := os.Stat(filepath.Join(dir, journalSnapshotFile)); err != nil {
+		t.Fatalf("expected the journal to be compacted first, got %v", err)
+	}
+	storage.journal.log = log
+
+	reopened := newTestJournaledMemoryStorage(t, dir)
+	ingredients, _ := reopened.List(ctx)
+	if len(ingredients) != 2 {
+		t.Fatalf("expected the 2 ingredients from before the batch, got %d", len(ingredients))
+	}
+	for _, name := range []string{"pimienta", "azúcar"} {
+		if _, err := reopened.GetByName(ctx, name); err != ErrIngredientNotFound {
+			t.Errorf("expected %q to be rolled back, got %v", name, err)
+		}
+	}
+}
+
+func TestJournalExists(t *testing.T) {
+	dir := t.TempDir()
+
+	exists, err := JournalExists(filepath.Join(dir, "missing"))
+	if err != nil || exists {
+		t.Errorf("expected no journal in a missing directory, got %v, %v", exists, err)
+	}
+
+	storage := newTestJournaledMemoryStorage(t, dir)
+	exists, err = JournalExists(dir)
+	if err != nil || !exists {
+		t.Errorf("expected a journal once opened, got %v, %v", exists, err)
+	}
+
+	// a journal emptied of ingredients still exists
+	storage.Create(t.Context(), "sal")
+	storage.Delete(t.Context(), "sal", AnyVersion)
+	storage.Purge(t.Context(), time.Time{})
+	storage.Compact()
+	if exists, _ := JournalExists(dir); !exists {
+		t.Errorf("expected an emptied journal to still exist")
+	}
+}
+
+func TestJournalCompaction(t *testing.T) {
+	ctx := t.Context()
+
+	dir := t.TempDir()
+	storage := newTestJournaledMemoryStorage(t, dir)
+	storage.journal.compactAfter = 3
+
+	storage.Create(ctx, "sal")
+	storage.Create(ctx, "leche")
+	storage.Delete(ctx, "leche", AnyVersion)
+	storage.Create(ctx, "pimienta")
+
+	if _, err := os.Stat(filepath.Join(dir, journalSnapshotFile)); err != nil {
+		t.Fatalf("expected a snapshot to be written, got %v", err)
+	}
+
+	if storage.journal.records != 1 {
+		t.Errorf("expected 1 record after the snapshot, got %d", storage.journal.records)
+	}
+
+	reopened := newTestJournaledMemoryStorage(t, dir)
+	ingredients, _ := reopened.List(ctx)
+	if len(ingredients) != 2 {
+		t.Fatalf("expected 2 ingredients, got %d", len(ingredients))
+	}
+
+	created, _ := reopened.Create(ctx, "leche")
+	if created.ID != 4 {
+		t.Errorf("expected IDs to continue from 4, got %d", created.ID)
+	}
+
+	if err := reopened.Compact(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info, _ := os.Stat(filepath.Join(dir, journalLogFile))
+	if info.Size() != 0 {
+		t.Errorf("expected an empty journal after compacting, got %d bytes", info.Size())
+	}
+}
+
+func TestJournalCompactionFailure(t *testing.T) {
+	ctx := t.Context()
+
+	dir := t.TempDir()
+	storage := newTestJournaledMemoryStorage(t, dir)
+	storage.journal.compactAfter = 2
+
+	storage.Create(ctx, "sal")
+	storage.Create(ctx, "leche")
+
+	// a directory in the way of the snapshot makes writing it fail
+	blocker := filepath.Join(dir, journalSnapshotFile, "blocker")
+	if err := os.MkdirAll(blocker, 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := storage.Create(ctx, "pimienta"); err == nil {
+		t.Fatal("expected the failed compaction to be reported")
+	}
+	if _, err := storage.GetByName(ctx, "pimienta"); err != ErrIngredientNotFound {
+		t.Errorf("expected the refused change to be left out, got %v", err)
+	}
+	if storage.journal.records != 2 {
+		t.Errorf("expected the journal to keep its 2 records, got %d", storage.journal.records)
+	}
+
+	// once the snapshot can be written, the next change compacts the journal
+	os.RemoveAll(filepath.Join(dir, journalSnapshotFile))
+	if _, err := storage.Create(ctx, "pimienta"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if storage.journal.records != 1 {
+		t.Errorf("expected 1 record after the snapshot, got %d", storage.journal.records)
+	}
+
+	reopened := newTestJournaledMemoryStorage(t, dir)
+	if ingredients, _ := reopened.List(ctx); len(ingredients) != 3 {
+		t.Errorf("expected 3 ingredients, got %d", len(ingredients))
+	}
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// IngredientSortField is a field ingredients can be listed by.
+type IngredientSortField string
+
+const (
+	SortByID        IngredientSortField = "id"
+	SortByName      IngredientSortField = "name"
+	SortByCreatedAt IngredientSortField = "created_at"
+	SortByUpdatedAt IngredientSortField = "updated_at"
+)
+
+var (
+	ErrListSortInvalid   = errors.New("ingredients can only be sorted by id, name, created_at or updated_at")
+	ErrListLimitInvalid  = errors.New("list limit cannot be negative")
+	ErrListCursorInvalid = errors.New("list cursor is not valid for this listing")
+)
+
+// ListOptions selects a page of ingredients for ListPage. The zero value lists
+// every ingredient by ascending ID.
+type ListOptions struct {
+	SortBy     IngredientSortField
+	Descending bool
+	// Category, if not nil, only lists ingredients of that category.
+	Category *models.Category
+	// Limit is the maximum number of ingredients on the page, zero for all.
+	Limit int
+	// Cursor is the NextCursor of the previous page, empty for the first one.
+	Cursor string
+}
+
+// IngredientPage is a page of ingredients listed by ListPage.
+type IngredientPage struct {
+	Ingredients []*models.Ingredient
+	// Offset is how many ingredients were listed on the previous pages.
+	Offset int
+	// Total is how many ingredients match, over every page.
+	Total int
+	// NextCursor lists the next page, empty on the last one.
+	NextCursor string
+}
+
+// listCursor is the position a page ends at, encoded as an opaque string.
+// Pages start after the sort key and ID of the last ingredient listed, rather
+// than at an offset, so ingredients added or removed meanwhile never make the
+// next page skip or repeat any other ingredient.
+type listCursor struct {
+	SortBy     IngredientSortField `json:"s"`
+	Descending bool                `json:"d,omitempty"`
+	Category   *models.Category    `json:"c,omitempty"`
+	Key        string              `json:"k,omitempty"`
+	ID         int                 `json:"i"`
+	Offset     int                 `json:"o"`
+}
+
+// normalize fills in the defaults of the options and validates them, decoding
+// the cursor if there is one.
+func (o *ListOptions) normalize() (*listCursor, error) {
+	if o.SortBy == "" {
+		o.SortBy = SortByID
+	}
+	switch o.SortBy {
+	case SortByID, SortByName, SortByCreatedAt, SortByUpdatedAt:
+	default:
+		return nil, ErrListSortInvalid
+	}
+
+	if o.Category != nil {
+		category, ok := models.ParseCategory(string(*o.Category))
+		if !ok {
+			return nil, ErrIngredientCategoryInvalid
+		}
+		o.Category = &category
+	}
+
+	if o.Limit < 0 {
+		return nil, ErrListLimitInvalid
+	}
+
+	if o.Cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(o.Cursor)
+	if err != nil {
+		return nil, ErrListCursorInvalid
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrListCursorInvalid
+	}
+
+	// a cursor only continues the listing it was made for
+	if cursor.SortBy != o.SortBy || cursor.Descending != o.Descending || !sameCategory(cursor.Category, o.Category) {
+		return nil, ErrListCursorInvalid
+	}
+	return &cursor, nil
+}
+
+func sameCategory(a, b *models.Category) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// nextCursor returns the cursor of the page following one that ends with the
+// given ingredient, after offset ingredients in total.
+func (o ListOptions) nextCursor(last *models.Ingredient, offset int) string {
+	data, _ := json.Marshal(listCursor{
+		SortBy:     o.SortBy,
+		Descending: o.Descending,
+		Category:   o.Category,
+		Key:        sortKey(last, o.SortBy),
+		ID:         last.ID,
+		Offset:     offset,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// sortKey returns the value an ingredient is sorted by, besides its ID, which
+// breaks ties. Dates use the fixed-width layout of the database, so keys
+// compare as strings on every backend.
+func sortKey(ingredient *models.Ingredient, field IngredientSortField) string {
+	switch field {
+	case SortByName:
+		return ingredient.Name
+	case SortByCreatedAt:
+		return formatSQLTime(ingredient.CreatedAt)
+	case SortByUpdatedAt:
+		return formatSQLTime(ingredient.UpdatedAt)
+	default:
+		return ""
+	}
+}
+
+// pageOf returns the page of the given ingredients, in any order, selected by
+// options.
+func pageOf(ingredients []*models.Ingredient, options ListOptions, cursor *listCursor) *IngredientPage {
+	matching := make([]*models.Ingredient, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		if options.Category == nil || ingredient.Category == *options.Category {
+			matching = append(matching, ingredient)
+		}
+	}
+
+	// listedBefore reports whether the position (keyA, idA) comes before (keyB, idB)
+	listedBefore := func(keyA string, idA int, keyB string, idB int) bool {
+		if keyA != keyB {
+			return (keyA < keyB) != options.Descending
+		}
+		if idA != idB {
+			return (idA < idB) != options.Descending
+		}
+		return false
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		return listedBefore(sortKey(matching[i], options.SortBy), matching[i].ID, sortKey(matching[j], options.SortBy), matching[j].ID)
+	})
+
+	page := &IngredientPage{Total: len(matching)}
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(matching), func(i int) bool {
+			return listedBefore(cursor.Key, cursor.ID, sortKey(matching[i], options.SortBy), matching[i].ID)
+		})
+		page.Offset = cursor.Offset
+	}
+
+	end := len(matching)
+	if options.Limit > 0 && start+options.Limit < end {
+		end = start + options.Limit
+		page.NextCursor = options.nextCursor(matching[end-1], page.Offset+end-start)
+	}
+	page.Ingredients = matching[start:end]
+
+	return page
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+var (
+	ErrMealPlanSlotInvalid         = errors.New("meal slot must be one of desayuno, comida or cena")
+	ErrMealPlanRecipeInvalidID     = errors.New("planned meal must reference an existing recipe")
+	ErrMealPlanServingsOutOfRange  = fmt.Errorf("planned servings must be between %d and %d", RecipeServingsMin, RecipeServingsMax)
+	ErrMealPlanSlotEmpty           = errors.New("no meal is planned for that day and slot")
+	ErrMealPlanSlotTaken           = errors.New("a meal is already planned for that day and slot")
+	ErrMealPlanDateRangeIsInverted = errors.New("end of the date range cannot be earlier than its start")
+)
+
+// mealKey identifies a meal slot on a given day.
+type mealKey struct {
+	date time.Time
+	slot models.MealSlot
+}
+
+// MealPlanMemoryStorage provides in-memory storage for the meal plan. Each
+// meal slot of a day holds at most one recipe. Stored meals are never handed
+// out or changed in place: callers get copies, and changes replace the stored
+// meal.
+type MealPlanMemoryStorage struct {
+	mu     sync.RWMutex
+	meals  map[mealKey]*models.PlannedMeal
+	nextID int
+}
+
+// NewMealPlanMemoryStorage creates a new in-memory meal plan storage instance.
+func NewMealPlanMemoryStorage() *MealPlanMemoryStorage {
+	return &MealPlanMemoryStorage{
+		meals:  make(map[mealKey]*models.PlannedMeal),
+		nextID: 1,
+	}
+}
+
+// Assign plans a recipe for a meal slot, replacing whatever was planned there.
+func (s *MealPlanMemoryStorage) Assign(date time.Time, slot models.MealSlot, recipeID, servings int) (*models.PlannedMeal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := newMealKey(date, slot)
+	if err != nil {
+		return nil, err
+	}
+
+	if recipeID <= 0 {
+		return nil, ErrMealPlanRecipeInvalidID
+	}
+
+	if err := validateRecipeServings(servings); err != nil {
+		return nil, ErrMealPlanServingsOutOfRange
+	}
+
+	if meal, ok := s.meals[key]; ok {
+		updated := meal.Clone()
+		updated.RecipeID = recipeID
+		updated.Servings = servings
+		updated.UpdatedAt = time.Now()
+		s.meals[key] = updated
+		return updated.Clone(), nil
+	}
+
+	meal := models.NewPlannedMeal(s.nextID, key.date, key.slot, recipeID, servings)
+	s.meals[key] = meal
+	s.nextID++
+
+	return meal.Clone(), nil
+}
+
+// Move reschedules the meal planned for a slot to another, empty, slot.
+func (s *MealPlanMemoryStorage) Move(fromDate time.Time, fromSlot models.MealSlot, toDate time.Time, toSlot models.MealSlot) (*models.PlannedMeal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from, err := newMealKey(fromDate, fromSlot)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := newMealKey(toDate, toSlot)
+	if err != nil {
+		return nil, err
+	}
+
+	meal, ok := s.meals[from]
+	if !ok {
+		return nil, ErrMealPlanSlotEmpty
+	}
+
+	if from == to {
+		return meal.Clone(), nil
+	}
+
+	if _, ok := s.meals[to]; ok {
+		return nil, ErrMealPlanSlotTaken
+	}
+
+	moved := meal.Clone()
+	moved.Date = to.date
+	moved.Slot = to.slot
+	moved.UpdatedAt = time.Now()
+	delete(s.meals, from)
+	s.meals[to] = moved
+
+	return moved.Clone(), nil
+}
+
+// Clear removes the meal planned for a slot.
+func (s *MealPlanMemoryStorage) Clear(date time.Time, slot models.MealSlot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := newMealKey(date, slot)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := s.meals[key]; !ok {
+		return ErrMealPlanSlotEmpty
+	}
+
+	delete(s.meals, key)
+
+	return nil
+}
+
+// Between returns the meals planned from one day to another, both included,
+// ordered by day and slot.
+func (s *MealPlanMemoryStorage) Between(from, to time.Time) ([]*models.PlannedMeal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start, end := truncateToDay(from), truncateToDay(to)
+	if end.Before(start) {
+		return nil, ErrMealPlanDateRangeIsInverted
+	}
+
+	results := make([]*models.PlannedMeal, 0)
+	for key, meal := range s.meals {
+		if !key.date.Before(start) && !key.date.After(end) {
+			results = append(results, meal.Clone())
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].Date.Equal(results[j].Date) {
+			return results[i].Date.Before(results[j].Date)
+		}
+		return results[i].Slot.Order() < results[j].Slot.Order()
+	})
+
+	return results, nil
+}
+
+func newMealKey(date time.Time, slot models.MealSlot) (mealKey, error) {
+	if slot.Order() < 0 {
+		return mealKey{}, ErrMealPlanSlotInvalid
+	}
+	return mealKey{date: truncateToDay(date), slot: slot}, nil
+}
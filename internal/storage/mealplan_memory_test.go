@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+func TestNewMealPlanMemoryStorage(t *testing.T) {
+	storage := NewMealPlanMemoryStorage()
+	if storage == nil {
+		t.Fatal("meal plan memory storage created is nil")
+	}
+
+	if storage.meals == nil {
+		t.Error("meals map is nil")
+	}
+
+	if storage.nextID != 1 {
+		t.Errorf("expected ID to be 1, got %d", storage.nextID)
+	}
+}
+
+func TestAssignMeal(t *testing.T) {
+	t.Run("successful assignment", func(t *testing.T) {
+		storage := NewMealPlanMemoryStorage()
+		meal, err := storage.Assign(time.Date(2025, 3, 3, 13, 45, 0, 0, time.UTC), models.MealSlotLunch, 1, 4)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if meal.ID != 1 || meal.RecipeID != 1 || meal.Servings != 4 {
+			t.Errorf("expected ID 1, recipe 1 and 4 servings, got %d, %d and %d", meal.ID, meal.RecipeID, meal.Servings)
+		}
+
+		if !meal.Date.Equal(testDate(3)) {
+			t.Errorf("expected date truncated to the day, got %v", meal.Date)
+		}
+	})
+
+	t.Run("replaces the meal already planned", func(t *testing.T) {
+		storage := NewMealPlanMemoryStorage()
+		first, _ := storage.Assign(testDate(3), models.MealSlotDinner, 1, 4)
+
+		meal, err := storage.Assign(testDate(3), models.MealSlotDinner, 2, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if meal.ID != first.ID || meal.RecipeID != 2 || meal.Servings != 2 {
+			t.Errorf("expected meal %d to plan recipe 2 for 2, got meal %d with recipe %d for %d", first.ID, meal.ID, meal.RecipeID, meal.Servings)
+		}
+
+		meals, _ := storage.Between(testDate(3), testDate(3))
+		if len(meals) != 1 {
+			t.Errorf("expected 1 planned meal, got %d", len(meals))
+		}
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			slot     models.MealSlot
+			recipeID int
+			servings int
+			expected error
+		}{
+			{"invalid slot", "merienda", 1, 4, ErrMealPlanSlotInvalid},
+			{"invalid recipe", models.MealSlotLunch, 0, 4, ErrMealPlanRecipeInvalidID},
+			{"no servings", models.MealSlotLunch, 1, 0, ErrMealPlanServingsOutOfRange},
+			{"too many servings", models.MealSlotLunch, 1, RecipeServingsMax + 1, ErrMealPlanServingsOutOfRange},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				storage := NewMealPlanMemoryStorage()
+				_, err := storage.Assign(testDate(3), tc.slot, tc.recipeID, tc.servings)
+				if err != tc.expected {
+					t.Errorf("expected %v, got %v", tc.expected, err)
+				}
+			})
+		}
+	})
+}
+
+func TestMoveMeal(t *testing.T) {
+	t.Run("successful move", func(t *testing.T) {
+		storage := NewMealPlanMemoryStorage()
+		storage.Assign(testDate(3), models.MealSlotLunch, 1, 4)
+
+		meal, err := storage.Move(testDate(3), models.MealSlotLunch, testDate(4), models.MealSlotDinner)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !meal.Date.Equal(testDate(4)) || meal.Slot != models.MealSlotDinner {
+			t.Errorf("expected cena on %v, got %s on %v", testDate(4), meal.Slot, meal.Date)
+		}
+
+		if err := storage.Clear(testDate(3), models.MealSlotLunch); err != ErrMealPlanSlotEmpty {
+			t.Errorf("expected the original slot to be empty, got %v", err)
+		}
+	})
+
+	t.Run("move to the same slot", func(t *testing.T) {
+		storage := NewMealPlanMemoryStorage()
+		storage.Assign(testDate(3), models.MealSlotLunch, 1, 4)
+
+		if _, err := storage.Move(testDate(3), models.MealSlotLunch, testDate(3), models.MealSlotLunch); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("target slot taken", func(t *testing.T) {
+		storage := NewMealPlanMemoryStorage()
+		storage.Assign(testDate(3), models.MealSlotLunch, 1, 4)
+		storage.Assign(testDate(4), models.MealSlotLunch, 2, 4)
+
+		_, err := storage.Move(testDate(3), models.MealSlotLunch, testDate(4), models.MealSlotLunch)
+		if err != ErrMealPlanSlotTaken {
+			t.Errorf("expected %v, got %v", ErrMealPlanSlotTaken, err)
+		}
+	})
+
+	t.Run("source slot empty", func(t *testing.T) {
+		storage := NewMealPlanMemoryStorage()
+
+		_, err := storage.Move(testDate(3), models.MealSlotLunch, testDate(4), models.MealSlotLunch)
+		if err != ErrMealPlanSlotEmpty {
+			t.Errorf("expected %v, got %v", ErrMealPlanSlotEmpty, err)
+		}
+	})
+
+	t.Run("invalid slot", func(t *testing.T) {
+		storage := NewMealPlanMemoryStorage()
+		storage.Assign(testDate(3), models.MealSlotLunch, 1, 4)
+
+		_, err := storage.Move(testDate(3), models.MealSlotLunch, testDate(4), "merienda")
+		if err != ErrMealPlanSlotInvalid {
+			t.Errorf("expected %v, got %v", ErrMealPlanSlotInvalid, err)
+		}
+	})
+}
+
+func TestClearMeal(t *testing.T) {
+	storage := NewMealPlanMemoryStorage()
+	storage.Assign(testDate(3), models.MealSlotLunch, 1, 4)
+
+	if err := storage.Clear(testDate(3), models.MealSlotLunch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := storage.Clear(testDate(3), models.MealSlotLunch); err != ErrMealPlanSlotEmpty {
+		t.Errorf("expected %v, got %v", ErrMealPlanSlotEmpty, err)
+	}
+}
+
+func TestMealsBetween(t *testing.T) {
+	t.Run("meals are ordered by day and slot", func(t *testing.T) {
+		storage := NewMealPlanMemoryStorage()
+		storage.Assign(testDate(4), models.MealSlotBreakfast, 1, 2)
+		storage.Assign(testDate(3), models.MealSlotDinner, 2, 2)
+		storage.Assign(testDate(3), models.MealSlotBreakfast, 3, 2)
+		storage.Assign(testDate(10), models.MealSlotLunch, 4, 2)
+
+		meals, err := storage.Between(testDate(3), testDate(9))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := []int{3, 2, 1}
+		if len(meals) != len(expected) {
+			t.Fatalf("expected %d meals, got %d", len(expected), len(meals))
+		}
+		for i, meal := range meals {
+			if meal.RecipeID != expected[i] {
+				t.Errorf("expected recipe %d at position %d, got %d", expected[i], i, meal.RecipeID)
+			}
+		}
+	})
+
+	t.Run("empty range returns empty slice", func(t *testing.T) {
+		storage := NewMealPlanMemoryStorage()
+		meals, err := storage.Between(testDate(3), testDate(9))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if meals == nil || len(meals) != 0 {
+			t.Errorf("expected empty slice, got %v", meals)
+		}
+	})
+
+	t.Run("inverted range", func(t *testing.T) {
+		storage := NewMealPlanMemoryStorage()
+		if _, err := storage.Between(testDate(9), testDate(3)); err != ErrMealPlanDateRangeIsInverted {
+			t.Errorf("expected %v, got %v", ErrMealPlanDateRangeIsInverted, err)
+		}
+	})
+}
+
+func TestMealPlanReturnsCopies(t *testing.T) {
+	storage := NewMealPlanMemoryStorage()
+	assigned, _ := storage.Assign(testDate(3), models.MealSlotLunch, 1, 4)
+	assigned.Servings = 0
+
+	moved, _ := storage.Move(testDate(3), models.MealSlotLunch, testDate(4), models.MealSlotDinner)
+	moved.RecipeID = 0
+
+	meals, _ := storage.Between(testDate(1), testDate(7))
+	meals[0].Slot = models.MealSlotBreakfast
+
+	meals, _ = storage.Between(testDate(1), testDate(7))
+	if len(meals) != 1 {
+		t.Fatalf("expected 1 meal, got %d", len(meals))
+	}
+
+	if meals[0].RecipeID != 1 || meals[0].Servings != 4 || meals[0].Slot != models.MealSlotDinner {
+		t.Errorf("expected the stored meal to be untouched, got %+v", meals[0])
+	}
+}
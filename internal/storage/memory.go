@@ -1,9 +1,11 @@
 package storage
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -25,194 +27,1097 @@ var (
 	ErrIngredientNameIsTooLong            = fmt.Errorf("ingredient name cannot exceed %d characters long", IngredientNameMaxLength)
 	ErrIngredientNameIsTooShort           = fmt.Errorf("ingredient name must be at least %d characters long", IngredientNameMinLength)
 	ErrIngredientNotFound                 = errors.New("ingredient not found")
+	ErrIngredientNotInTrash               = errors.New("ingredient is not in the trash")
+	ErrIngredientCategoryInvalid          = errors.New("ingredient category is not a known category")
 )
 
-// MemoryStorage provides in-memory storage for ingredients.
+// MemoryStorage provides in-memory storage for ingredients. Changes can
+// optionally be made durable with a journal, see NewJournaledMemoryStorage.
+// Methods called with a cancelled context return its error right away.
+// Ingredients and revisions are handed out as copies, so callers may change
+// what they get without touching the storage.
 type MemoryStorage struct {
 	mu          sync.RWMutex
 	ingredients map[int]*models.Ingredient
-	nextID      int
+	// names maps every lowercased name and alias to the ID of its ingredient,
+	// so lookups by name do not scan every ingredient.
+	names  map[string]int
+	nextID int
+	// revisions holds every change ever made, oldest first, so the ID of a
+	// revision is its position plus one. Revisions outlive purged ingredients.
+	revisions             []*models.Revision
+	revisionsByIngredient map[int][]*models.Revision
+	// journal is nil unless the storage was created with a journal.
+	journal *journal
+	// batch collects the changes of the batch being applied, if any.
+	batch *memoryBatch
+	feed  changeFeed
+	clock Clock
+	// ids is nil unless the storage was given an IDGenerator, in which case
+	// nextID is left alone. nextID is never derived from the IDs in use, so
+	// generated IDs do not make it jump.
+	ids IDGenerator
 }
 
-// NewMemoryStorage creates a new in-memory storage instance.
-func NewMemoryStorage() *MemoryStorage {
-	return &MemoryStorage{
+// NewMemoryStorage creates a new in-memory storage instance. Unless options
+// say otherwise, it takes the time from the system and numbers ingredients in
+// order from 1.
+func NewMemoryStorage(options ...MemoryOption) *MemoryStorage {
+	s := &MemoryStorage{
 		ingredients: make(map[int]*models.Ingredient),
+		names:       make(map[string]int),
 		nextID:      1,
+		clock:       systemClock{},
+
+		revisionsByIngredient: make(map[int][]*models.Revision),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Create adds a new ingredient and returns it with an assigned ID.
+func (s *MemoryStorage) Create(ctx context.Context, name string) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createLocked(ctx, name)
+}
+
+// CreateMany adds a new ingredient for every name, or none if any name fails.
+func (s *MemoryStorage) CreateMany(ctx context.Context, names []string) ([]*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]*models.Ingredient, len(names))
+	err := s.applyBatch(func() error {
+		return runBatch(ctx, len(names), func(i int) error {
+			var err error
+			results[i], err = s.createLocked(ctx, names[i])
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// CreateWithDetails adds a new ingredient with the given details set, all in
+// one batch, so the ingredient is either created with every detail or not at
+// all.
+func (s *MemoryStorage) CreateWithDetails(ctx context.Context, name string, details IngredientDetails) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	normalizedDetails, err := details.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result *models.Ingredient
+	err = s.applyBatch(func() error {
+		created, err := s.createLocked(ctx, name)
+		if err != nil {
+			return err
+		}
+		result, err = s.setDetailsLocked(ctx, created.ID, normalizedDetails)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Delete moves an ingredient to the trash, freeing its name and aliases.
+func (s *MemoryStorage) Delete(ctx context.Context, name string, expectedVersion int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.deleteLocked(ctx, name, expectedVersion)
+}
+
+// DeleteMany moves the ingredients with the given names to the trash, or none
+// if any name fails.
+func (s *MemoryStorage) DeleteMany(ctx context.Context, names []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.applyBatch(func() error {
+		return runBatch(ctx, len(names), func(i int) error {
+			return s.deleteLocked(ctx, names[i], AnyVersion)
+		})
+	})
+}
+
+// Get returns the ingredient with the given ID.
+func (s *MemoryStorage) Get(ctx context.Context, id int) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ingredient, ok := s.ingredients[id]
+	if !ok || ingredient.IsDeleted() {
+		return nil, ErrIngredientNotFound
+	}
+
+	return ingredient.Clone(), nil
+}
+
+// GetByName returns the ingredient whose name or alias matches the given one.
+func (s *MemoryStorage) GetByName(ctx context.Context, name string) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ingredient := s.findIngredient(normalizedName)
+	if ingredient == nil {
+		return nil, ErrIngredientNotFound
+	}
+
+	return ingredient.Clone(), nil
+}
+
+// History returns every revision of the ingredient with the given ID, oldest
+// first. Ingredients in the trash or purged keep their history.
+func (s *MemoryStorage) History(ctx context.Context, id int) ([]*models.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := s.revisionsByIngredient[id]
+	if len(revisions) == 0 && s.ingredients[id] == nil {
+		return nil, ErrIngredientNotFound
+	}
+
+	results := make([]*models.Revision, 0, len(revisions))
+	for _, revision := range revisions {
+		results = append(results, revision.Clone())
+	}
+	return results, nil
+}
+
+// List returns every ingredient, ordered by ID.
+func (s *MemoryStorage) List(ctx context.Context) ([]*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := s.liveLocked()
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+
+	return cloneIngredients(results), nil
+}
+
+// ListPage returns the page of ingredients selected by options.
+func (s *MemoryStorage) ListPage(ctx context.Context, options ListOptions) (*IngredientPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor, err := options.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	page := pageOf(s.liveLocked(), options, cursor)
+	page.Ingredients = cloneIngredients(page.Ingredients)
+	return page, nil
+}
+
+// ListDeleted returns every ingredient in the trash, ordered by ID.
+func (s *MemoryStorage) ListDeleted(ctx context.Context) ([]*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return cloneIngredients(s.deletedBefore(time.Time{})), nil
+}
+
+// Purge permanently removes every ingredient moved to the trash before the
+// given time and returns them. A zero time purges the whole trash.
+func (s *MemoryStorage) Purge(ctx context.Context, deletedBefore time.Time) ([]*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := s.deletedBefore(deletedBefore)
+	err := s.applyBatch(func() error {
+		for _, ingredient := range purged {
+			if err := s.remove(ingredient.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneIngredients(purged), nil
+}
+
+// Restore takes the ingredient with the given ID out of the trash. It fails if
+// its name or any of its aliases has been taken since it was deleted.
+func (s *MemoryStorage) Restore(ctx context.Context, id int) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	targetIngredient, ok := s.ingredients[id]
+	if !ok {
+		return nil, ErrIngredientNotFound
+	}
+	if !targetIngredient.IsDeleted() {
+		return nil, ErrIngredientNotInTrash
+	}
+
+	if s.IngredientNameExists(targetIngredient.Name) {
+		return nil, ErrIngredientNameExists
+	}
+	for _, alias := range targetIngredient.Aliases {
+		if s.IngredientNameExists(alias.Name) {
+			return nil, ErrIngredientAliasExists
+		}
+	}
+
+	updated := targetIngredient.Clone()
+	updated.DeletedAt = nil
+
+	if err := s.put(ctx, models.RevisionRestore, updated); err != nil {
+		return nil, err
+	}
+
+	return updated.Clone(), nil
+}
+
+// UndoLastChange reverts the most recent change made by the session of ctx
+// that has not been undone yet, and returns the revision recording the undo.
+// Undoing again walks further back. A change is only undone while it is still
+// the latest one to its ingredient and the names it restores are free.
+func (s *MemoryStorage) UndoLastChange(ctx context.Context) (*models.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessionID := sessionFromContext(ctx)
+	target := latestChange(s.revisions, func(revision *models.Revision) bool {
+		return revision.SessionID == sessionID
+	})
+	if target == nil {
+		return nil, ErrNothingToUndo
+	}
+
+	if latestChange(s.revisionsByIngredient[target.IngredientID], func(*models.Revision) bool { return true }) != target {
+		return nil, ErrUndoConflict
+	}
+
+	current, ok := s.ingredients[target.IngredientID]
+	if !ok {
+		return nil, ErrIngredientNotFound
+	}
+
+	reverted := revertedIngredient(target, current, s.clock.Now())
+	if !reverted.IsDeleted() {
+		if id, ok := s.names[strings.ToLower(reverted.Name)]; ok && id != reverted.ID {
+			return nil, ErrIngredientNameExists
+		}
+		for _, alias := range reverted.Aliases {
+			if id, ok := s.names[strings.ToLower(alias.Name)]; ok && id != reverted.ID {
+				return nil, ErrIngredientAliasExists
+			}
+		}
+	}
+
+	revision := &models.Revision{
+		IngredientID: reverted.ID,
+		Change:       models.RevisionUndo,
+		After:        reverted,
+		SessionID:    sessionID,
+		Reverts:      target.ID,
+	}
+	if err := s.putRevision(revision); err != nil {
+		return nil, err
+	}
+
+	return revision.Clone(), nil
+}
+
+func (s *MemoryStorage) Update(ctx context.Context, name, newName string, expectedVersion int) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateLocked(ctx, name, newName, expectedVersion)
+}
+
+// UpdateMany applies every rename in order, or none if any rename fails.
+func (s *MemoryStorage) UpdateMany(ctx context.Context, renames []IngredientRename) ([]*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]*models.Ingredient, len(renames))
+	err := s.applyBatch(func() error {
+		return runBatch(ctx, len(renames), func(i int) error {
+			var err error
+			results[i], err = s.updateLocked(ctx, renames[i].Name, renames[i].NewName, AnyVersion)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// UpdateWithDetails renames the ingredient with the given name, unless newName
+// is empty, and sets the given details, all in one batch, so either every
+// change is made or none is. Each change is recorded as its own revision.
+func (s *MemoryStorage) UpdateWithDetails(ctx context.Context, name, newName string, details IngredientDetails, expectedVersion int) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedDetails, err := details.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	targetIngredient := s.findIngredient(normalizedName)
+	if targetIngredient == nil {
+		return nil, ErrIngredientNotFound
+	}
+
+	if err := checkVersion(targetIngredient, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	var result *models.Ingredient
+	err = s.applyBatch(func() error {
+		if newName != "" {
+			// the version was checked above, and the lock is held since
+			if _, err := s.updateLocked(ctx, normalizedName, newName, AnyVersion); err != nil {
+				return err
+			}
+		}
+		var err error
+		result, err = s.setDetailsLocked(ctx, targetIngredient.ID, normalizedDetails)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Watch returns a channel receiving an event for every change made from now on,
+// in order. It is closed once ctx is done, or if the watcher falls too far
+// behind, in which case it must read what it needs again and watch anew.
+func (s *MemoryStorage) Watch(ctx context.Context) <-chan models.IngredientEvent {
+	return s.feed.watch(ctx)
+}
+
+// AddAlias adds an alternative name, such as a translation, to the ingredient
+// with the given name. Aliases share the uniqueness rules of ingredient names.
+func (s *MemoryStorage) AddAlias(ctx context.Context, name, alias, locale string, expectedVersion int) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedAlias, err := validateIngredientName(alias)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedLocale, err := normalizeAliasLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	targetIngredient := s.findIngredient(normalizedName)
+	if targetIngredient == nil {
+		return nil, ErrIngredientNotFound
+	}
+
+	if err := checkVersion(targetIngredient, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	if s.IngredientNameExists(normalizedAlias) {
+		return nil, ErrIngredientAliasExists
+	}
+
+	if len(targetIngredient.Aliases) >= IngredientMaxAliases {
+		return nil, ErrIngredientTooManyAliases
+	}
+
+	updated := targetIngredient.Clone()
+	updated.Aliases = append(updated.Aliases, models.Alias{
+		Name:   normalizedAlias,
+		Locale: normalizedLocale,
+	})
+	updated.UpdatedAt = s.clock.Now()
+
+	if err := s.put(ctx, models.RevisionAddAlias, updated); err != nil {
+		return nil, err
+	}
+
+	return updated.Clone(), nil
+}
+
+// RemoveAlias removes an alias from the ingredient with the given name.
+func (s *MemoryStorage) RemoveAlias(ctx context.Context, name, alias string, expectedVersion int) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedAlias, err := validateIngredientName(alias)
+	if err != nil {
+		return nil, err
 	}
+
+	targetIngredient := s.findIngredient(normalizedName)
+	if targetIngredient == nil {
+		return nil, ErrIngredientNotFound
+	}
+
+	if err := checkVersion(targetIngredient, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	aliases := make([]models.Alias, 0, len(targetIngredient.Aliases))
+	for _, existing := range targetIngredient.Aliases {
+		if !strings.EqualFold(existing.Name, normalizedAlias) {
+			aliases = append(aliases, existing)
+		}
+	}
+
+	if len(aliases) == len(targetIngredient.Aliases) {
+		return nil, ErrIngredientAliasNotFound
+	}
+
+	updated := targetIngredient.Clone()
+	updated.Aliases = aliases
+	updated.UpdatedAt = s.clock.Now()
+
+	if err := s.put(ctx, models.RevisionRemoveAlias, updated); err != nil {
+		return nil, err
+	}
+
+	return updated.Clone(), nil
 }
 
-// Create adds a new ingredient and returns it with an assigned ID.
-func (s *MemoryStorage) Create(name string) (*models.Ingredient, error) {
+// SetCategory sets the category of the ingredient with the given name.
+func (s *MemoryStorage) SetCategory(ctx context.Context, name string, category models.Category, expectedVersion int) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	normalizedName, err := s.validateIngredientName(name)
+	normalizedName, err := validateIngredientName(name)
 	if err != nil {
 		return nil, err
 	}
 
-	if s.IngredientNameExists(normalizedName) {
-		return nil, ErrIngredientNameExists
+	normalizedCategory, ok := models.ParseCategory(string(category))
+	if !ok {
+		return nil, ErrIngredientCategoryInvalid
+	}
+
+	targetIngredient := s.findIngredient(normalizedName)
+	if targetIngredient == nil {
+		return nil, ErrIngredientNotFound
+	}
+
+	if err := checkVersion(targetIngredient, expectedVersion); err != nil {
+		return nil, err
 	}
 
-	ingredient := models.NewIngredient(s.nextID, normalizedName)
-	s.ingredients[s.nextID] = ingredient
-	s.nextID++
+	updated := targetIngredient.Clone()
+	updated.Category = normalizedCategory
+	updated.UpdatedAt = s.clock.Now()
+
+	if err := s.put(ctx, models.RevisionSetCategory, updated); err != nil {
+		return nil, err
+	}
 
-	return ingredient, nil
+	return updated.Clone(), nil
 }
 
-func (s *MemoryStorage) Delete(name string) error {
+// SetDietaryInfo replaces the allergens and diets of the ingredient with the given name.
+func (s *MemoryStorage) SetDietaryInfo(ctx context.Context, name string, info models.DietaryInfo, expectedVersion int) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	normalizedName, err := s.validateIngredientName(name)
+	normalizedName, err := validateIngredientName(name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var targetIngredient *models.Ingredient
-	for _, ingredient := range s.ingredients {
-		if strings.EqualFold(ingredient.Name, normalizedName) {
-			targetIngredient = ingredient
-			break
-		}
+	normalizedInfo, err := ValidateDietaryInfo(info)
+	if err != nil {
+		return nil, err
 	}
 
+	targetIngredient := s.findIngredient(normalizedName)
 	if targetIngredient == nil {
-		return ErrIngredientNotFound
+		return nil, ErrIngredientNotFound
 	}
 
-	delete(s.ingredients, targetIngredient.ID)
+	if err := checkVersion(targetIngredient, expectedVersion); err != nil {
+		return nil, err
+	}
 
-	return nil
+	updated := targetIngredient.Clone()
+	updated.Dietary = &normalizedInfo
+	updated.UpdatedAt = s.clock.Now()
+
+	if err := s.put(ctx, models.RevisionSetDietaryInfo, updated); err != nil {
+		return nil, err
+	}
+
+	return updated.Clone(), nil
 }
 
-func (s *MemoryStorage) List() ([]*models.Ingredient, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// SetNutrition replaces the nutrition facts of the ingredient with the given name.
+func (s *MemoryStorage) SetNutrition(ctx context.Context, name string, facts models.NutritionFacts, expectedVersion int) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	results := make([]*models.Ingredient, 0, len(s.ingredients))
-	for _, ingredient := range s.ingredients {
-		results = append(results, ingredient)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
 	}
 
-	return results, nil
+	if err := ValidateNutritionFacts(facts); err != nil {
+		return nil, err
+	}
+
+	targetIngredient := s.findIngredient(normalizedName)
+	if targetIngredient == nil {
+		return nil, ErrIngredientNotFound
+	}
+
+	if err := checkVersion(targetIngredient, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	updated := targetIngredient.Clone()
+	updated.Nutrition = &facts
+	updated.UpdatedAt = s.clock.Now()
+
+	if err := s.put(ctx, models.RevisionSetNutrition, updated); err != nil {
+		return nil, err
+	}
+
+	return updated.Clone(), nil
 }
 
-func (s *MemoryStorage) Update(name, newName string) (*models.Ingredient, error) {
+// SetUnitConversion replaces the unit conversion fields of the ingredient with the given name.
+func (s *MemoryStorage) SetUnitConversion(ctx context.Context, name string, conversion models.UnitConversion, expectedVersion int) (*models.Ingredient, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// normalize both inputs early
-	normalizedName, err := s.validateIngredientName(name)
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedConversion, err := ValidateUnitConversion(conversion)
+	if err != nil {
+		return nil, err
+	}
+
+	targetIngredient := s.findIngredient(normalizedName)
+	if targetIngredient == nil {
+		return nil, ErrIngredientNotFound
+	}
+
+	if err := checkVersion(targetIngredient, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	updated := targetIngredient.Clone()
+	updated.UnitConversion = normalizedConversion
+	updated.UpdatedAt = s.clock.Now()
+
+	if err := s.put(ctx, models.RevisionSetUnitConversion, updated); err != nil {
+		return nil, err
+	}
+
+	return updated.Clone(), nil
+}
+
+func (s *MemoryStorage) SeedTestData(ctx context.Context) ([]*models.Ingredient, error) {
+	return seedTestData(ctx, s)
+}
+
+// createLocked is Create for callers already holding the lock.
+func (s *MemoryStorage) createLocked(ctx context.Context, name string) (*models.Ingredient, error) {
+	normalizedName, err := validateIngredientName(name)
 	if err != nil {
 		return nil, err
 	}
 
-	normalizedNewName, err := s.validateIngredientName(newName)
+	if s.IngredientNameExists(normalizedName) {
+		return nil, ErrIngredientNameExists
+	}
+
+	id, err := s.newID()
 	if err != nil {
 		return nil, err
 	}
 
-	var targetIngredient *models.Ingredient
+	ingredient := models.NewIngredientAt(id, normalizedName, s.clock.Now())
+	if err := s.put(ctx, models.RevisionCreate, ingredient); err != nil {
+		return nil, err
+	}
+	if s.ids == nil {
+		s.nextID++
+	}
+
+	return ingredient.Clone(), nil
+}
+
+// newID returns the ID of the next ingredient to create. IDs ever used before
+// are skipped, so purged ingredients never share their history, and numbering
+// in order steps over the IDs a generator handed out.
+func (s *MemoryStorage) newID() (int, error) {
+	if s.ids == nil {
+		for s.idUsed(s.nextID) {
+			s.nextID++
+		}
+		return s.nextID, nil
+	}
+
+	for range idAttempts {
+		id := s.ids.NewID()
+		if id <= 0 || s.idUsed(id) {
+			continue
+		}
+		return id, nil
+	}
+	return 0, ErrIngredientIDsExhausted
+}
+
+// idUsed reports whether an ingredient, even a purged one, ever had the given ID.
+func (s *MemoryStorage) idUsed(id int) bool {
+	return s.ingredients[id] != nil || len(s.revisionsByIngredient[id]) > 0
+}
+
+// deleteLocked is Delete for callers already holding the lock.
+func (s *MemoryStorage) deleteLocked(ctx context.Context, name string, expectedVersion int) error {
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return err
+	}
+
+	targetIngredient := s.findIngredient(normalizedName)
+	if targetIngredient == nil {
+		return ErrIngredientNotFound
+	}
+
+	if err := checkVersion(targetIngredient, expectedVersion); err != nil {
+		return err
+	}
+
+	deleted := targetIngredient.Clone()
+	deletedAt := s.clock.Now()
+	deleted.DeletedAt = &deletedAt
+
+	return s.put(ctx, models.RevisionDelete, deleted)
+}
+
+// cloneIngredients returns deep copies of the given ingredients, in the same
+// order, for handing stored ingredients out to callers.
+func cloneIngredients(ingredients []*models.Ingredient) []*models.Ingredient {
+	clones := make([]*models.Ingredient, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		clones = append(clones, ingredient.Clone())
+	}
+	return clones
+}
+
+// liveLocked returns the ingredients that are not in the trash, in any order.
+func (s *MemoryStorage) liveLocked() []*models.Ingredient {
+	results := make([]*models.Ingredient, 0, len(s.ingredients))
+	for _, ingredient := range s.ingredients {
+		if !ingredient.IsDeleted() {
+			results = append(results, ingredient)
+		}
+	}
+	return results
+}
+
+// deletedBefore returns the ingredients in the trash that were deleted before
+// the given time, or all of them for a zero time, ordered by ID.
+func (s *MemoryStorage) deletedBefore(t time.Time) []*models.Ingredient {
+	results := make([]*models.Ingredient, 0)
 	for _, ingredient := range s.ingredients {
-		if strings.EqualFold(ingredient.Name, normalizedName) {
-			targetIngredient = ingredient
-			break
+		if ingredient.IsDeleted() && (t.IsZero() || ingredient.DeletedAt.Before(t)) {
+			results = append(results, ingredient)
 		}
 	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+
+	return results
+}
+
+// updateLocked is Update for callers already holding the lock.
+func (s *MemoryStorage) updateLocked(ctx context.Context, name, newName string, expectedVersion int) (*models.Ingredient, error) {
+	// normalize both inputs early
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedNewName, err := validateIngredientName(newName)
+	if err != nil {
+		return nil, err
+	}
 
+	targetIngredient := s.findIngredient(normalizedName)
 	if targetIngredient == nil {
 		return nil, ErrIngredientNotFound
 	}
 
+	if err := checkVersion(targetIngredient, expectedVersion); err != nil {
+		return nil, err
+	}
+
 	if s.IngredientNameExists(normalizedNewName) {
 		return nil, ErrIngredientNameExists
 	}
 
-	targetIngredient.Name = normalizedNewName
-	targetIngredient.UpdatedAt = time.Now()
-
-	return targetIngredient, nil
-}
-
-func (s *MemoryStorage) SeedTestData() ([]*models.Ingredient, error) {
-	testIngredients := []string{
-		"sal",
-		"pimienta negra",
-		"ajo en polvo",
-		"cebolla en polvo",
-		"pimentón",
-		"comino",
-		"orégano",
-		"albahaca seca",
-		"tomillo",
-		"romero",
-		"aceite de oliva",
-		"aceite vegetal",
-		"vinagre blanco",
-		"vinagre de manzana",
-		"vinagre balsámico",
-		"leche",
-		"mantequilla",
-		"queso parmesano",
-		"huevos",
-		"yogur natural",
-		"pollo",
-		"ternera",
-		"pescado blanco",
-		"atún en lata",
-		"judías",
-		"cebolla",
-		"ajo fresco",
-		"tomate",
-		"zanahoria",
-		"apio",
-		"pimiento",
-		"patata",
-		"limón",
-		"arroz",
-		"pasta",
-		"pan",
-		"harina",
-		"avena",
-		"azúcar",
-		"miel",
-		"salsa de soja",
-		"caldo de pollo",
-		"tomate triturado",
-		"mostaza",
-		"mahonesa",
-		"perejil",
-		"cilantro",
-		"albahaca fresca",
-		"levadura",
-		"bicarbonato sódico",
-	}
-	results := make([]*models.Ingredient, 0, len(testIngredients))
-
-	for _, ingredientName := range testIngredients {
-		ingredient, err := s.Create(ingredientName)
+	updated := targetIngredient.Clone()
+	updated.Name = normalizedNewName
+	updated.UpdatedAt = s.clock.Now()
+
+	if err := s.put(ctx, models.RevisionRename, updated); err != nil {
+		return nil, err
+	}
+
+	return updated.Clone(), nil
+}
+
+// setDetailsLocked sets the given details, already normalized, of the
+// ingredient with the given ID, recording a revision for each of them, and
+// returns the ingredient as it is afterwards.
+func (s *MemoryStorage) setDetailsLocked(ctx context.Context, id int, details IngredientDetails) (*models.Ingredient, error) {
+	set := func(change models.RevisionChange, apply func(updated *models.Ingredient)) error {
+		updated := s.ingredients[id].Clone()
+		apply(updated)
+		updated.UpdatedAt = s.clock.Now()
+		return s.put(ctx, change, updated)
+	}
+
+	if details.Nutrition != nil {
+		err := set(models.RevisionSetNutrition, func(updated *models.Ingredient) {
+			facts := *details.Nutrition
+			updated.Nutrition = &facts
+		})
 		if err != nil {
-			continue
+			return nil, err
 		}
-		results = append(results, ingredient)
 	}
-	return results, nil
+
+	if details.UnitConversion != nil {
+		err := set(models.RevisionSetUnitConversion, func(updated *models.Ingredient) {
+			updated.UnitConversion = *details.UnitConversion
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if details.Category != nil {
+		err := set(models.RevisionSetCategory, func(updated *models.Ingredient) {
+			updated.Category = *details.Category
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.ingredients[id].Clone(), nil
 }
 
-func (s *MemoryStorage) IngredientNameExists(name string) bool {
-	for _, ingredient := range s.ingredients {
-		if strings.EqualFold(ingredient.Name, name) {
-			return true
+// memoryBatch holds the changes made so far by a batch, along with what they
+// replaced, so they can be journaled as one record or undone.
+type memoryBatch struct {
+	records []journalRecord
+	// previous holds, for every record, the ingredient it replaced or removed,
+	// or nil if it added a new one.
+	previous  []*models.Ingredient
+	nextID    int
+	revisions int
+	// events are published once the whole batch has been applied.
+	events []models.IngredientEvent
+}
+
+// applyBatch runs the changes of a batch. Once they all succeed they are
+// recorded in the journal as a single record, so a crash never leaves part of
+// a batch behind; if any fails, or the journal cannot be written, every change
+// is undone. A journal due for compaction is compacted before the batch
+// starts, so no snapshot ever holds a batch that is undone.
+func (s *MemoryStorage) applyBatch(changes func() error) error {
+	if err := s.compactJournal(); err != nil {
+		return err
+	}
+
+	batch := &memoryBatch{nextID: s.nextID, revisions: len(s.revisions)}
+	s.batch = batch
+	err := changes()
+	s.batch = nil
+
+	if err == nil && len(batch.records) > 0 {
+		err = s.appendJournal(journalRecord{Op: journalOpBatch, Batch: batch.records})
+	}
+	if err == nil {
+		s.feed.publish(batch.events...)
+	}
+
+	if err != nil {
+		for i := len(batch.records) - 1; i >= 0; i-- {
+			if previous := batch.previous[i]; previous != nil {
+				s.store(previous)
+			} else {
+				s.drop(batch.records[i].Ingredient.ID)
+			}
 		}
+		s.nextID = batch.nextID
+
+		for _, revision := range s.revisions[batch.revisions:] {
+			history := s.revisionsByIngredient[revision.IngredientID]
+			s.revisionsByIngredient[revision.IngredientID] = history[:len(history)-1]
+		}
+		s.revisions = s.revisions[:batch.revisions]
+	}
+
+	return err
+}
+
+// put stores a new or changed ingredient along with the revision describing
+// the change.
+func (s *MemoryStorage) put(ctx context.Context, change models.RevisionChange, ingredient *models.Ingredient) error {
+	return s.putRevision(&models.Revision{
+		IngredientID: ingredient.ID,
+		Change:       change,
+		After:        ingredient,
+		SessionID:    sessionFromContext(ctx),
+	})
+}
+
+// putRevision stores the ingredient a revision leads to, filling in the rest
+// of the revision and the version of the ingredient, and records both in the
+// journal first. Stored ingredients are replaced rather than changed in place,
+// so a change that cannot be recorded leaves the storage untouched, and
+// revisions can share them.
+func (s *MemoryStorage) putRevision(revision *models.Revision) error {
+	revision.ID = len(s.revisions) + 1
+	revision.Before = s.ingredients[revision.IngredientID]
+	revision.CreatedAt = s.clock.Now()
+	if revision.Before != nil {
+		revision.After.Version = revision.Before.Version + 1
+	}
+
+	if err := s.record(journalRecord{Op: journalOpPut, Ingredient: revision.After, Revision: revision}); err != nil {
+		return err
+	}
+	s.store(revision.After)
+	s.appendRevision(revision)
+	s.publish(revisionEvent(revision))
+	return nil
+}
+
+// appendRevision adds a revision to the history.
+func (s *MemoryStorage) appendRevision(revision *models.Revision) {
+	s.revisions = append(s.revisions, revision)
+	s.revisionsByIngredient[revision.IngredientID] = append(s.revisionsByIngredient[revision.IngredientID], revision)
+}
+
+// remove deletes an ingredient, recording it in the journal first.
+func (s *MemoryStorage) remove(id int) error {
+	if err := s.record(journalRecord{Op: journalOpDelete, ID: id}); err != nil {
+		return err
+	}
+	s.publish(purgedEvent(s.ingredients[id]))
+	s.drop(id)
+	return nil
+}
+
+// publish announces a change to the watchers, or leaves it to applyBatch
+// while a batch is being applied.
+func (s *MemoryStorage) publish(event models.IngredientEvent) {
+	if s.batch != nil {
+		s.batch.events = append(s.batch.events, event)
+		return
+	}
+	s.feed.publish(event)
+}
+
+// store adds or replaces an ingredient and keeps the name index in sync.
+// Ingredients in the trash are left out of the index, so their names are free.
+func (s *MemoryStorage) store(ingredient *models.Ingredient) {
+	s.unindex(s.ingredients[ingredient.ID])
+	s.ingredients[ingredient.ID] = ingredient
+	if ingredient.IsDeleted() {
+		return
+	}
+
+	s.names[strings.ToLower(ingredient.Name)] = ingredient.ID
+	for _, alias := range ingredient.Aliases {
+		s.names[strings.ToLower(alias.Name)] = ingredient.ID
+	}
+}
+
+// drop deletes an ingredient and its entries in the name index.
+func (s *MemoryStorage) drop(id int) {
+	s.unindex(s.ingredients[id])
+	delete(s.ingredients, id)
+}
+
+// unindex removes the name and aliases of an ingredient from the name index,
+// unless they now belong to another ingredient.
+func (s *MemoryStorage) unindex(ingredient *models.Ingredient) {
+	if ingredient == nil {
+		return
 	}
-	return false
+
+	names := []string{ingredient.Name}
+	for _, alias := range ingredient.Aliases {
+		names = append(names, alias.Name)
+	}
+
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if s.names[key] == ingredient.ID {
+			delete(s.names, key)
+		}
+	}
+}
+
+func (s *MemoryStorage) IngredientNameExists(name string) bool {
+	return s.findIngredient(name) != nil
 }
 
-func (s *MemoryStorage) validateIngredientName(name string) (string, error) {
+// findIngredient returns the ingredient whose name or alias matches the given one.
+func (s *MemoryStorage) findIngredient(normalizedName string) *models.Ingredient {
+	id, ok := s.names[strings.ToLower(normalizedName)]
+	if !ok {
+		return nil
+	}
+	return s.ingredients[id]
+}
+
+func validateIngredientName(name string) (string, error) {
 	normalizedName := normalizeIngredientName(name)
 
 	if normalizedName == "" {
@@ -248,3 +1153,54 @@ func normalizeIngredientName(name string) string {
 func isValidIngredientName(name string) bool {
 	return ingredientNameRegex.MatchString(name)
 }
+
+// ingredientSnapshot is a point-in-time copy of the contents of a MemoryStorage.
+type ingredientSnapshot struct {
+	NextID      int                  `json:"next_id"`
+	Ingredients []*models.Ingredient `json:"ingredients"`
+	Revisions   []*models.Revision   `json:"revisions,omitempty"`
+}
+
+// snapshot returns deep copies of every ingredient, ordered by ID, along with
+// the next ID to assign.
+func (s *MemoryStorage) snapshot() ingredientSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.snapshotLocked()
+}
+
+// snapshotLocked is snapshot for callers already holding the lock.
+func (s *MemoryStorage) snapshotLocked() ingredientSnapshot {
+	ingredients := make([]*models.Ingredient, 0, len(s.ingredients))
+	for _, ingredient := range s.ingredients {
+		ingredients = append(ingredients, ingredient.Clone())
+	}
+	sort.Slice(ingredients, func(i, j int) bool {
+		return ingredients[i].ID < ingredients[j].ID
+	})
+
+	// revisions are never changed once made, so they can be shared
+	revisions := append([]*models.Revision(nil), s.revisions...)
+
+	return ingredientSnapshot{NextID: s.nextID, Ingredients: ingredients, Revisions: revisions}
+}
+
+// restore replaces the contents of the storage with the given snapshot.
+func (s *MemoryStorage) restore(snapshot ingredientSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ingredients = make(map[int]*models.Ingredient, len(snapshot.Ingredients))
+	s.names = make(map[string]int, len(snapshot.Ingredients))
+	for _, ingredient := range snapshot.Ingredients {
+		s.store(ingredient)
+	}
+	s.nextID = snapshot.NextID
+
+	s.revisions = nil
+	s.revisionsByIngredient = make(map[int][]*models.Revision)
+	for _, revision := range snapshot.Revisions {
+		s.appendRevision(revision)
+	}
+}
@@ -1,8 +1,14 @@
 package storage
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"testing"
 	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
 )
 
 func TestNewMemoryStorage(t *testing.T) {
@@ -20,404 +26,365 @@ func TestNewMemoryStorage(t *testing.T) {
 	}
 }
 
-func TestCreateIngredient(t *testing.T) {
-	t.Run("successful creation", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		ingredient, err := storage.Create("tomato")
+// fixedClock is a Clock that only moves when told to.
+type fixedClock struct {
+	now time.Time
+}
 
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
-		}
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
 
-		if ingredient.ID != 1 {
-			t.Errorf("expected ID 1, got %d", ingredient.ID)
-		}
+// listedIDs is an IDGenerator handing out the given IDs in order.
+type listedIDs struct {
+	ids []int
+}
 
-		if ingredient.Name != "tomato" {
-			t.Errorf("expected name 'tomato', got '%s'", ingredient.Name)
-		}
+func (g *listedIDs) NewID() int {
+	if len(g.ids) == 0 {
+		return 0
+	}
+	id := g.ids[0]
+	g.ids = g.ids[1:]
+	return id
+}
 
-		if ingredient.CreatedAt.IsZero() {
-			t.Errorf("creation date should not be zero")
-		}
+func TestMemoryStorageClock(t *testing.T) {
+	ctx := t.Context()
+	clock := &fixedClock{now: time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)}
+	storage := NewMemoryStorage(WithClock(clock))
 
-		if ingredient.UpdatedAt.IsZero() {
-			t.Errorf("update date should not be zero")
-		}
-	})
+	created, _ := storage.Create(ctx, "tomato")
+	if !created.CreatedAt.Equal(clock.now) || !created.UpdatedAt.Equal(clock.now) {
+		t.Errorf("expected ingredient created at %v, got %v and %v", clock.now, created.CreatedAt, created.UpdatedAt)
+	}
 
-	t.Run("duplicated ingredient names", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		ingredient1 := "tomato"
-		ingredient2 := "tomato"
+	clock.now = clock.now.Add(time.Hour)
+	updated, _ := storage.SetCategory(ctx, "tomato", models.CategoryProduce, AnyVersion)
+	if !updated.CreatedAt.Equal(clock.now.Add(-time.Hour)) || !updated.UpdatedAt.Equal(clock.now) {
+		t.Errorf("expected ingredient updated at %v, got %v", clock.now, updated.UpdatedAt)
+	}
 
-		_, err := storage.Create(ingredient1)
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
-		}
+	clock.now = clock.now.Add(time.Hour)
+	storage.Delete(ctx, "tomato", AnyVersion)
+	deleted, _ := storage.ListDeleted(ctx)
+	if len(deleted) != 1 || !deleted[0].DeletedAt.Equal(clock.now) {
+		t.Errorf("expected ingredient deleted at %v, got %v", clock.now, deleted)
+	}
 
-		_, err = storage.Create(ingredient2)
-		if err != ErrIngredientNameExists {
-			t.Errorf("expected %v, got %v", ErrIngredientNameExists, err)
+	history, _ := storage.History(ctx, created.ID)
+	for i, revision := range history {
+		expected := clock.now.Add(time.Duration(i-2) * time.Hour)
+		if !revision.CreatedAt.Equal(expected) {
+			t.Errorf("expected revision %d made at %v, got %v", revision.ID, expected, revision.CreatedAt)
 		}
-	})
+	}
+}
 
-	t.Run("case insensitive duplicates", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		ingredient1 := "tomato"
-		ingredient2 := " TOMATO "
+func TestMemoryStorageIDGenerator(t *testing.T) {
+	ctx := t.Context()
 
-		_, err := storage.Create(ingredient1)
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
-		}
+	t.Run("generated IDs are used", func(t *testing.T) {
+		storage := NewMemoryStorage(WithIDGenerator(&listedIDs{ids: []int{42, 7}}))
 
-		_, err = storage.Create(ingredient2)
-		if err != ErrIngredientNameExists {
-			t.Errorf("expected %v, got %v", ErrIngredientNameExists, err)
+		tomato, _ := storage.Create(ctx, "tomato")
+		basil, _ := storage.Create(ctx, "basil")
+		if tomato.ID != 42 || basil.ID != 7 {
+			t.Errorf("expected IDs 42 and 7, got %d and %d", tomato.ID, basil.ID)
 		}
-	})
-
-	t.Run("empty ingredient name", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		ingredient := ""
 
-		_, err := storage.Create(ingredient)
-		if err != ErrIngredientNameCannotBeEmpty {
-			t.Errorf("expected %v, got %v", ErrIngredientNameCannotBeEmpty, err)
+		if ingredient, err := storage.Get(ctx, 42); err != nil || ingredient.Name != "tomato" {
+			t.Errorf("expected tomato under ID 42, got %v, %v", ingredient, err)
 		}
 	})
 
-	t.Run("ingredient name is too short", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		ingredient := "XD"
+	t.Run("IDs in use or ever used are skipped", func(t *testing.T) {
+		storage := NewMemoryStorage(WithIDGenerator(&listedIDs{ids: []int{5, -1, 5, 0, 6, 5, 8}}))
 
-		_, err := storage.Create(ingredient)
-		if err != ErrIngredientNameIsTooShort {
-			t.Errorf("expected %v, got %v", ErrIngredientNameIsTooShort, err)
+		storage.Create(ctx, "tomato")
+		basil, _ := storage.Create(ctx, "basil")
+		if basil.ID != 6 {
+			t.Errorf("expected ID 6, got %d", basil.ID)
 		}
-	})
 
-	t.Run("ingredient name is too long", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		ingredient := "Super-Ultra-Mega-Long-Ingredient-Name-That-Goes-On-Forever"
-
-		_, err := storage.Create(ingredient)
-		if err != ErrIngredientNameIsTooLong {
-			t.Errorf("expected %v, got %v", ErrIngredientNameIsTooLong, err)
+		// purged ingredients keep their history under their ID
+		storage.Delete(ctx, "tomato", AnyVersion)
+		storage.Purge(ctx, time.Time{})
+		cheese, _ := storage.Create(ctx, "cheese")
+		if cheese.ID != 8 {
+			t.Errorf("expected ID 8, got %d", cheese.ID)
 		}
 	})
 
-	t.Run("whitespace normalization", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		name := "  ChickeN   breast   "
-		normalizedName := "chicken breast"
+	t.Run("a generator with no unused IDs left", func(t *testing.T) {
+		storage := NewMemoryStorage(WithIDGenerator(&listedIDs{ids: []int{1}}))
+		storage.Create(ctx, "tomato")
 
-		ingredient, err := storage.Create(name)
-		if err != nil {
-			t.Fatalf("unexpected error: %v", err)
+		_, err := storage.Create(ctx, "basil")
+		if err != ErrIngredientIDsExhausted {
+			t.Errorf("expected %v, got %v", ErrIngredientIDsExhausted, err)
 		}
 
-		if ingredient.Name != normalizedName {
-			t.Errorf("expected normalized name %q, got %q", normalizedName, ingredient.Name)
+		_, err = storage.CreateMany(ctx, []string{"basil", "cheese"})
+		if !errors.Is(err, ErrIngredientIDsExhausted) {
+			t.Errorf("expected %v, got %v", ErrIngredientIDsExhausted, err)
 		}
 	})
 
-	t.Run("character regexp validation", func(t *testing.T) {
-		storage := NewMemoryStorage()
-
-		testCases := []struct {
-			name        string
-			input       string
-			shouldError bool
-			expectedErr error
-		}{
-			// valid cases
-			{"simple ingredient", "tomato", false, nil},
-			{"with apostrophe", "Mom's Sauce", false, nil},
-			{"with accent", "jalapeño", false, nil},
-			{"with hyphen", "extra-virgin", false, nil},
-			{"with number", "7-Spice Blend", false, nil},
-			{"mixed case", "Chicken Breast", false, nil},
-
-			// invalid cases
-			{"with quotes", `"salt"`, true, ErrIngredientNameContainsInvalidChars},
-			{"with at symbol", "tom@to", true, ErrIngredientNameContainsInvalidChars},
-			{"with brackets", "salt<script>", true, ErrIngredientNameContainsInvalidChars},
-			{"with emoji", "🍅", true, ErrIngredientNameContainsInvalidChars},
-			{"with parentheses", "salt (sea)", true, ErrIngredientNameContainsInvalidChars},
-			{"with period", "Dr. Pepper", true, ErrIngredientNameContainsInvalidChars},
-		}
-
-		for _, tc := range testCases {
-			t.Run(tc.name, func(t *testing.T) {
-				_, err := storage.Create(tc.input)
-
-				if tc.shouldError {
-					if err != tc.expectedErr {
-						t.Errorf("expected error %v, got %v", tc.expectedErr, err)
-					}
-				} else {
-					if err != nil {
-						t.Errorf("expected no error, got %v", err)
-					}
-				}
-			})
-		}
-	})
-}
-
-func TestDeleteIngredient(t *testing.T) {
-	t.Run("successful single delete", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		storage.Create("tomato")
-
-		err := storage.Delete("tomato")
+	t.Run("generated IDs survive a restart", func(t *testing.T) {
+		dir := t.TempDir()
+		generator, err := NewTimeOrderedIDGenerator(3)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-
-		ingredients, _ := storage.List()
-		for _, ingredient := range ingredients {
-			if ingredient.Name == "tomato" {
-				t.Errorf("tomato should have been deleted but was still found")
-			}
-		}
-	})
-
-	t.Run("successful multiple deletion", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		storage.Create("tomato")
-		storage.Create("basil")
-
-		err := storage.Delete("tomato")
+		storage, err := NewJournaledMemoryStorage(dir, WithIDGenerator(generator))
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		err = storage.Delete("basil")
+		created, _ := storage.Create(ctx, "tomato")
+		storage.Close()
+
+		reopened, err := NewJournaledMemoryStorage(dir)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		ingredients, _ := storage.List()
-		for _, ingredient := range ingredients {
-			if ingredient.Name == "tomato" {
-				t.Errorf("tomato should have been deleted but was still found")
-			}
+		defer reopened.Close()
+
+		if ingredient, err := reopened.Get(ctx, created.ID); err != nil || ingredient.Name != "tomato" {
+			t.Errorf("expected tomato under ID %d, got %v, %v", created.ID, ingredient, err)
 		}
-		for _, ingredient := range ingredients {
-			if ingredient.Name == "basil" {
-				t.Errorf("basil should have been deleted but was still found")
-			}
+
+		// numbering in order does not jump past the generated IDs
+		basil, _ := reopened.Create(ctx, "basil")
+		if basil.ID != 1 {
+			t.Errorf("expected ID 1, got %d", basil.ID)
 		}
 	})
 
-	t.Run("case insensitive delete", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		storage.Create("tomato")
-
-		err := storage.Delete("  TOMATO ")
+	t.Run("numbering in order skips generated IDs", func(t *testing.T) {
+		dir := t.TempDir()
+		storage, err := NewJournaledMemoryStorage(dir, WithIDGenerator(&listedIDs{ids: []int{2, 3}}))
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
+		storage.Create(ctx, "tomato")
+		storage.Create(ctx, "basil")
+		storage.Delete(ctx, "basil", AnyVersion)
+		storage.Purge(ctx, time.Time{})
+		storage.Close()
 
-		ingredients, _ := storage.List()
-		for _, ingredient := range ingredients {
-			if ingredient.Name == "tomato" {
-				t.Errorf("tomato should have been deleted but was still found")
-			}
+		reopened, err := NewJournaledMemoryStorage(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
-	})
-
-	t.Run("ingredient not found", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		storage.Create("tomato")
+		defer reopened.Close()
 
-		err := storage.Delete("brotato")
-		if err != ErrIngredientNotFound {
-			t.Errorf("expected %v, got %v", ErrIngredientNotFound, err)
+		// 2 is in use and 3 was used by the purged basil
+		var ids []int
+		for _, name := range []string{"cheese", "garlic", "onion"} {
+			ingredient, _ := reopened.Create(ctx, name)
+			ids = append(ids, ingredient.ID)
 		}
-	})
-
-	t.Run("delete from empty storage", func(t *testing.T) {
-		storage := NewMemoryStorage()
-
-		err := storage.Delete("brotato")
-		if err != ErrIngredientNotFound {
-			t.Errorf("expected %v, got %v", ErrIngredientNotFound, err)
+		if !slices.Equal(ids, []int{1, 4, 5}) {
+			t.Errorf("expected IDs [1 4 5], got %v", ids)
 		}
 	})
 }
 
-func TestUpdateIngredient(t *testing.T) {
-	t.Run("successful update", func(t *testing.T) {
-		storage := NewMemoryStorage()
-
-		originalName := "tomato"
-		storage.Create(originalName)
-		newName := "potato"
-
-		time.Sleep(5 * time.Millisecond)
-
-		ingredient, err := storage.Update(originalName, newName)
+func TestTimeOrderedIDGenerator(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)}
+	newGenerator := func(node int) *TimeOrderedIDGenerator {
+		generator, err := NewTimeOrderedIDGenerator(node)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		if ingredient.Name != newName {
-			t.Errorf("expected name %q, got %q", ingredient.Name, newName)
-		}
-		if !ingredient.UpdatedAt.After(ingredient.CreatedAt) {
-			t.Errorf("UpdatedAt should be after CreatedAt")
-		}
-	})
-
-	t.Run("ingredient not found", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		storage.Create("tomato")
-
-		_, err := storage.Update("brotato", "potato")
-		if err != ErrIngredientNotFound {
-			t.Errorf("expected %v, got %v", ErrIngredientNotFound, err)
-		}
-	})
-
-	t.Run("validation errors", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		originalName := "tomato"
-		storage.Create(originalName)
+		generator.clock = clock
+		return generator
+	}
 
-		_, err := storage.Update(originalName, "tomato")
-		if err != ErrIngredientNameExists {
-			t.Errorf("expected %v, got %v", ErrIngredientNameExists, err)
-		}
+	t.Run("IDs grow and stay unique", func(t *testing.T) {
+		generator := newGenerator(1)
 
-		_, err = storage.Update(originalName, " tomato ")
-		if err != ErrIngredientNameExists {
-			t.Errorf("expected %v, got %v", ErrIngredientNameExists, err)
+		// more IDs than fit in a millisecond, then a later one
+		last := 0
+		for range 200 {
+			id := generator.NewID()
+			if id <= last {
+				t.Fatalf("expected an ID above %d, got %d", last, id)
+			}
+			last = id
 		}
 
-		_, err = storage.Update(originalName, "ToMaTo   ")
-		if err != ErrIngredientNameExists {
-			t.Errorf("expected %v, got %v", ErrIngredientNameExists, err)
+		clock.now = clock.now.Add(time.Second)
+		if id := generator.NewID(); id <= last {
+			t.Errorf("expected an ID above %d, got %d", last, id)
 		}
+	})
 
-		_, err = storage.Update(originalName, "")
-		if err != ErrIngredientNameCannotBeEmpty {
-			t.Errorf("expected %v, got %v", ErrIngredientNameCannotBeEmpty, err)
-		}
+	t.Run("IDs keep growing when the clock goes back", func(t *testing.T) {
+		generator := newGenerator(1)
+		first := generator.NewID()
 
-		_, err = storage.Update(originalName, "a")
-		if err != ErrIngredientNameIsTooShort {
-			t.Errorf("expected %v, got %v", ErrIngredientNameIsTooShort, err)
+		clock.now = clock.now.Add(-time.Minute)
+		if id := generator.NewID(); id <= first {
+			t.Errorf("expected an ID above %d, got %d", first, id)
 		}
+	})
 
-		_, err = storage.Update(originalName, "Super-Ultra-Mega-Long-Ingredient-Name-That-Goes-On-Forever")
-		if err != ErrIngredientNameIsTooLong {
-			t.Errorf("expected %v, got %v", ErrIngredientNameIsTooLong, err)
+	t.Run("nodes never share IDs", func(t *testing.T) {
+		ids := make(map[int]bool)
+		for _, node := range []int{0, 1, MaxIDNode} {
+			generator := newGenerator(node)
+			for range 100 {
+				id := generator.NewID()
+				if ids[id] {
+					t.Fatalf("ID %d generated twice", id)
+				}
+				ids[id] = true
+			}
 		}
+	})
 
-		_, err = storage.Update(originalName, "<!!tomato>")
-		if err != ErrIngredientNameContainsInvalidChars {
-			t.Errorf("expected %v, got %v", ErrIngredientNameContainsInvalidChars, err)
+	t.Run("IDs fit in a JSON number", func(t *testing.T) {
+		generator := newGenerator(MaxIDNode)
+		clock.now = time.Date(2094, 1, 1, 0, 0, 0, 0, time.UTC)
+		if id := generator.NewID(); id <= 0 || id >= 1<<53 {
+			t.Errorf("expected an ID between 0 and 2^53, got %d", id)
 		}
 	})
 
-	t.Run("updating a different existing ingredient name", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		storage.Create("tomato")
-		storage.Create("basil")
-
-		_, err := storage.Update("tomato", "basil")
-		if err != ErrIngredientNameExists {
-			t.Errorf("expected %v, got %v", ErrIngredientNameExists, err)
+	t.Run("nodes out of range", func(t *testing.T) {
+		for _, node := range []int{-1, MaxIDNode + 1} {
+			if _, err := NewTimeOrderedIDGenerator(node); err != ErrIDNodeOutOfRange {
+				t.Errorf("expected %v for node %d, got %v", ErrIDNodeOutOfRange, node, err)
+			}
 		}
 	})
 }
 
-func TestListIngredients(t *testing.T) {
-	t.Run("list returns valid count and names", func(t *testing.T) {
-		storage := NewMemoryStorage()
+func TestMemoryStorageBatchCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	storage := NewMemoryStorage()
+	events := storage.Watch(t.Context())
+	names := []string{"sal", "leche", "pimienta"}
+
+	// the batch of CreateMany, cancelled while its second item is applied
+	storage.mu.Lock()
+	applied := 0
+	err := storage.applyBatch(func() error {
+		return runBatch(ctx, len(names), func(i int) error {
+			applied++
+			if i == 1 {
+				cancel()
+			}
+			_, err := storage.createLocked(ctx, names[i])
+			return err
+		})
+	})
+	storage.mu.Unlock()
 
-		storage.Create("tomato")
-		storage.Create("basil")
-		storage.Create("cheese")
-		expectedItems := 3
-		expectedNames := []string{"tomato", "basil", "cheese"}
+	if err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if applied != 2 {
+		t.Errorf("expected the batch to stop after 2 items, applied %d", applied)
+	}
 
-		results, _ := storage.List()
+	ingredients, _ := storage.List(t.Context())
+	if len(ingredients) != 0 || storage.nextID != 1 || len(storage.revisions) != 0 {
+		t.Errorf("expected the whole batch to be rolled back, got %v", ingredients)
+	}
+	select {
+	case event := <-events:
+		t.Errorf("expected no event for a cancelled batch, got %+v", event)
+	default:
+	}
+}
 
-		if len(results) != expectedItems {
-			t.Errorf("expected %d ingredients, got %d", expectedItems, len(results))
-		}
+const benchmarkIngredientCount = 100_000
 
-		// build a map of resulted ingredient names
-		foundNames := make(map[string]bool)
-		for _, ingredient := range results {
-			foundNames[ingredient.Name] = true
-		}
+func newBenchmarkMemoryStorage(b *testing.B) *MemoryStorage {
+	b.Helper()
 
-		// check each expected name exists
-		for _, expectedName := range expectedNames {
-			if !foundNames[expectedName] {
-				t.Errorf("expected ingredient %q not found", expectedName)
-			}
+	storage := NewMemoryStorage()
+	for i := range benchmarkIngredientCount {
+		if _, err := storage.Create(b.Context(), fmt.Sprintf("ingredient %d", i)); err != nil {
+			b.Fatalf("unexpected error: %v", err)
 		}
-	})
-
-	t.Run("list returns valid IDs", func(t *testing.T) {
-		storage := NewMemoryStorage()
+	}
 
-		storage.Create("tomato")
-		storage.Create("basil")
-		storage.Create("cheese")
-		expectedIDs := []int{1, 2, 3}
+	return storage
+}
 
-		results, _ := storage.List()
+func BenchmarkMemoryStorageGetByName(b *testing.B) {
+	storage := newBenchmarkMemoryStorage(b)
+	name := fmt.Sprintf("Ingredient %d", benchmarkIngredientCount/2)
 
-		// build a map of resulted ingredient IDs
-		foundIDs := make(map[int]bool)
-		for _, ingredient := range results {
-			foundIDs[ingredient.ID] = true
+	for b.Loop() {
+		if _, err := storage.GetByName(b.Context(), name); err != nil {
+			b.Fatalf("unexpected error: %v", err)
 		}
+	}
+}
 
-		// check each expected ID exists
-		for _, expectedID := range expectedIDs {
-			if !foundIDs[expectedID] {
-				t.Errorf("expected ingredient %d not found", expectedID)
+// BenchmarkMemoryStorageLinearScan is the lookup MemoryStorage did before it
+// indexed names, kept as a baseline for BenchmarkMemoryStorageGetByName.
+func BenchmarkMemoryStorageLinearScan(b *testing.B) {
+	storage := newBenchmarkMemoryStorage(b)
+	name := fmt.Sprintf("Ingredient %d", benchmarkIngredientCount/2)
+
+	for b.Loop() {
+		var found *models.Ingredient
+		for _, ingredient := range storage.ingredients {
+			if ingredient.HasName(name) {
+				found = ingredient
+				break
 			}
 		}
-	})
+		if found == nil {
+			b.Fatal("ingredient not found")
+		}
+	}
+}
 
-	t.Run("list returns valid timestamps", func(t *testing.T) {
-		storage := NewMemoryStorage()
+func BenchmarkMemoryStorageCreate(b *testing.B) {
+	storage := newBenchmarkMemoryStorage(b)
 
-		storage.Create("tomato")
-		storage.Create("basil")
-		storage.Create("cheese")
+	i := 0
+	for b.Loop() {
+		if _, err := storage.Create(b.Context(), fmt.Sprintf("new ingredient %d", i)); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		i++
+	}
+}
 
-		results, _ := storage.List()
+func BenchmarkMemoryStorageUpdate(b *testing.B) {
+	storage := newBenchmarkMemoryStorage(b)
+	names := [2]string{"ingredient 0", "renamed ingredient"}
 
-		// check all timestamps are not zero
-		for _, ingredient := range results {
-			if ingredient.CreatedAt.IsZero() {
-				t.Errorf("ingredient %q CreatedAt value is zero", ingredient.Name)
-			}
-			if ingredient.UpdatedAt.IsZero() {
-				t.Errorf("ingredient %q UpdatedAt value is zero", ingredient.Name)
-			}
+	i := 0
+	for b.Loop() {
+		if _, err := storage.Update(b.Context(), names[i%2], names[(i+1)%2], AnyVersion); err != nil {
+			b.Fatalf("unexpected error: %v", err)
 		}
+		i++
+	}
+}
 
-	})
-
-	t.Run("empty storage returns empty slice", func(t *testing.T) {
-		storage := NewMemoryStorage()
-		results, _ := storage.List()
+func BenchmarkMemoryStorageDelete(b *testing.B) {
+	storage := newBenchmarkMemoryStorage(b)
 
-		if len(results) != 0 {
-			t.Errorf("expected empty storage, got %v", results)
+	i := 0
+	for b.Loop() {
+		name := fmt.Sprintf("ingredient %d", i%benchmarkIngredientCount)
+		if i >= benchmarkIngredientCount {
+			name = fmt.Sprintf("ingredient %d", i)
+			storage.Create(b.Context(), name)
 		}
-
-		if results == nil {
-			t.Errorf("expected non-nil slice, got %v", results)
+		if err := storage.Delete(b.Context(), name, AnyVersion); err != nil {
+			b.Fatalf("unexpected error: %v", err)
 		}
-	})
+		i++
+	}
 }
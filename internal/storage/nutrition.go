@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+const (
+	// NutritionMaxCaloriesPer100g is the energy of pure fat, the densest food there is.
+	NutritionMaxCaloriesPer100g = 900
+	NutritionMaxGramsPer100g    = 100
+	NutritionMaxSodiumMgPer100g = 100000
+)
+
+var (
+	ErrNutritionValueIsNegative     = errors.New("nutrition values cannot be negative")
+	ErrNutritionCaloriesAreTooHigh  = fmt.Errorf("calories cannot exceed %d per 100g", NutritionMaxCaloriesPer100g)
+	ErrNutritionNutrientsExceedMass = fmt.Errorf("protein, fat, carbs and fiber cannot add up to more than %dg per 100g", NutritionMaxGramsPer100g)
+	ErrNutritionSugarExceedsCarbs   = errors.New("sugar cannot exceed total carbs")
+	ErrNutritionSodiumIsTooHigh     = fmt.Errorf("sodium cannot exceed %dmg per 100g", NutritionMaxSodiumMgPer100g)
+)
+
+// ValidateNutritionFacts checks that the given values are physically possible
+// for 100 grams of an ingredient.
+func ValidateNutritionFacts(facts models.NutritionFacts) error {
+	values := []float64{
+		facts.CaloriesPer100g,
+		facts.ProteinPer100g,
+		facts.FatPer100g,
+		facts.CarbsPer100g,
+		facts.FiberPer100g,
+		facts.SugarPer100g,
+		facts.SodiumMgPer100g,
+	}
+	for _, value := range values {
+		if value < 0 {
+			return ErrNutritionValueIsNegative
+		}
+	}
+
+	if facts.CaloriesPer100g > NutritionMaxCaloriesPer100g {
+		return ErrNutritionCaloriesAreTooHigh
+	}
+
+	if facts.ProteinPer100g+facts.FatPer100g+facts.CarbsPer100g+facts.FiberPer100g > NutritionMaxGramsPer100g {
+		return ErrNutritionNutrientsExceedMass
+	}
+
+	if facts.SugarPer100g > facts.CarbsPer100g {
+		return ErrNutritionSugarExceedsCarbs
+	}
+
+	if facts.SodiumMgPer100g > NutritionMaxSodiumMgPer100g {
+		return ErrNutritionSodiumIsTooHigh
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+func TestValidateNutritionFacts(t *testing.T) {
+	testCases := []struct {
+		name        string
+		facts       models.NutritionFacts
+		expectedErr error
+	}{
+		{"all zero", models.NutritionFacts{}, nil},
+		{"regular values", models.NutritionFacts{CaloriesPer100g: 165, ProteinPer100g: 31, FatPer100g: 3.6, SodiumMgPer100g: 74}, nil},
+		{"pure fat", models.NutritionFacts{CaloriesPer100g: 900, FatPer100g: 100}, nil},
+		{"salt sodium", models.NutritionFacts{SodiumMgPer100g: 38758}, nil},
+		{"negative value", models.NutritionFacts{ProteinPer100g: -1}, ErrNutritionValueIsNegative},
+		{"too many calories", models.NutritionFacts{CaloriesPer100g: 901}, ErrNutritionCaloriesAreTooHigh},
+		{"nutrients exceed mass", models.NutritionFacts{ProteinPer100g: 50, CarbsPer100g: 60}, ErrNutritionNutrientsExceedMass},
+		{"sugar exceeds carbs", models.NutritionFacts{CarbsPer100g: 10, SugarPer100g: 12}, ErrNutritionSugarExceedsCarbs},
+		{"too much sodium", models.NutritionFacts{SodiumMgPer100g: 100001}, ErrNutritionSodiumIsTooHigh},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateNutritionFacts(tc.facts)
+			if err != tc.expectedErr {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
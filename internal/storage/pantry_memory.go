@@ -0,0 +1,266 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/units"
+)
+
+const (
+	PantryUnitMaxLength = 16
+	PantryQuantityMax   = 100000
+)
+
+// pantryEpsilon absorbs floating point noise when consuming across batches,
+// so a batch left with a few nanograms is removed rather than kept around.
+const pantryEpsilon = 1e-9
+
+var (
+	ErrPantryIngredientInvalidID      = errors.New("pantry item must reference an existing ingredient")
+	ErrPantryItemNotFound             = errors.New("pantry item not found")
+	ErrPantryIngredientNotStocked     = errors.New("ingredient is not in the pantry")
+	ErrPantryInsufficientStock        = errors.New("not enough of the ingredient in the pantry")
+	ErrPantryQuantityInvalid          = errors.New("pantry quantity must be greater than zero")
+	ErrPantryQuantityIsNegative       = errors.New("pantry quantity cannot be negative")
+	ErrPantryQuantityIsTooHigh        = fmt.Errorf("pantry quantity cannot exceed %d", PantryQuantityMax)
+	ErrPantryUnitIsTooLong            = fmt.Errorf("pantry unit cannot exceed %d characters long", PantryUnitMaxLength)
+	ErrPantryBestBeforeBeforePurchase = errors.New("best-before date cannot be earlier than the purchase date")
+)
+
+// PantryMemoryStorage provides in-memory storage for pantry inventory. Stored
+// items are never handed out or changed in place: callers get copies, and
+// changes replace the stored item.
+type PantryMemoryStorage struct {
+	mu     sync.RWMutex
+	items  map[int]*models.PantryItem
+	nextID int
+}
+
+// NewPantryMemoryStorage creates a new in-memory pantry storage instance.
+func NewPantryMemoryStorage() *PantryMemoryStorage {
+	return &PantryMemoryStorage{
+		items:  make(map[int]*models.PantryItem),
+		nextID: 1,
+	}
+}
+
+// Stock adds a new batch of an ingredient to the pantry. A zero purchase date
+// means it was bought today.
+func (s *PantryMemoryStorage) Stock(ingredientID int, quantity float64, unit string, purchasedOn time.Time, bestBefore *time.Time) (*models.PantryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ingredientID <= 0 {
+		return nil, ErrPantryIngredientInvalidID
+	}
+
+	if quantity <= 0 {
+		return nil, ErrPantryQuantityInvalid
+	}
+	if quantity > PantryQuantityMax {
+		return nil, ErrPantryQuantityIsTooHigh
+	}
+
+	normalizedUnit, err := validatePantryUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+
+	if purchasedOn.IsZero() {
+		purchasedOn = time.Now()
+	}
+	purchasedOn = truncateToDay(purchasedOn)
+
+	if bestBefore != nil {
+		day := truncateToDay(*bestBefore)
+		if day.Before(purchasedOn) {
+			return nil, ErrPantryBestBeforeBeforePurchase
+		}
+		bestBefore = &day
+	}
+
+	item := models.NewPantryItem(s.nextID, ingredientID, quantity, normalizedUnit, purchasedOn, bestBefore)
+	s.items[s.nextID] = item
+	s.nextID++
+
+	return item.Clone(), nil
+}
+
+// Consume takes the given quantity of an ingredient out of the pantry, using
+// up the batches closest to their best-before date first. The conversion of
+// the ingredient is used when batches were stocked in a different unit. If
+// there is not enough in stock, nothing is consumed. It returns the batches
+// of the ingredient still left.
+func (s *PantryMemoryStorage) Consume(ingredientID int, quantity float64, unit string, conversion models.UnitConversion) ([]*models.PantryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if quantity <= 0 {
+		return nil, ErrPantryQuantityInvalid
+	}
+
+	normalizedUnit, err := validatePantryUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+
+	batches := s.itemsForIngredient(ingredientID)
+	if len(batches) == 0 {
+		return nil, ErrPantryIngredientNotStocked
+	}
+
+	// work out how much each batch holds in the requested unit before
+	// touching any of them
+	available := make([]float64, len(batches))
+	total := 0.0
+	for i, batch := range batches {
+		amount, err := units.Convert(batch.Quantity, batch.Unit, normalizedUnit, conversion)
+		if err != nil {
+			return nil, err
+		}
+		available[i] = amount
+		total += amount
+	}
+	if total+pantryEpsilon < quantity {
+		return nil, ErrPantryInsufficientStock
+	}
+
+	now := time.Now()
+	remaining := quantity
+	for i, batch := range batches {
+		if remaining <= pantryEpsilon {
+			break
+		}
+
+		taken := min(available[i], remaining)
+		remaining -= taken
+
+		updated := batch.Clone()
+		updated.Quantity -= batch.Quantity * taken / available[i]
+		updated.UpdatedAt = now
+
+		if updated.Quantity <= pantryEpsilon {
+			delete(s.items, batch.ID)
+		} else {
+			s.items[batch.ID] = updated
+		}
+	}
+
+	return clonePantryItems(s.itemsForIngredient(ingredientID)), nil
+}
+
+// Adjust sets the exact quantity of a pantry item, for example after checking
+// what is really left in the fridge. Adjusting an item to zero removes it.
+func (s *PantryMemoryStorage) Adjust(id int, quantity float64) (*models.PantryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if quantity < 0 {
+		return nil, ErrPantryQuantityIsNegative
+	}
+	if quantity > PantryQuantityMax {
+		return nil, ErrPantryQuantityIsTooHigh
+	}
+
+	item, ok := s.items[id]
+	if !ok {
+		return nil, ErrPantryItemNotFound
+	}
+
+	updated := item.Clone()
+	updated.Quantity = quantity
+	updated.UpdatedAt = time.Now()
+
+	if quantity == 0 {
+		delete(s.items, id)
+	} else {
+		s.items[id] = updated
+	}
+
+	return updated.Clone(), nil
+}
+
+// List returns every pantry item, the ones closest to their best-before date
+// first. Items without a best-before date come last.
+func (s *PantryMemoryStorage) List() ([]*models.PantryItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]*models.PantryItem, 0, len(s.items))
+	for _, item := range s.items {
+		results = append(results, item.Clone())
+	}
+	sortPantryItems(results)
+
+	return results, nil
+}
+
+// PantryItemsExpiringBy returns the items that reach their best-before date on
+// or before the given date, keeping their order.
+func PantryItemsExpiringBy(items []*models.PantryItem, date time.Time) []*models.PantryItem {
+	deadline := truncateToDay(date)
+
+	results := make([]*models.PantryItem, 0)
+	for _, item := range items {
+		if item.ExpiresBy(deadline) {
+			results = append(results, item)
+		}
+	}
+	return results
+}
+
+func (s *PantryMemoryStorage) itemsForIngredient(ingredientID int) []*models.PantryItem {
+	results := make([]*models.PantryItem, 0)
+	for _, item := range s.items {
+		if item.IngredientID == ingredientID {
+			results = append(results, item)
+		}
+	}
+	sortPantryItems(results)
+	return results
+}
+
+// clonePantryItems returns copies of the given items, in the same order.
+func clonePantryItems(items []*models.PantryItem) []*models.PantryItem {
+	clones := make([]*models.PantryItem, len(items))
+	for i, item := range items {
+		clones[i] = item.Clone()
+	}
+	return clones
+}
+
+func sortPantryItems(items []*models.PantryItem) {
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if (a.BestBefore == nil) != (b.BestBefore == nil) {
+			return a.BestBefore != nil
+		}
+		if a.BestBefore != nil && !a.BestBefore.Equal(*b.BestBefore) {
+			return a.BestBefore.Before(*b.BestBefore)
+		}
+		if !a.PurchasedOn.Equal(b.PurchasedOn) {
+			return a.PurchasedOn.Before(b.PurchasedOn)
+		}
+		return a.ID < b.ID
+	})
+}
+
+func validatePantryUnit(unit string) (string, error) {
+	normalizedUnit := strings.ToLower(strings.TrimSpace(unit))
+	if len(normalizedUnit) > PantryUnitMaxLength {
+		return "", ErrPantryUnitIsTooLong
+	}
+	return normalizedUnit, nil
+}
+
+// truncateToDay drops the time of day, since pantry dates are calendar days.
+func truncateToDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
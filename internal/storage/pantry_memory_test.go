@@ -0,0 +1,361 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/units"
+)
+
+func testDate(day int) time.Time {
+	return time.Date(2025, 3, day, 0, 0, 0, 0, time.UTC)
+}
+
+func testDatePtr(day int) *time.Time {
+	date := testDate(day)
+	return &date
+}
+
+func TestNewPantryMemoryStorage(t *testing.T) {
+	storage := NewPantryMemoryStorage()
+	if storage == nil {
+		t.Fatal("pantry memory storage created is nil")
+	}
+
+	if storage.items == nil {
+		t.Error("items map is nil")
+	}
+
+	if storage.nextID != 1 {
+		t.Errorf("expected ID to be 1, got %d", storage.nextID)
+	}
+}
+
+func TestStockPantry(t *testing.T) {
+	t.Run("successful stock", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		item, err := storage.Stock(1, 2, " L ", time.Date(2025, 3, 1, 18, 30, 0, 0, time.UTC), testDatePtr(8))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if item.ID != 1 || item.IngredientID != 1 {
+			t.Errorf("expected ID 1 and ingredient ID 1, got %d and %d", item.ID, item.IngredientID)
+		}
+
+		if item.Unit != "l" {
+			t.Errorf("expected normalized unit 'l', got %q", item.Unit)
+		}
+
+		if !item.PurchasedOn.Equal(testDate(1)) {
+			t.Errorf("expected purchase date truncated to the day, got %v", item.PurchasedOn)
+		}
+	})
+
+	t.Run("purchase date defaults to today", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		item, err := storage.Stock(1, 6, "", time.Time{}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !item.PurchasedOn.Equal(truncateToDay(time.Now())) {
+			t.Errorf("expected purchase date to be today, got %v", item.PurchasedOn)
+		}
+
+		if item.BestBefore != nil {
+			t.Errorf("expected no best-before date, got %v", item.BestBefore)
+		}
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		testCases := []struct {
+			name         string
+			ingredientID int
+			quantity     float64
+			unit         string
+			bestBefore   *time.Time
+			expected     error
+		}{
+			{"invalid ingredient", 0, 1, "g", nil, ErrPantryIngredientInvalidID},
+			{"zero quantity", 1, 0, "g", nil, ErrPantryQuantityInvalid},
+			{"quantity too high", 1, PantryQuantityMax + 1, "g", nil, ErrPantryQuantityIsTooHigh},
+			{"unit too long", 1, 1, "a very long unit name", nil, ErrPantryUnitIsTooLong},
+			{"best-before before purchase", 1, 1, "g", testDatePtr(1), ErrPantryBestBeforeBeforePurchase},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				storage := NewPantryMemoryStorage()
+				_, err := storage.Stock(tc.ingredientID, tc.quantity, tc.unit, testDate(2), tc.bestBefore)
+				if err != tc.expected {
+					t.Errorf("expected %v, got %v", tc.expected, err)
+				}
+
+				items, _ := storage.List()
+				if len(items) != 0 {
+					t.Errorf("expected nothing stocked, got %d items", len(items))
+				}
+			})
+		}
+	})
+}
+
+func TestConsumePantry(t *testing.T) {
+	t.Run("consumes the batch closest to expiry first", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		storage.Stock(1, 1, "l", testDate(1), testDatePtr(20))
+		storage.Stock(1, 1, "l", testDate(2), testDatePtr(10))
+
+		left, err := storage.Consume(1, 1.5, "l", models.UnitConversion{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(left) != 1 {
+			t.Fatalf("expected 1 batch left, got %d", len(left))
+		}
+
+		if left[0].ID != 1 || math.Abs(left[0].Quantity-0.5) > 1e-9 {
+			t.Errorf("expected 0.5 l left in batch 1, got %v l in batch %d", left[0].Quantity, left[0].ID)
+		}
+	})
+
+	t.Run("converts between units", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		storage.Stock(1, 1, "kg", testDate(1), nil)
+
+		left, err := storage.Consume(1, 250, "g", models.UnitConversion{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if math.Abs(left[0].Quantity-0.75) > 1e-9 || left[0].Unit != "kg" {
+			t.Errorf("expected 0.75 kg left, got %v %s", left[0].Quantity, left[0].Unit)
+		}
+	})
+
+	t.Run("consuming everything removes the batches", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		storage.Stock(1, 3, "", testDate(1), nil)
+		storage.Stock(1, 3, "", testDate(2), nil)
+
+		left, err := storage.Consume(1, 6, "", models.UnitConversion{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(left) != 0 {
+			t.Errorf("expected no batches left, got %d", len(left))
+		}
+	})
+
+	t.Run("insufficient stock leaves the pantry untouched", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		storage.Stock(1, 200, "g", testDate(1), nil)
+
+		_, err := storage.Consume(1, 1, "kg", models.UnitConversion{})
+		if err != ErrPantryInsufficientStock {
+			t.Errorf("expected %v, got %v", ErrPantryInsufficientStock, err)
+		}
+
+		items, _ := storage.List()
+		if items[0].Quantity != 200 {
+			t.Errorf("expected 200 g still in stock, got %v", items[0].Quantity)
+		}
+	})
+
+	t.Run("ingredient not stocked", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		storage.Stock(1, 200, "g", testDate(1), nil)
+
+		_, err := storage.Consume(2, 1, "g", models.UnitConversion{})
+		if err != ErrPantryIngredientNotStocked {
+			t.Errorf("expected %v, got %v", ErrPantryIngredientNotStocked, err)
+		}
+	})
+
+	t.Run("incompatible units", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		storage.Stock(1, 6, "", testDate(1), nil)
+
+		_, err := storage.Consume(1, 100, "g", models.UnitConversion{})
+		if err != units.ErrPieceWeightRequired {
+			t.Errorf("expected %v, got %v", units.ErrPieceWeightRequired, err)
+		}
+	})
+
+	t.Run("invalid quantity", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		storage.Stock(1, 6, "", testDate(1), nil)
+
+		_, err := storage.Consume(1, -1, "", models.UnitConversion{})
+		if err != ErrPantryQuantityInvalid {
+			t.Errorf("expected %v, got %v", ErrPantryQuantityInvalid, err)
+		}
+	})
+}
+
+func TestAdjustPantry(t *testing.T) {
+	t.Run("successful adjust", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		stocked, _ := storage.Stock(1, 6, "", testDate(1), nil)
+		originalUpdatedAt := stocked.UpdatedAt
+
+		time.Sleep(1 * time.Millisecond)
+		item, err := storage.Adjust(stocked.ID, 4)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if item.Quantity != 4 {
+			t.Errorf("expected quantity 4, got %v", item.Quantity)
+		}
+
+		if !item.UpdatedAt.After(originalUpdatedAt) {
+			t.Error("update date should have been refreshed")
+		}
+	})
+
+	t.Run("adjusting to zero removes the item", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		stocked, _ := storage.Stock(1, 6, "", testDate(1), nil)
+
+		if _, err := storage.Adjust(stocked.ID, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		items, _ := storage.List()
+		if len(items) != 0 {
+			t.Errorf("expected empty pantry, got %d items", len(items))
+		}
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		stocked, _ := storage.Stock(1, 6, "", testDate(1), nil)
+
+		if _, err := storage.Adjust(stocked.ID, -1); err != ErrPantryQuantityIsNegative {
+			t.Errorf("expected %v, got %v", ErrPantryQuantityIsNegative, err)
+		}
+
+		if _, err := storage.Adjust(stocked.ID, PantryQuantityMax+1); err != ErrPantryQuantityIsTooHigh {
+			t.Errorf("expected %v, got %v", ErrPantryQuantityIsTooHigh, err)
+		}
+
+		if _, err := storage.Adjust(42, 1); err != ErrPantryItemNotFound {
+			t.Errorf("expected %v, got %v", ErrPantryItemNotFound, err)
+		}
+	})
+}
+
+func TestListPantry(t *testing.T) {
+	t.Run("list is ordered by best-before date", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		storage.Stock(1, 1, "kg", testDate(1), nil)
+		storage.Stock(2, 1, "l", testDate(1), testDatePtr(15))
+		storage.Stock(3, 1, "g", testDate(1), testDatePtr(5))
+
+		items, err := storage.List()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := []int{3, 2, 1}
+		for i, item := range items {
+			if item.IngredientID != expected[i] {
+				t.Errorf("expected ingredient %d at position %d, got %d", expected[i], i, item.IngredientID)
+			}
+		}
+	})
+
+	t.Run("empty storage returns empty slice", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		items, err := storage.List()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if items == nil || len(items) != 0 {
+			t.Errorf("expected empty slice, got %v", items)
+		}
+	})
+}
+
+func TestPantryItemsExpiringBy(t *testing.T) {
+	storage := NewPantryMemoryStorage()
+	storage.Stock(1, 1, "kg", testDate(1), nil)
+	storage.Stock(2, 1, "l", testDate(1), testDatePtr(3))
+	storage.Stock(3, 1, "g", testDate(1), testDatePtr(5))
+	storage.Stock(4, 1, "g", testDate(1), testDatePtr(6))
+
+	items, _ := storage.List()
+	expiring := PantryItemsExpiringBy(items, time.Date(2025, 3, 5, 21, 0, 0, 0, time.UTC))
+
+	if len(expiring) != 2 {
+		t.Fatalf("expected 2 expiring items, got %d", len(expiring))
+	}
+
+	if expiring[0].IngredientID != 2 || expiring[1].IngredientID != 3 {
+		t.Errorf("expected ingredients 2 and 3, got %d and %d", expiring[0].IngredientID, expiring[1].IngredientID)
+	}
+}
+
+func TestPantryMemoryStorageConcurrentAccess(t *testing.T) {
+	t.Run("changing returned items leaves the storage untouched", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		stocked, _ := storage.Stock(1, 6, "", testDate(1), testDatePtr(8))
+		stocked.Quantity = 0
+
+		adjusted, _ := storage.Adjust(stocked.ID, 5)
+		adjusted.Quantity = 0
+
+		left, _ := storage.Consume(1, 1, "", models.UnitConversion{})
+		*left[0].BestBefore = testDate(2)
+
+		items, _ := storage.List()
+		if items[0].Quantity != 4 || !items[0].BestBefore.Equal(testDate(8)) {
+			t.Errorf("expected the stored item to be untouched, got %+v", items[0])
+		}
+		items[0].Quantity = 0
+
+		if items, _ := storage.List(); items[0].Quantity != 4 {
+			t.Errorf("expected quantity 4, got %v", items[0].Quantity)
+		}
+	})
+
+	t.Run("reading while others adjust and consume", func(t *testing.T) {
+		storage := NewPantryMemoryStorage()
+		stocked, _ := storage.Stock(1, 1000, "g", testDate(1), nil)
+
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(3)
+			go func() {
+				defer wg.Done()
+				if _, err := storage.Adjust(stocked.ID, float64(500+i)); err != nil && err != ErrPantryItemNotFound {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				if _, err := storage.Consume(1, 1, "g", models.UnitConversion{}); err != nil && err != ErrPantryIngredientNotStocked {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				// reading every field races with a change made in place
+				items, _ := storage.List()
+				for _, item := range items {
+					_ = fmt.Sprint(*item)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
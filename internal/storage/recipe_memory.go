@@ -0,0 +1,339 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+const (
+	RecipeTitleMaxLength     = 80
+	RecipeTitleMinLength     = 3
+	RecipeServingsMax        = 100
+	RecipeServingsMin        = 1
+	RecipeStepMaxLength      = 1000
+	RecipeUnitMaxLength      = 16
+	RecipeMaxStepCount       = 100
+	RecipeMaxIngredientCount = 100
+)
+
+var (
+	recipeTitleRegex = regexp.MustCompile(`^[a-zA-Z0-9\s'\-,À-ÿ]+$`)
+
+	ErrRecipeTitleCannotBeEmpty        = errors.New("recipe title cannot be empty")
+	ErrRecipeTitleContainsInvalidChars = errors.New("recipe title contains one or more invalid characters")
+	ErrRecipeTitleExists               = errors.New("recipe title already exists")
+	ErrRecipeTitleIsTooLong            = fmt.Errorf("recipe title cannot exceed %d characters long", RecipeTitleMaxLength)
+	ErrRecipeTitleIsTooShort           = fmt.Errorf("recipe title must be at least %d characters long", RecipeTitleMinLength)
+	ErrRecipeNotFound                  = errors.New("recipe not found")
+	ErrRecipeServingsOutOfRange        = fmt.Errorf("recipe servings must be between %d and %d", RecipeServingsMin, RecipeServingsMax)
+	ErrRecipeStepsCannotBeEmpty        = errors.New("recipe must have at least one step")
+	ErrRecipeStepCannotBeEmpty         = errors.New("recipe step cannot be empty")
+	ErrRecipeStepIsTooLong             = fmt.Errorf("recipe step cannot exceed %d characters long", RecipeStepMaxLength)
+	ErrRecipeTooManySteps              = fmt.Errorf("recipe cannot have more than %d steps", RecipeMaxStepCount)
+	ErrRecipeIngredientsCannotBeEmpty  = errors.New("recipe must have at least one ingredient")
+	ErrRecipeTooManyIngredients        = fmt.Errorf("recipe cannot have more than %d ingredients", RecipeMaxIngredientCount)
+	ErrRecipeIngredientInvalidID       = errors.New("recipe ingredient must reference an existing ingredient")
+	ErrRecipeIngredientDuplicated      = errors.New("recipe ingredient is listed more than once")
+	ErrRecipeIngredientInvalidQuantity = errors.New("recipe ingredient quantity must be greater than zero")
+	ErrRecipeIngredientUnitIsTooLong   = fmt.Errorf("recipe ingredient unit cannot exceed %d characters long", RecipeUnitMaxLength)
+)
+
+// RecipeUpdate holds the recipe fields to change. Nil fields are left untouched.
+type RecipeUpdate struct {
+	Title       *string
+	Servings    *int
+	Steps       []string
+	Ingredients []models.RecipeIngredient
+}
+
+// RecipeMemoryStorage provides in-memory storage for recipes. Stored recipes
+// are never handed out or changed in place: callers get copies, and changes
+// replace the stored recipe, so callers may keep what they get while others
+// write.
+type RecipeMemoryStorage struct {
+	mu      sync.RWMutex
+	recipes map[int]*models.Recipe
+	nextID  int
+}
+
+// NewRecipeMemoryStorage creates a new in-memory recipe storage instance.
+func NewRecipeMemoryStorage() *RecipeMemoryStorage {
+	return &RecipeMemoryStorage{
+		recipes: make(map[int]*models.Recipe),
+		nextID:  1,
+	}
+}
+
+// Create adds a new recipe and returns it with an assigned ID.
+func (s *RecipeMemoryStorage) Create(title string, servings int, steps []string, ingredients []models.RecipeIngredient) (*models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	normalizedTitle, err := validateRecipeTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRecipeServings(servings); err != nil {
+		return nil, err
+	}
+
+	normalizedSteps, err := validateRecipeSteps(steps)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedIngredients, err := validateRecipeIngredients(ingredients)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.findByTitle(normalizedTitle) != nil {
+		return nil, ErrRecipeTitleExists
+	}
+
+	recipe := models.NewRecipe(s.nextID, normalizedTitle, servings, normalizedSteps, normalizedIngredients)
+	s.recipes[s.nextID] = recipe
+	s.nextID++
+
+	return recipe.Clone(), nil
+}
+
+// Delete removes the recipe with the given title.
+func (s *RecipeMemoryStorage) Delete(title string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	normalizedTitle, err := validateRecipeTitle(title)
+	if err != nil {
+		return err
+	}
+
+	targetRecipe := s.findByTitle(normalizedTitle)
+	if targetRecipe == nil {
+		return ErrRecipeNotFound
+	}
+
+	delete(s.recipes, targetRecipe.ID)
+
+	return nil
+}
+
+// Get returns the recipe with the given title.
+func (s *RecipeMemoryStorage) Get(title string) (*models.Recipe, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	normalizedTitle, err := validateRecipeTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	targetRecipe := s.findByTitle(normalizedTitle)
+	if targetRecipe == nil {
+		return nil, ErrRecipeNotFound
+	}
+
+	return targetRecipe.Clone(), nil
+}
+
+// List returns all recipes ordered by ID.
+func (s *RecipeMemoryStorage) List() ([]*models.Recipe, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]*models.Recipe, 0, len(s.recipes))
+	for _, recipe := range s.recipes {
+		results = append(results, recipe.Clone())
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+
+	return results, nil
+}
+
+// Update applies the given changes to the recipe with the given title.
+func (s *RecipeMemoryStorage) Update(title string, changes RecipeUpdate) (*models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	normalizedTitle, err := validateRecipeTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	// validate every change before touching the stored recipe
+	var normalizedNewTitle string
+	if changes.Title != nil {
+		normalizedNewTitle, err = validateRecipeTitle(*changes.Title)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if changes.Servings != nil {
+		if err := validateRecipeServings(*changes.Servings); err != nil {
+			return nil, err
+		}
+	}
+
+	var normalizedSteps []string
+	if changes.Steps != nil {
+		normalizedSteps, err = validateRecipeSteps(changes.Steps)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var normalizedIngredients []models.RecipeIngredient
+	if changes.Ingredients != nil {
+		normalizedIngredients, err = validateRecipeIngredients(changes.Ingredients)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	targetRecipe := s.findByTitle(normalizedTitle)
+	if targetRecipe == nil {
+		return nil, ErrRecipeNotFound
+	}
+
+	updated := targetRecipe.Clone()
+
+	if changes.Title != nil {
+		// renaming to a different casing of the same title is allowed
+		existing := s.findByTitle(normalizedNewTitle)
+		if existing != nil && existing.ID != targetRecipe.ID {
+			return nil, ErrRecipeTitleExists
+		}
+		updated.Title = normalizedNewTitle
+	}
+
+	if changes.Servings != nil {
+		updated.Servings = *changes.Servings
+	}
+
+	if normalizedSteps != nil {
+		updated.Steps = normalizedSteps
+	}
+
+	if normalizedIngredients != nil {
+		updated.Ingredients = normalizedIngredients
+	}
+
+	updated.UpdatedAt = time.Now()
+	s.recipes[updated.ID] = updated
+
+	return updated.Clone(), nil
+}
+
+func (s *RecipeMemoryStorage) findByTitle(title string) *models.Recipe {
+	for _, recipe := range s.recipes {
+		if strings.EqualFold(recipe.Title, title) {
+			return recipe
+		}
+	}
+	return nil
+}
+
+func validateRecipeTitle(title string) (string, error) {
+	// titles keep their casing, but whitespace is normalized
+	normalizedTitle := strings.Join(strings.Fields(title), " ")
+
+	if normalizedTitle == "" {
+		return "", ErrRecipeTitleCannotBeEmpty
+	}
+
+	if len(normalizedTitle) < RecipeTitleMinLength {
+		return "", ErrRecipeTitleIsTooShort
+	}
+
+	if len(normalizedTitle) > RecipeTitleMaxLength {
+		return "", ErrRecipeTitleIsTooLong
+	}
+
+	if !recipeTitleRegex.MatchString(normalizedTitle) {
+		return "", ErrRecipeTitleContainsInvalidChars
+	}
+
+	return normalizedTitle, nil
+}
+
+func validateRecipeServings(servings int) error {
+	if servings < RecipeServingsMin || servings > RecipeServingsMax {
+		return ErrRecipeServingsOutOfRange
+	}
+	return nil
+}
+
+func validateRecipeSteps(steps []string) ([]string, error) {
+	if len(steps) == 0 {
+		return nil, ErrRecipeStepsCannotBeEmpty
+	}
+
+	if len(steps) > RecipeMaxStepCount {
+		return nil, ErrRecipeTooManySteps
+	}
+
+	normalizedSteps := make([]string, 0, len(steps))
+	for _, step := range steps {
+		normalizedStep := strings.TrimSpace(step)
+		if normalizedStep == "" {
+			return nil, ErrRecipeStepCannotBeEmpty
+		}
+		if len(normalizedStep) > RecipeStepMaxLength {
+			return nil, ErrRecipeStepIsTooLong
+		}
+		normalizedSteps = append(normalizedSteps, normalizedStep)
+	}
+
+	return normalizedSteps, nil
+}
+
+func validateRecipeIngredients(ingredients []models.RecipeIngredient) ([]models.RecipeIngredient, error) {
+	if len(ingredients) == 0 {
+		return nil, ErrRecipeIngredientsCannotBeEmpty
+	}
+
+	if len(ingredients) > RecipeMaxIngredientCount {
+		return nil, ErrRecipeTooManyIngredients
+	}
+
+	seen := make(map[int]bool, len(ingredients))
+	normalizedIngredients := make([]models.RecipeIngredient, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		if ingredient.IngredientID <= 0 {
+			return nil, ErrRecipeIngredientInvalidID
+		}
+		if seen[ingredient.IngredientID] {
+			return nil, ErrRecipeIngredientDuplicated
+		}
+		seen[ingredient.IngredientID] = true
+
+		if ingredient.Quantity <= 0 {
+			return nil, ErrRecipeIngredientInvalidQuantity
+		}
+
+		normalizedUnit := strings.ToLower(strings.TrimSpace(ingredient.Unit))
+		if len(normalizedUnit) > RecipeUnitMaxLength {
+			return nil, ErrRecipeIngredientUnitIsTooLong
+		}
+
+		normalizedIngredients = append(normalizedIngredients, models.RecipeIngredient{
+			IngredientID: ingredient.IngredientID,
+			Quantity:     ingredient.Quantity,
+			Unit:         normalizedUnit,
+		})
+	}
+
+	return normalizedIngredients, nil
+}
@@ -0,0 +1,363 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+var (
+	testRecipeSteps       = []string{"boil the pasta", "mix with the sauce"}
+	testRecipeIngredients = []models.RecipeIngredient{
+		{IngredientID: 1, Quantity: 200, Unit: "g"},
+		{IngredientID: 2, Quantity: 1, Unit: "tbsp"},
+	}
+)
+
+func TestNewRecipeMemoryStorage(t *testing.T) {
+	storage := NewRecipeMemoryStorage()
+	if storage == nil {
+		t.Fatal("recipe memory storage created is nil")
+	}
+
+	if storage.recipes == nil {
+		t.Error("recipes map is nil")
+	}
+
+	if storage.nextID != 1 {
+		t.Errorf("expected ID to be 1, got %d", storage.nextID)
+	}
+}
+
+func TestCreateRecipe(t *testing.T) {
+	t.Run("successful creation", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+		recipe, err := storage.Create("Pasta al pesto", 2, testRecipeSteps, testRecipeIngredients)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if recipe.ID != 1 {
+			t.Errorf("expected ID 1, got %d", recipe.ID)
+		}
+
+		if recipe.Title != "Pasta al pesto" {
+			t.Errorf("expected title 'Pasta al pesto', got '%s'", recipe.Title)
+		}
+
+		if recipe.Servings != 2 {
+			t.Errorf("expected 2 servings, got %d", recipe.Servings)
+		}
+
+		if len(recipe.Steps) != 2 || len(recipe.Ingredients) != 2 {
+			t.Errorf("expected 2 steps and 2 ingredients, got %d and %d", len(recipe.Steps), len(recipe.Ingredients))
+		}
+
+		if recipe.CreatedAt.IsZero() {
+			t.Errorf("creation date should not be zero")
+		}
+	})
+
+	t.Run("normalizes title, steps and units", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+		recipe, err := storage.Create("  Pasta   al  pesto ", 2,
+			[]string{"  boil the pasta  "},
+			[]models.RecipeIngredient{{IngredientID: 1, Quantity: 200, Unit: " G "}},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if recipe.Title != "Pasta al pesto" {
+			t.Errorf("expected normalized title %q, got %q", "Pasta al pesto", recipe.Title)
+		}
+
+		if recipe.Steps[0] != "boil the pasta" {
+			t.Errorf("expected trimmed step, got %q", recipe.Steps[0])
+		}
+
+		if recipe.Ingredients[0].Unit != "g" {
+			t.Errorf("expected normalized unit %q, got %q", "g", recipe.Ingredients[0].Unit)
+		}
+	})
+
+	t.Run("case insensitive duplicates", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+
+		_, err := storage.Create("Pasta al pesto", 2, testRecipeSteps, testRecipeIngredients)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_, err = storage.Create(" PASTA AL PESTO ", 4, testRecipeSteps, testRecipeIngredients)
+		if err != ErrRecipeTitleExists {
+			t.Errorf("expected %v, got %v", ErrRecipeTitleExists, err)
+		}
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+
+		testCases := []struct {
+			name        string
+			title       string
+			servings    int
+			steps       []string
+			ingredients []models.RecipeIngredient
+			expectedErr error
+		}{
+			{"empty title", "", 2, testRecipeSteps, testRecipeIngredients, ErrRecipeTitleCannotBeEmpty},
+			{"title too short", "XD", 2, testRecipeSteps, testRecipeIngredients, ErrRecipeTitleIsTooShort},
+			{"title too long", strings.Repeat("a", RecipeTitleMaxLength+1), 2, testRecipeSteps, testRecipeIngredients, ErrRecipeTitleIsTooLong},
+			{"title with invalid chars", "pasta <script>", 2, testRecipeSteps, testRecipeIngredients, ErrRecipeTitleContainsInvalidChars},
+			{"zero servings", "pasta", 0, testRecipeSteps, testRecipeIngredients, ErrRecipeServingsOutOfRange},
+			{"too many servings", "pasta", RecipeServingsMax + 1, testRecipeSteps, testRecipeIngredients, ErrRecipeServingsOutOfRange},
+			{"no steps", "pasta", 2, nil, testRecipeIngredients, ErrRecipeStepsCannotBeEmpty},
+			{"blank step", "pasta", 2, []string{"boil", "   "}, testRecipeIngredients, ErrRecipeStepCannotBeEmpty},
+			{"no ingredients", "pasta", 2, testRecipeSteps, nil, ErrRecipeIngredientsCannotBeEmpty},
+			{"invalid ingredient ID", "pasta", 2, testRecipeSteps, []models.RecipeIngredient{{IngredientID: 0, Quantity: 1}}, ErrRecipeIngredientInvalidID},
+			{"zero quantity", "pasta", 2, testRecipeSteps, []models.RecipeIngredient{{IngredientID: 1, Quantity: 0}}, ErrRecipeIngredientInvalidQuantity},
+			{"negative quantity", "pasta", 2, testRecipeSteps, []models.RecipeIngredient{{IngredientID: 1, Quantity: -2}}, ErrRecipeIngredientInvalidQuantity},
+			{"duplicated ingredient", "pasta", 2, testRecipeSteps, []models.RecipeIngredient{{IngredientID: 1, Quantity: 1}, {IngredientID: 1, Quantity: 2}}, ErrRecipeIngredientDuplicated},
+			{"unit too long", "pasta", 2, testRecipeSteps, []models.RecipeIngredient{{IngredientID: 1, Quantity: 1, Unit: strings.Repeat("g", RecipeUnitMaxLength+1)}}, ErrRecipeIngredientUnitIsTooLong},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := storage.Create(tc.title, tc.servings, tc.steps, tc.ingredients)
+				if err != tc.expectedErr {
+					t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+				}
+			})
+		}
+
+		recipes, _ := storage.List()
+		if len(recipes) != 0 {
+			t.Errorf("expected no recipes to be stored, got %d", len(recipes))
+		}
+	})
+}
+
+func TestGetRecipe(t *testing.T) {
+	t.Run("case insensitive get", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+		storage.Create("Pasta al pesto", 2, testRecipeSteps, testRecipeIngredients)
+
+		recipe, err := storage.Get("  pasta AL pesto ")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if recipe.Title != "Pasta al pesto" {
+			t.Errorf("expected title %q, got %q", "Pasta al pesto", recipe.Title)
+		}
+	})
+
+	t.Run("recipe not found", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+
+		_, err := storage.Get("gazpacho")
+		if err != ErrRecipeNotFound {
+			t.Errorf("expected %v, got %v", ErrRecipeNotFound, err)
+		}
+	})
+}
+
+func TestDeleteRecipe(t *testing.T) {
+	t.Run("successful delete", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+		storage.Create("Pasta al pesto", 2, testRecipeSteps, testRecipeIngredients)
+
+		err := storage.Delete("pasta al pesto")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_, err = storage.Get("pasta al pesto")
+		if err != ErrRecipeNotFound {
+			t.Errorf("expected %v, got %v", ErrRecipeNotFound, err)
+		}
+	})
+
+	t.Run("recipe not found", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+
+		err := storage.Delete("gazpacho")
+		if err != ErrRecipeNotFound {
+			t.Errorf("expected %v, got %v", ErrRecipeNotFound, err)
+		}
+	})
+}
+
+func TestUpdateRecipe(t *testing.T) {
+	t.Run("successful partial update", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+		storage.Create("Pasta al pesto", 2, testRecipeSteps, testRecipeIngredients)
+
+		time.Sleep(5 * time.Millisecond)
+
+		servings := 4
+		recipe, err := storage.Update("pasta al pesto", RecipeUpdate{Servings: &servings})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if recipe.Servings != servings {
+			t.Errorf("expected %d servings, got %d", servings, recipe.Servings)
+		}
+
+		if recipe.Title != "Pasta al pesto" {
+			t.Errorf("expected title to be untouched, got %q", recipe.Title)
+		}
+
+		if len(recipe.Steps) != len(testRecipeSteps) {
+			t.Errorf("expected steps to be untouched, got %v", recipe.Steps)
+		}
+
+		if !recipe.UpdatedAt.After(recipe.CreatedAt) {
+			t.Errorf("UpdatedAt should be after CreatedAt")
+		}
+	})
+
+	t.Run("rename to a different casing of the same title", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+		storage.Create("pasta al pesto", 2, testRecipeSteps, testRecipeIngredients)
+
+		newTitle := "Pasta al Pesto"
+		recipe, err := storage.Update("pasta al pesto", RecipeUpdate{Title: &newTitle})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if recipe.Title != newTitle {
+			t.Errorf("expected title %q, got %q", newTitle, recipe.Title)
+		}
+	})
+
+	t.Run("rename to an existing title", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+		storage.Create("Pasta al pesto", 2, testRecipeSteps, testRecipeIngredients)
+		storage.Create("Gazpacho", 4, testRecipeSteps, testRecipeIngredients)
+
+		newTitle := "gazpacho"
+		_, err := storage.Update("pasta al pesto", RecipeUpdate{Title: &newTitle})
+		if err != ErrRecipeTitleExists {
+			t.Errorf("expected %v, got %v", ErrRecipeTitleExists, err)
+		}
+	})
+
+	t.Run("invalid changes leave the recipe untouched", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+		storage.Create("Pasta al pesto", 2, testRecipeSteps, testRecipeIngredients)
+
+		servings := 6
+		_, err := storage.Update("pasta al pesto", RecipeUpdate{
+			Servings: &servings,
+			Steps:    []string{},
+		})
+		if err != ErrRecipeStepsCannotBeEmpty {
+			t.Errorf("expected %v, got %v", ErrRecipeStepsCannotBeEmpty, err)
+		}
+
+		recipe, _ := storage.Get("pasta al pesto")
+		if recipe.Servings != 2 {
+			t.Errorf("expected servings to be untouched, got %d", recipe.Servings)
+		}
+	})
+
+	t.Run("recipe not found", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+
+		servings := 4
+		_, err := storage.Update("gazpacho", RecipeUpdate{Servings: &servings})
+		if err != ErrRecipeNotFound {
+			t.Errorf("expected %v, got %v", ErrRecipeNotFound, err)
+		}
+	})
+}
+
+func TestListRecipes(t *testing.T) {
+	t.Run("list is ordered by ID", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+		storage.Create("Pasta al pesto", 2, testRecipeSteps, testRecipeIngredients)
+		storage.Create("Gazpacho", 4, testRecipeSteps, testRecipeIngredients)
+		storage.Create("Tortilla de patatas", 4, testRecipeSteps, testRecipeIngredients)
+
+		results, _ := storage.List()
+		if len(results) != 3 {
+			t.Fatalf("expected 3 recipes, got %d", len(results))
+		}
+
+		for i, recipe := range results {
+			if recipe.ID != i+1 {
+				t.Errorf("expected recipe at position %d to have ID %d, got %d", i, i+1, recipe.ID)
+			}
+		}
+	})
+
+	t.Run("empty storage returns empty slice", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+		results, _ := storage.List()
+
+		if results == nil || len(results) != 0 {
+			t.Errorf("expected non-nil empty slice, got %v", results)
+		}
+	})
+}
+
+func TestRecipeMemoryStorageConcurrentAccess(t *testing.T) {
+	t.Run("changing returned recipes leaves the storage untouched", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+		created, _ := storage.Create("Pasta al pesto", 2, testRecipeSteps, testRecipeIngredients)
+		created.Steps[0] = "scribbled"
+
+		recipe, _ := storage.Get("Pasta al pesto")
+		recipe.Title = "scribbled"
+		recipe.Ingredients[0].Quantity = 0
+
+		recipes, _ := storage.List()
+		recipes[0].Servings = 0
+
+		stored, _ := storage.Get("Pasta al pesto")
+		if stored.Title != "Pasta al pesto" || stored.Servings != 2 || stored.Steps[0] != testRecipeSteps[0] || stored.Ingredients[0].Quantity != 200 {
+			t.Errorf("expected the stored recipe to be untouched, got %+v", stored)
+		}
+	})
+
+	t.Run("reading while others update", func(t *testing.T) {
+		storage := NewRecipeMemoryStorage()
+		storage.Create("Pasta al pesto", 2, testRecipeSteps, testRecipeIngredients)
+
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				servings := i + 1
+				steps := []string{fmt.Sprintf("step %d", i)}
+				if _, err := storage.Update("Pasta al pesto", RecipeUpdate{Servings: &servings, Steps: steps}); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				recipe, err := storage.Get("Pasta al pesto")
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				// reading every field races with an update changing it
+				// in place
+				_ = fmt.Sprint(*recipe)
+				recipes, _ := storage.List()
+				_ = fmt.Sprint(*recipes[0])
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// RecipeMatchMode controls how requested ingredients are combined when searching.
+type RecipeMatchMode string
+
+const (
+	// RecipeMatchAny returns recipes using at least one requested ingredient.
+	RecipeMatchAny RecipeMatchMode = "any"
+	// RecipeMatchAll returns recipes using every requested ingredient.
+	RecipeMatchAll RecipeMatchMode = "all"
+)
+
+var (
+	ErrRecipeSearchIngredientsCannotBeEmpty = errors.New("at least one ingredient is required to search recipes")
+	ErrRecipeSearchInvalidMatchMode         = errors.New("match mode must be either 'any' or 'all'")
+)
+
+// RecipeSearchQuery describes which recipes to look for.
+type RecipeSearchQuery struct {
+	Ingredients []string
+	Exclude     []string
+	Mode        RecipeMatchMode
+}
+
+// RecipeMatch is a recipe found by SearchRecipesByIngredient along with the
+// requested ingredient names it uses and the ones it lacks.
+type RecipeMatch struct {
+	Recipe  *models.Recipe
+	Matched []string
+	Missing []string
+}
+
+// SearchRecipesByIngredient finds recipes that use the requested ingredients,
+// ranked by how many of them they contain. Ties favour recipes with fewer
+// ingredients overall, since they are closer to what was asked for.
+func SearchRecipesByIngredient(ctx context.Context, recipes RecipeStorage, ingredients IngredientStorage, query RecipeSearchQuery) ([]RecipeMatch, error) {
+	mode := query.Mode
+	if mode == "" {
+		mode = RecipeMatchAny
+	}
+	if mode != RecipeMatchAny && mode != RecipeMatchAll {
+		return nil, ErrRecipeSearchInvalidMatchMode
+	}
+
+	requested := uniqueIngredientNames(query.Ingredients)
+	if len(requested) == 0 {
+		return nil, ErrRecipeSearchIngredientsCannotBeEmpty
+	}
+	excluded := uniqueIngredientNames(query.Exclude)
+
+	storedIngredients, err := ingredients.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idsByName := make(map[string]int, len(storedIngredients))
+	for _, ingredient := range storedIngredients {
+		idsByName[normalizeIngredientName(ingredient.Name)] = ingredient.ID
+		for _, alias := range ingredient.Aliases {
+			idsByName[normalizeIngredientName(alias.Name)] = ingredient.ID
+		}
+	}
+
+	storedRecipes, err := recipes.List()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RecipeMatch, 0)
+	for _, recipe := range storedRecipes {
+		used := make(map[int]bool, len(recipe.Ingredients))
+		for _, line := range recipe.Ingredients {
+			used[line.IngredientID] = true
+		}
+
+		if usesAnyIngredient(used, excluded, idsByName) {
+			continue
+		}
+
+		match := RecipeMatch{Recipe: recipe}
+		for _, name := range requested {
+			id, ok := idsByName[name]
+			if ok && used[id] {
+				match.Matched = append(match.Matched, name)
+			} else {
+				match.Missing = append(match.Missing, name)
+			}
+		}
+
+		if len(match.Matched) == 0 {
+			continue
+		}
+		if mode == RecipeMatchAll && len(match.Missing) > 0 {
+			continue
+		}
+		results = append(results, match)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if len(results[i].Matched) != len(results[j].Matched) {
+			return len(results[i].Matched) > len(results[j].Matched)
+		}
+		if len(results[i].Recipe.Ingredients) != len(results[j].Recipe.Ingredients) {
+			return len(results[i].Recipe.Ingredients) < len(results[j].Recipe.Ingredients)
+		}
+		return strings.ToLower(results[i].Recipe.Title) < strings.ToLower(results[j].Recipe.Title)
+	})
+
+	return results, nil
+}
+
+func usesAnyIngredient(used map[int]bool, names []string, idsByName map[string]int) bool {
+	for _, name := range names {
+		if id, ok := idsByName[name]; ok && used[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueIngredientNames normalizes the given names, dropping blanks and duplicates
+// while keeping their original order.
+func uniqueIngredientNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	results := make([]string, 0, len(names))
+	for _, name := range names {
+		normalizedName := normalizeIngredientName(name)
+		if normalizedName == "" || seen[normalizedName] {
+			continue
+		}
+		seen[normalizedName] = true
+		results = append(results, normalizedName)
+	}
+	return results
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// newSearchFixture stores a few ingredients and recipes to search through.
+func newSearchFixture(t *testing.T) (*RecipeMemoryStorage, *MemoryStorage) {
+	t.Helper()
+	ctx := t.Context()
+
+	ingredients := NewMemoryStorage()
+	ids := make(map[string]int)
+	for _, name := range []string{"pollo", "arroz", "tomate", "cebolla", "leche"} {
+		ingredient, err := ingredients.Create(ctx, name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids[name] = ingredient.ID
+	}
+
+	line := func(name string) models.RecipeIngredient {
+		return models.RecipeIngredient{IngredientID: ids[name], Quantity: 100, Unit: "g"}
+	}
+
+	recipes := NewRecipeMemoryStorage()
+	steps := []string{"cook everything"}
+	fixtures := []struct {
+		title       string
+		ingredients []models.RecipeIngredient
+	}{
+		{"Arroz con pollo", []models.RecipeIngredient{line("arroz"), line("pollo"), line("tomate"), line("cebolla")}},
+		{"Pollo al horno", []models.RecipeIngredient{line("pollo"), line("cebolla")}},
+		{"Arroz blanco", []models.RecipeIngredient{line("arroz")}},
+		{"Arroz con leche", []models.RecipeIngredient{line("arroz"), line("leche")}},
+		{"Pollo con arroz", []models.RecipeIngredient{line("pollo"), line("arroz")}},
+	}
+	for _, fixture := range fixtures {
+		if _, err := recipes.Create(fixture.title, 2, steps, fixture.ingredients); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	return recipes, ingredients
+}
+
+func matchedTitles(matches []RecipeMatch) []string {
+	titles := make([]string, 0, len(matches))
+	for _, match := range matches {
+		titles = append(titles, match.Recipe.Title)
+	}
+	return titles
+}
+
+func TestSearchRecipesByIngredient(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("any mode ranks by matched ingredients", func(t *testing.T) {
+		recipes, ingredients := newSearchFixture(t)
+
+		matches, err := SearchRecipesByIngredient(ctx, recipes, ingredients, RecipeSearchQuery{
+			Ingredients: []string{"pollo", "arroz"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := []string{"Pollo con arroz", "Arroz con pollo", "Arroz blanco", "Arroz con leche", "Pollo al horno"}
+		titles := matchedTitles(matches)
+		if len(titles) != len(expected) {
+			t.Fatalf("expected %v, got %v", expected, titles)
+		}
+		for i := range expected {
+			if titles[i] != expected[i] {
+				t.Errorf("expected %v, got %v", expected, titles)
+				break
+			}
+		}
+
+		if len(matches[0].Matched) != 2 || len(matches[0].Missing) != 0 {
+			t.Errorf("expected full match, got matched %v missing %v", matches[0].Matched, matches[0].Missing)
+		}
+		if len(matches[2].Missing) != 1 || matches[2].Missing[0] != "pollo" {
+			t.Errorf("expected pollo to be missing, got %v", matches[2].Missing)
+		}
+	})
+
+	t.Run("all mode requires every ingredient", func(t *testing.T) {
+		recipes, ingredients := newSearchFixture(t)
+
+		matches, err := SearchRecipesByIngredient(ctx, recipes, ingredients, RecipeSearchQuery{
+			Ingredients: []string{"pollo", "arroz"},
+			Mode:        RecipeMatchAll,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		titles := matchedTitles(matches)
+		if len(titles) != 2 || titles[0] != "Pollo con arroz" || titles[1] != "Arroz con pollo" {
+			t.Errorf("unexpected results %v", titles)
+		}
+	})
+
+	t.Run("excluded ingredients drop recipes", func(t *testing.T) {
+		recipes, ingredients := newSearchFixture(t)
+
+		matches, err := SearchRecipesByIngredient(ctx, recipes, ingredients, RecipeSearchQuery{
+			Ingredients: []string{"arroz"},
+			Exclude:     []string{"leche", "cebolla"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		titles := matchedTitles(matches)
+		if len(titles) != 2 || titles[0] != "Arroz blanco" || titles[1] != "Pollo con arroz" {
+			t.Errorf("unexpected results %v", titles)
+		}
+	})
+
+	t.Run("names are normalized and deduplicated", func(t *testing.T) {
+		recipes, ingredients := newSearchFixture(t)
+
+		matches, err := SearchRecipesByIngredient(ctx, recipes, ingredients, RecipeSearchQuery{
+			Ingredients: []string{"  POLLO ", "pollo", "Arroz"},
+			Mode:        RecipeMatchAll,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(matches) != 2 {
+			t.Fatalf("expected 2 results, got %v", matchedTitles(matches))
+		}
+		if len(matches[0].Matched) != 2 {
+			t.Errorf("expected 2 matched ingredients, got %v", matches[0].Matched)
+		}
+	})
+
+	t.Run("names resolve through aliases", func(t *testing.T) {
+		recipes, ingredients := newSearchFixture(t)
+		ingredients.AddAlias(ctx, "pollo", "chicken", "en", AnyVersion)
+
+		matches, err := SearchRecipesByIngredient(ctx, recipes, ingredients, RecipeSearchQuery{
+			Ingredients: []string{"chicken", "arroz"},
+			Mode:        RecipeMatchAll,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(matches) != 2 {
+			t.Errorf("expected 2 results, got %v", matchedTitles(matches))
+		}
+	})
+
+	t.Run("unknown ingredients match nothing", func(t *testing.T) {
+		recipes, ingredients := newSearchFixture(t)
+
+		matches, err := SearchRecipesByIngredient(ctx, recipes, ingredients, RecipeSearchQuery{
+			Ingredients: []string{"brotato"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(matches) != 0 {
+			t.Errorf("expected no results, got %v", matchedTitles(matches))
+		}
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		recipes, ingredients := newSearchFixture(t)
+
+		_, err := SearchRecipesByIngredient(ctx, recipes, ingredients, RecipeSearchQuery{Ingredients: []string{"  "}})
+		if err != ErrRecipeSearchIngredientsCannotBeEmpty {
+			t.Errorf("expected %v, got %v", ErrRecipeSearchIngredientsCannotBeEmpty, err)
+		}
+
+		_, err = SearchRecipesByIngredient(ctx, recipes, ingredients, RecipeSearchQuery{
+			Ingredients: []string{"pollo"},
+			Mode:        "some",
+		})
+		if err != ErrRecipeSearchInvalidMatchMode {
+			t.Errorf("expected %v, got %v", ErrRecipeSearchInvalidMatchMode, err)
+		}
+	})
+}
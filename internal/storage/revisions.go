@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+var (
+	ErrNothingToUndo = errors.New("there is no change left to undo")
+	ErrUndoConflict  = errors.New("ingredient has been changed again since, by another session")
+)
+
+type sessionKey struct{}
+
+// WithSession returns a copy of ctx carrying the ID of the client session on
+// whose behalf changes are made. Storages record it in the revision of every
+// change, and UndoLastChange only undoes changes made by the same session.
+func WithSession(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sessionID)
+}
+
+// sessionFromContext returns the session ID carried by ctx, or "" if none.
+func sessionFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(sessionKey{}).(string)
+	return sessionID
+}
+
+// revertedIngredient returns the ingredient as it must be stored to undo
+// target at the given time, given its current state. Undoing a creation moves
+// the ingredient to the trash, so the undo can be reverted by hand with
+// Restore. Undoing is a change of its own, so the version still grows.
+func revertedIngredient(target *models.Revision, current *models.Ingredient, now time.Time) *models.Ingredient {
+	var reverted *models.Ingredient
+	if target.Before == nil {
+		reverted = current.Clone()
+		reverted.DeletedAt = &now
+	} else {
+		reverted = target.Before.Clone()
+	}
+	reverted.UpdatedAt = now
+	reverted.Version = current.Version + 1
+
+	return reverted
+}
+
+// latestChange returns the newest of the given revisions, ordered oldest
+// first, that has not been undone and matches the filter. Undo revisions are
+// never returned themselves.
+func latestChange(revisions []*models.Revision, match func(revision *models.Revision) bool) *models.Revision {
+	undone := make(map[int]bool)
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+		if revision.Change == models.RevisionUndo {
+			undone[revision.Reverts] = true
+			continue
+		}
+		if !undone[revision.ID] && match(revision) {
+			return revision
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// testIngredients are the sample ingredients added by SeedTestData. Every one
+// gets its English name as an alias, when it differs.
+var testIngredients = []struct {
+	name     string
+	english  string
+	category models.Category
+}{
+	{"sal", "salt", models.CategorySpices},
+	{"pimienta negra", "black pepper", models.CategorySpices},
+	{"ajo en polvo", "garlic powder", models.CategorySpices},
+	{"cebolla en polvo", "onion powder", models.CategorySpices},
+	{"pimentón", "paprika", models.CategorySpices},
+	{"comino", "cumin", models.CategorySpices},
+	{"orégano", "oregano", models.CategoryHerbs},
+	{"albahaca seca", "dried basil", models.CategoryHerbs},
+	{"tomillo", "thyme", models.CategoryHerbs},
+	{"romero", "rosemary", models.CategoryHerbs},
+	{"aceite de oliva", "olive oil", models.CategoryOils},
+	{"aceite vegetal", "vegetable oil", models.CategoryOils},
+	{"vinagre blanco", "white vinegar", models.CategoryCondiments},
+	{"vinagre de manzana", "apple cider vinegar", models.CategoryCondiments},
+	{"vinagre balsámico", "balsamic vinegar", models.CategoryCondiments},
+	{"leche", "milk", models.CategoryDairy},
+	{"mantequilla", "butter", models.CategoryDairy},
+	{"queso parmesano", "parmesan cheese", models.CategoryDairy},
+	{"huevos", "eggs", models.CategoryProteins},
+	{"yogur natural", "plain yogurt", models.CategoryDairy},
+	{"pollo", "chicken", models.CategoryProteins},
+	{"ternera", "beef", models.CategoryProteins},
+	{"pescado blanco", "white fish", models.CategoryProteins},
+	{"atún en lata", "canned tuna", models.CategoryCanned},
+	{"judías", "beans", models.CategoryLegumes},
+	{"cebolla", "onion", models.CategoryProduce},
+	{"ajo fresco", "fresh garlic", models.CategoryProduce},
+	{"tomate", "tomato", models.CategoryProduce},
+	{"zanahoria", "carrot", models.CategoryProduce},
+	{"apio", "celery", models.CategoryProduce},
+	{"pimiento", "bell pepper", models.CategoryProduce},
+	{"patata", "potato", models.CategoryProduce},
+	{"limón", "lemon", models.CategoryProduce},
+	{"arroz", "rice", models.CategoryGrains},
+	{"pasta", "", models.CategoryGrains},
+	{"pan", "bread", models.CategoryGrains},
+	{"harina", "flour", models.CategoryBaking},
+	{"avena", "oats", models.CategoryGrains},
+	{"azúcar", "sugar", models.CategorySweeteners},
+	{"miel", "honey", models.CategorySweeteners},
+	{"salsa de soja", "soy sauce", models.CategoryCondiments},
+	{"caldo de pollo", "chicken broth", models.CategoryCanned},
+	{"tomate triturado", "crushed tomatoes", models.CategoryCanned},
+	{"mostaza", "mustard", models.CategoryCondiments},
+	{"mahonesa", "mayonnaise", models.CategoryCondiments},
+	{"perejil", "parsley", models.CategoryHerbs},
+	{"cilantro", "coriander", models.CategoryHerbs},
+	{"albahaca fresca", "fresh basil", models.CategoryHerbs},
+	{"levadura", "yeast", models.CategoryBaking},
+	{"bicarbonato sódico", "baking soda", models.CategoryBaking},
+}
+
+// seedTestData adds the sample ingredients to any IngredientStorage, skipping
+// the ones whose name is already taken. It stops as soon as ctx is cancelled,
+// returning the ingredients added so far along with the context error.
+func seedTestData(ctx context.Context, s IngredientStorage) ([]*models.Ingredient, error) {
+	results := make([]*models.Ingredient, 0, len(testIngredients))
+
+	for _, testIngredient := range testIngredients {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		ingredient, err := s.Create(ctx, testIngredient.name)
+		if err != nil {
+			continue
+		}
+		if testIngredient.english != "" {
+			if aliased, err := s.AddAlias(ctx, ingredient.Name, testIngredient.english, "en", AnyVersion); err == nil {
+				ingredient = aliased
+			}
+		}
+		if categorized, err := s.SetCategory(ctx, ingredient.Name, testIngredient.category, AnyVersion); err == nil {
+			ingredient = categorized
+		}
+		results = append(results, ingredient)
+	}
+	return results, nil
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+const (
+	ShoppingListMaxItemCount  = 500
+	ShoppingListUnitMaxLength = 16
+)
+
+var (
+	ErrShoppingListNotFound            = errors.New("shopping list not found")
+	ErrShoppingListItemsCannotBeEmpty  = errors.New("shopping list must have at least one item")
+	ErrShoppingListTooManyItems        = fmt.Errorf("shopping list cannot have more than %d items", ShoppingListMaxItemCount)
+	ErrShoppingListItemInvalidID       = errors.New("shopping list item must reference an existing ingredient")
+	ErrShoppingListItemInvalidQuantity = errors.New("shopping list item quantity must be greater than zero")
+	ErrShoppingListItemUnitIsTooLong   = fmt.Errorf("shopping list item unit cannot exceed %d characters long", ShoppingListUnitMaxLength)
+	ErrShoppingListItemNotFound        = errors.New("ingredient is not on the shopping list")
+)
+
+// ShoppingListMemoryStorage provides in-memory storage for shopping lists.
+// Stored lists are never handed out or changed in place: callers get copies,
+// and changes replace the stored list.
+type ShoppingListMemoryStorage struct {
+	mu     sync.RWMutex
+	lists  map[int]*models.ShoppingList
+	nextID int
+}
+
+// NewShoppingListMemoryStorage creates a new in-memory shopping list storage instance.
+func NewShoppingListMemoryStorage() *ShoppingListMemoryStorage {
+	return &ShoppingListMemoryStorage{
+		lists:  make(map[int]*models.ShoppingList),
+		nextID: 1,
+	}
+}
+
+// Create stores a new shopping list and returns it with an assigned ID. Items
+// start unchecked.
+func (s *ShoppingListMemoryStorage) Create(items []models.ShoppingListItem) (*models.ShoppingList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(items) == 0 {
+		return nil, ErrShoppingListItemsCannotBeEmpty
+	}
+
+	if len(items) > ShoppingListMaxItemCount {
+		return nil, ErrShoppingListTooManyItems
+	}
+
+	normalizedItems := make([]models.ShoppingListItem, 0, len(items))
+	for _, item := range items {
+		if item.IngredientID <= 0 {
+			return nil, ErrShoppingListItemInvalidID
+		}
+
+		if item.Quantity <= 0 {
+			return nil, ErrShoppingListItemInvalidQuantity
+		}
+
+		normalizedUnit := strings.ToLower(strings.TrimSpace(item.Unit))
+		if len(normalizedUnit) > ShoppingListUnitMaxLength {
+			return nil, ErrShoppingListItemUnitIsTooLong
+		}
+
+		normalizedItems = append(normalizedItems, models.ShoppingListItem{
+			IngredientID: item.IngredientID,
+			Quantity:     item.Quantity,
+			Unit:         normalizedUnit,
+		})
+	}
+
+	list := models.NewShoppingList(s.nextID, normalizedItems)
+	s.lists[s.nextID] = list
+	s.nextID++
+
+	return list.Clone(), nil
+}
+
+// Get returns the shopping list with the given ID.
+func (s *ShoppingListMemoryStorage) Get(id int) (*models.ShoppingList, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list, ok := s.lists[id]
+	if !ok {
+		return nil, ErrShoppingListNotFound
+	}
+
+	return list.Clone(), nil
+}
+
+// List returns all shopping lists ordered by ID, so the latest one comes last.
+func (s *ShoppingListMemoryStorage) List() ([]*models.ShoppingList, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]*models.ShoppingList, 0, len(s.lists))
+	for _, list := range s.lists {
+		results = append(results, list.Clone())
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+
+	return results, nil
+}
+
+// SetChecked checks off, or unchecks, every item of the given ingredient on a
+// shopping list.
+func (s *ShoppingListMemoryStorage) SetChecked(id, ingredientID int, checked bool) (*models.ShoppingList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, ok := s.lists[id]
+	if !ok {
+		return nil, ErrShoppingListNotFound
+	}
+
+	updated := list.Clone()
+	found := false
+	for i := range updated.Items {
+		if updated.Items[i].IngredientID == ingredientID {
+			updated.Items[i].Checked = checked
+			found = true
+		}
+	}
+	if !found {
+		return nil, ErrShoppingListItemNotFound
+	}
+
+	updated.UpdatedAt = time.Now()
+	s.lists[id] = updated
+
+	return updated.Clone(), nil
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+var testShoppingListItems = []models.ShoppingListItem{
+	{IngredientID: 1, Quantity: 500, Unit: "g"},
+	{IngredientID: 2, Quantity: 6, Unit: ""},
+	{IngredientID: 1, Quantity: 2, Unit: "cup"},
+}
+
+func TestNewShoppingListMemoryStorage(t *testing.T) {
+	storage := NewShoppingListMemoryStorage()
+	if storage == nil {
+		t.Fatal("shopping list memory storage created is nil")
+	}
+
+	if storage.lists == nil {
+		t.Error("lists map is nil")
+	}
+
+	if storage.nextID != 1 {
+		t.Errorf("expected ID to be 1, got %d", storage.nextID)
+	}
+}
+
+func TestCreateShoppingList(t *testing.T) {
+	t.Run("successful creation", func(t *testing.T) {
+		storage := NewShoppingListMemoryStorage()
+		list, err := storage.Create([]models.ShoppingListItem{
+			{IngredientID: 1, Quantity: 500, Unit: " G ", Checked: true},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if list.ID != 1 {
+			t.Errorf("expected ID 1, got %d", list.ID)
+		}
+
+		if list.Items[0].Unit != "g" {
+			t.Errorf("expected normalized unit 'g', got %q", list.Items[0].Unit)
+		}
+
+		if list.Items[0].Checked {
+			t.Error("new items should start unchecked")
+		}
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			items    []models.ShoppingListItem
+			expected error
+		}{
+			{"no items", nil, ErrShoppingListItemsCannotBeEmpty},
+			{"too many items", make([]models.ShoppingListItem, ShoppingListMaxItemCount+1), ErrShoppingListTooManyItems},
+			{"invalid ingredient", []models.ShoppingListItem{{IngredientID: 0, Quantity: 1}}, ErrShoppingListItemInvalidID},
+			{"invalid quantity", []models.ShoppingListItem{{IngredientID: 1, Quantity: 0}}, ErrShoppingListItemInvalidQuantity},
+			{"unit too long", []models.ShoppingListItem{{IngredientID: 1, Quantity: 1, Unit: strings.Repeat("a", ShoppingListUnitMaxLength+1)}}, ErrShoppingListItemUnitIsTooLong},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				storage := NewShoppingListMemoryStorage()
+				_, err := storage.Create(tc.items)
+				if err != tc.expected {
+					t.Errorf("expected %v, got %v", tc.expected, err)
+				}
+			})
+		}
+	})
+}
+
+func TestGetShoppingList(t *testing.T) {
+	storage := NewShoppingListMemoryStorage()
+	created, _ := storage.Create(testShoppingListItems)
+
+	list, err := storage.Get(created.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(list.Items) != len(testShoppingListItems) {
+		t.Errorf("expected %d items, got %d", len(testShoppingListItems), len(list.Items))
+	}
+
+	if _, err := storage.Get(42); err != ErrShoppingListNotFound {
+		t.Errorf("expected %v, got %v", ErrShoppingListNotFound, err)
+	}
+}
+
+func TestListShoppingLists(t *testing.T) {
+	t.Run("list is ordered by ID", func(t *testing.T) {
+		storage := NewShoppingListMemoryStorage()
+		for range 3 {
+			storage.Create(testShoppingListItems)
+		}
+
+		lists, err := storage.List()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for i, list := range lists {
+			if list.ID != i+1 {
+				t.Errorf("expected ID %d at position %d, got %d", i+1, i, list.ID)
+			}
+		}
+	})
+
+	t.Run("empty storage returns empty slice", func(t *testing.T) {
+		storage := NewShoppingListMemoryStorage()
+		lists, err := storage.List()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if lists == nil || len(lists) != 0 {
+			t.Errorf("expected empty slice, got %v", lists)
+		}
+	})
+}
+
+func TestSetShoppingListItemChecked(t *testing.T) {
+	t.Run("checks every item of the ingredient", func(t *testing.T) {
+		storage := NewShoppingListMemoryStorage()
+		created, _ := storage.Create(testShoppingListItems)
+		originalUpdatedAt := created.UpdatedAt
+
+		time.Sleep(1 * time.Millisecond)
+		list, err := storage.SetChecked(created.ID, 1, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !list.Items[0].Checked || list.Items[1].Checked || !list.Items[2].Checked {
+			t.Errorf("expected only the items of ingredient 1 to be checked, got %+v", list.Items)
+		}
+
+		if list.Remaining() != 1 {
+			t.Errorf("expected 1 remaining item, got %d", list.Remaining())
+		}
+
+		if !list.UpdatedAt.After(originalUpdatedAt) {
+			t.Error("update date should have been refreshed")
+		}
+	})
+
+	t.Run("unchecks items", func(t *testing.T) {
+		storage := NewShoppingListMemoryStorage()
+		created, _ := storage.Create(testShoppingListItems)
+		storage.SetChecked(created.ID, 2, true)
+
+		list, err := storage.SetChecked(created.ID, 2, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if list.Items[1].Checked {
+			t.Error("expected item to be unchecked")
+		}
+	})
+
+	t.Run("not found errors", func(t *testing.T) {
+		storage := NewShoppingListMemoryStorage()
+		created, _ := storage.Create(testShoppingListItems)
+
+		if _, err := storage.SetChecked(42, 1, true); err != ErrShoppingListNotFound {
+			t.Errorf("expected %v, got %v", ErrShoppingListNotFound, err)
+		}
+
+		if _, err := storage.SetChecked(created.ID, 42, true); err != ErrShoppingListItemNotFound {
+			t.Errorf("expected %v, got %v", ErrShoppingListItemNotFound, err)
+		}
+	})
+}
+
+func TestShoppingListMemoryStorageConcurrentAccess(t *testing.T) {
+	t.Run("changing returned lists leaves the storage untouched", func(t *testing.T) {
+		storage := NewShoppingListMemoryStorage()
+		created, _ := storage.Create(testShoppingListItems)
+		created.Items[0].Quantity = 0
+
+		checked, _ := storage.SetChecked(created.ID, 2, true)
+		checked.Items[1].Checked = false
+
+		list, _ := storage.Get(created.ID)
+		list.Items[2].Checked = true
+
+		lists, _ := storage.List()
+		lists[0].Items[0].Unit = "scribbled"
+
+		stored, _ := storage.Get(created.ID)
+		if stored.Items[0].Quantity != 500 || stored.Items[0].Unit != "g" || !stored.Items[1].Checked || stored.Items[2].Checked {
+			t.Errorf("expected the stored list to be untouched, got %+v", stored.Items)
+		}
+	})
+
+	t.Run("reading while others check items off", func(t *testing.T) {
+		storage := NewShoppingListMemoryStorage()
+		created, _ := storage.Create(testShoppingListItems)
+
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				if _, err := storage.SetChecked(created.ID, 1, i%2 == 0); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				// reading every field races with a change made in place
+				list, err := storage.Get(created.ID)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				_ = fmt.Sprint(*list)
+				lists, _ := storage.List()
+				_ = fmt.Sprint(*lists[0])
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,1317 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	// SQLite is the default driver of SQLStorage, and its error codes tell
+	// unique constraint violations apart.
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// sqlExecutor is implemented by both *sql.DB and *sql.Tx.
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// SQLStorage provides ingredient storage backed by a SQL database through
+// database/sql. Queries are written for SQLite. Every change runs in a
+// transaction, and the unique indexes of the schema back the name checks, so
+// two writers can never store the same name. Unlike the memory-based storages
+// it takes no Clock or IDGenerator: dates come from the system clock and SQLite
+// numbers the ingredients in order.
+type SQLStorage struct {
+	db   *sql.DB
+	feed changeFeed
+	// announceMu guards announced, the ID of the last revision published to
+	// the watchers.
+	announceMu sync.Mutex
+	announced  int
+}
+
+// NewSQLStorage creates a SQL storage instance on an open database, migrating
+// its schema to the latest version first.
+func NewSQLStorage(ctx context.Context, db *sql.DB) (*SQLStorage, error) {
+	if err := migrateSQL(ctx, db); err != nil {
+		return nil, err
+	}
+
+	s := &SQLStorage{db: db}
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM ingredient_revisions`).Scan(&s.announced); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// OpenSQLiteStorage opens, and creates if missing, the SQLite database at path
+// and returns a SQL storage instance on it. Use ":memory:" for a throwaway
+// database.
+func OpenSQLiteStorage(ctx context.Context, path string) (*SQLStorage, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer at a time, and every connection to ":memory:"
+	// would otherwise get its own empty database
+	db.SetMaxOpenConns(1)
+
+	s, err := NewSQLStorage(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the underlying database.
+func (s *SQLStorage) Close() error {
+	return s.db.Close()
+}
+
+// AddAlias adds an alternative name to an ingredient. Aliases share the
+// uniqueness rules of ingredient names.
+func (s *SQLStorage) AddAlias(ctx context.Context, name, alias, locale string, expectedVersion int) (*models.Ingredient, error) {
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedAlias, err := validateIngredientName(alias)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedLocale, err := normalizeAliasLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.update(ctx, normalizedName, models.RevisionAddAlias, func(tx *sql.Tx, id int) error {
+		if err := sqlCheckVersion(ctx, tx, id, expectedVersion); err != nil {
+			return err
+		}
+
+		exists, err := sqlIngredientNameExists(ctx, tx, normalizedAlias)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrIngredientAliasExists
+		}
+
+		var aliasCount int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM ingredient_aliases WHERE ingredient_id = ?`, id).Scan(&aliasCount); err != nil {
+			return err
+		}
+		if aliasCount >= IngredientMaxAliases {
+			return ErrIngredientTooManyAliases
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO ingredient_aliases (ingredient_id, name, locale) VALUES (?, ?, ?)`,
+			id, normalizedAlias, normalizedLocale)
+		if isSQLUniqueViolation(err) {
+			return ErrIngredientAliasExists
+		}
+		return err
+	})
+}
+
+// Create adds a new ingredient and returns it with an assigned ID.
+func (s *SQLStorage) Create(ctx context.Context, name string) (*models.Ingredient, error) {
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ingredient, err := sqlCreateIngredient(ctx, tx, normalizedName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return ingredient, nil
+}
+
+// CreateMany adds a new ingredient for every name in one transaction, or none
+// if any name fails.
+func (s *SQLStorage) CreateMany(ctx context.Context, names []string) ([]*models.Ingredient, error) {
+	results := make([]*models.Ingredient, len(names))
+	err := s.batch(ctx, len(names), func(tx *sql.Tx, i int) error {
+		normalizedName, err := validateIngredientName(names[i])
+		if err != nil {
+			return err
+		}
+
+		results[i], err = sqlCreateIngredient(ctx, tx, normalizedName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CreateWithDetails adds a new ingredient with the given details set, all in
+// one transaction, so the ingredient is either created with every detail or
+// not at all.
+func (s *SQLStorage) CreateWithDetails(ctx context.Context, name string, details IngredientDetails) (*models.Ingredient, error) {
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedDetails, err := details.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := sqlCreateIngredient(ctx, tx, normalizedName); err != nil {
+		return nil, err
+	}
+
+	ingredient, err := sqlSetDetails(ctx, tx, normalizedName, normalizedDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return ingredient, nil
+}
+
+// Delete moves an ingredient, found by its name or any of its aliases, to the
+// trash.
+func (s *SQLStorage) Delete(ctx context.Context, name string, expectedVersion int) error {
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := sqlDeleteIngredient(ctx, tx, normalizedName, expectedVersion); err != nil {
+		return err
+	}
+
+	return s.commit(ctx, tx)
+}
+
+// DeleteMany moves the ingredients with the given names to the trash in one
+// transaction, or none if any name fails.
+func (s *SQLStorage) DeleteMany(ctx context.Context, names []string) error {
+	return s.batch(ctx, len(names), func(tx *sql.Tx, i int) error {
+		normalizedName, err := validateIngredientName(names[i])
+		if err != nil {
+			return err
+		}
+
+		return sqlDeleteIngredient(ctx, tx, normalizedName, AnyVersion)
+	})
+}
+
+// Get returns the ingredient with the given ID.
+func (s *SQLStorage) Get(ctx context.Context, id int) (*models.Ingredient, error) {
+	ingredients, err := sqlListIngredients(ctx, s.db, "WHERE id = ? AND deleted_at IS NULL", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(ingredients) == 0 {
+		return nil, ErrIngredientNotFound
+	}
+
+	return ingredients[0], nil
+}
+
+// GetByName returns the ingredient whose name or alias matches the given one.
+func (s *SQLStorage) GetByName(ctx context.Context, name string) (*models.Ingredient, error) {
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := sqlFindIngredientID(ctx, s.db, normalizedName)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, id)
+}
+
+// History returns every revision of the ingredient with the given ID, oldest
+// first. Ingredients in the trash or purged keep their history.
+func (s *SQLStorage) History(ctx context.Context, id int) ([]*models.Revision, error) {
+	revisions, err := sqlListRevisions(ctx, s.db, "WHERE ingredient_id = ? ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(revisions) == 0 {
+		if _, err := sqlGetIngredient(ctx, s.db, id); err != nil {
+			return nil, err
+		}
+	}
+	return revisions, nil
+}
+
+// List returns every ingredient, ordered by ID.
+func (s *SQLStorage) List(ctx context.Context) ([]*models.Ingredient, error) {
+	return sqlListIngredients(ctx, s.db, "WHERE deleted_at IS NULL")
+}
+
+// ListPage returns the page of ingredients selected by options.
+func (s *SQLStorage) ListPage(ctx context.Context, options ListOptions) (*IngredientPage, error) {
+	cursor, err := options.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := "WHERE deleted_at IS NULL", []any{}
+	if options.Category != nil {
+		where += " AND category = ?"
+		args = append(args, string(*options.Category))
+	}
+
+	page := &IngredientPage{}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM ingredients `+where, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	// the sort fields are stored just like sortKey returns them
+	column, direction, after := string(options.SortBy), "ASC", ">"
+	if options.Descending {
+		direction, after = "DESC", "<"
+	}
+	if cursor != nil {
+		page.Offset = cursor.Offset
+		if options.SortBy == SortByID {
+			where += " AND id " + after + " ?"
+			args = append(args, cursor.ID)
+		} else {
+			where += " AND (" + column + ", id) " + after + " (?, ?)"
+			args = append(args, cursor.Key, cursor.ID)
+		}
+	}
+
+	clause := where + " ORDER BY id " + direction
+	if options.SortBy != SortByID {
+		clause = where + " ORDER BY " + column + " " + direction + ", id " + direction
+	}
+	// one more than the limit tells whether there is a next page
+	if options.Limit > 0 {
+		clause += " LIMIT ?"
+		args = append(args, options.Limit+1)
+	}
+
+	ingredients, err := sqlQueryIngredients(ctx, s.db, clause, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Limit > 0 && len(ingredients) > options.Limit {
+		ingredients = ingredients[:options.Limit]
+		page.NextCursor = options.nextCursor(ingredients[len(ingredients)-1], page.Offset+len(ingredients))
+	}
+	page.Ingredients = ingredients
+
+	return page, nil
+}
+
+// ListDeleted returns every ingredient in the trash, ordered by ID.
+func (s *SQLStorage) ListDeleted(ctx context.Context) ([]*models.Ingredient, error) {
+	return sqlListIngredients(ctx, s.db, "WHERE deleted_at IS NOT NULL")
+}
+
+// Purge permanently removes every ingredient moved to the trash before the
+// given time and returns them. A zero time purges the whole trash.
+func (s *SQLStorage) Purge(ctx context.Context, deletedBefore time.Time) ([]*models.Ingredient, error) {
+	where, args := "WHERE deleted_at IS NOT NULL", []any{}
+	if !deletedBefore.IsZero() {
+		where, args = "WHERE deleted_at < ?", []any{formatSQLTime(deletedBefore)}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	purged, err := sqlListIngredients(ctx, tx, where, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ingredient_aliases WHERE ingredient_id IN (SELECT id FROM ingredients `+where+`)`, args...); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ingredients `+where, args...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.announce(ctx, purged)
+	return purged, nil
+}
+
+// RemoveAlias removes an alias from the ingredient with the given name.
+func (s *SQLStorage) RemoveAlias(ctx context.Context, name, alias string, expectedVersion int) (*models.Ingredient, error) {
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedAlias, err := validateIngredientName(alias)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.update(ctx, normalizedName, models.RevisionRemoveAlias, func(tx *sql.Tx, id int) error {
+		if err := sqlCheckVersion(ctx, tx, id, expectedVersion); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `DELETE FROM ingredient_aliases WHERE ingredient_id = ? AND name = ?`, id, normalizedAlias)
+		if err != nil {
+			return err
+		}
+
+		removed, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			return ErrIngredientAliasNotFound
+		}
+		return nil
+	})
+}
+
+// Restore takes the ingredient with the given ID out of the trash. It fails if
+// its name or any of its aliases has been taken since it was deleted.
+func (s *SQLStorage) Restore(ctx context.Context, id int) (*models.Ingredient, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ingredient, err := sqlGetIngredient(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ingredient.IsDeleted() {
+		return nil, ErrIngredientNotInTrash
+	}
+
+	exists, err := sqlIngredientNameExists(ctx, tx, ingredient.Name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrIngredientNameExists
+	}
+	for _, alias := range ingredient.Aliases {
+		exists, err := sqlIngredientNameExists(ctx, tx, alias.Name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrIngredientAliasExists
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE ingredients SET deleted_at = NULL, version = version + 1 WHERE id = ?`, id)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, `UPDATE ingredient_aliases SET deleted_at = NULL WHERE ingredient_id = ?`, id)
+	}
+	if isSQLUniqueViolation(err) {
+		return nil, ErrIngredientNameExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := sqlGetIngredient(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = sqlRecordRevision(ctx, tx, &models.Revision{IngredientID: id, Change: models.RevisionRestore, Before: ingredient, After: restored})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// SeedTestData adds the sample ingredients.
+func (s *SQLStorage) SeedTestData(ctx context.Context) ([]*models.Ingredient, error) {
+	return seedTestData(ctx, s)
+}
+
+// SetCategory sets the category of the ingredient with the given name.
+func (s *SQLStorage) SetCategory(ctx context.Context, name string, category models.Category, expectedVersion int) (*models.Ingredient, error) {
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedCategory, ok := models.ParseCategory(string(category))
+	if !ok {
+		return nil, ErrIngredientCategoryInvalid
+	}
+
+	return s.update(ctx, normalizedName, models.RevisionSetCategory, func(tx *sql.Tx, id int) error {
+		if err := sqlCheckVersion(ctx, tx, id, expectedVersion); err != nil {
+			return err
+		}
+
+		return sqlSetCategory(ctx, tx, id, normalizedCategory)
+	})
+}
+
+// SetDietaryInfo replaces the allergens and diets of the ingredient with the given name.
+func (s *SQLStorage) SetDietaryInfo(ctx context.Context, name string, info models.DietaryInfo, expectedVersion int) (*models.Ingredient, error) {
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedInfo, err := ValidateDietaryInfo(info)
+	if err != nil {
+		return nil, err
+	}
+
+	dietary, err := json.Marshal(normalizedInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.update(ctx, normalizedName, models.RevisionSetDietaryInfo, func(tx *sql.Tx, id int) error {
+		if err := sqlCheckVersion(ctx, tx, id, expectedVersion); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, `UPDATE ingredients SET dietary = ? WHERE id = ?`, string(dietary), id)
+		return err
+	})
+}
+
+// SetNutrition replaces the nutrition facts of the ingredient with the given name.
+func (s *SQLStorage) SetNutrition(ctx context.Context, name string, facts models.NutritionFacts, expectedVersion int) (*models.Ingredient, error) {
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateNutritionFacts(facts); err != nil {
+		return nil, err
+	}
+
+	return s.update(ctx, normalizedName, models.RevisionSetNutrition, func(tx *sql.Tx, id int) error {
+		if err := sqlCheckVersion(ctx, tx, id, expectedVersion); err != nil {
+			return err
+		}
+
+		return sqlSetNutrition(ctx, tx, id, facts)
+	})
+}
+
+// SetUnitConversion replaces the unit conversion fields of the ingredient with the given name.
+func (s *SQLStorage) SetUnitConversion(ctx context.Context, name string, conversion models.UnitConversion, expectedVersion int) (*models.Ingredient, error) {
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedConversion, err := ValidateUnitConversion(conversion)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.update(ctx, normalizedName, models.RevisionSetUnitConversion, func(tx *sql.Tx, id int) error {
+		if err := sqlCheckVersion(ctx, tx, id, expectedVersion); err != nil {
+			return err
+		}
+
+		return sqlSetUnitConversion(ctx, tx, id, normalizedConversion)
+	})
+}
+
+// UndoLastChange reverts the most recent change made by the session of ctx
+// that has not been undone yet, and returns the revision recording the undo.
+// Undoing again walks further back. A change is only undone while it is still
+// the latest one to its ingredient and the names it restores are free.
+func (s *SQLStorage) UndoLastChange(ctx context.Context) (*models.Revision, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// undo revisions are never undone themselves, but mark what they reverted as undone
+	const notUndone = `kind != 'undo' AND id NOT IN (SELECT reverts FROM ingredient_revisions WHERE reverts IS NOT NULL)`
+
+	targets, err := sqlListRevisions(ctx, tx, "WHERE session_id = ? AND "+notUndone+" ORDER BY id DESC LIMIT 1", sessionFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, ErrNothingToUndo
+	}
+	target := targets[0]
+
+	latest, err := sqlListRevisions(ctx, tx, "WHERE ingredient_id = ? AND "+notUndone+" ORDER BY id DESC LIMIT 1", target.IngredientID)
+	if err != nil {
+		return nil, err
+	}
+	if len(latest) == 0 || latest[0].ID != target.ID {
+		return nil, ErrUndoConflict
+	}
+
+	current, err := sqlGetIngredient(ctx, tx, target.IngredientID)
+	if err != nil {
+		return nil, err
+	}
+
+	reverted := revertedIngredient(target, current, time.Now())
+	if !reverted.IsDeleted() {
+		for i, name := range append([]string{reverted.Name}, aliasNames(reverted.Aliases)...) {
+			id, err := sqlFindIngredientID(ctx, tx, name)
+			if errors.Is(err, ErrIngredientNotFound) || (err == nil && id == reverted.ID) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				return nil, ErrIngredientNameExists
+			}
+			return nil, ErrIngredientAliasExists
+		}
+	}
+
+	err = sqlWriteIngredient(ctx, tx, reverted)
+	if isSQLUniqueViolation(err) {
+		return nil, ErrIngredientNameExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := sqlGetIngredient(ctx, tx, reverted.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	revision := &models.Revision{
+		IngredientID: reverted.ID,
+		Change:       models.RevisionUndo,
+		Before:       current,
+		After:        after,
+		Reverts:      target.ID,
+	}
+	if err := sqlRecordRevision(ctx, tx, revision); err != nil {
+		return nil, err
+	}
+
+	if err := s.commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// Update renames an ingredient, found by its name or any of its aliases.
+func (s *SQLStorage) Update(ctx context.Context, name, newName string, expectedVersion int) (*models.Ingredient, error) {
+	// normalize both inputs early
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedNewName, err := validateIngredientName(newName)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.update(ctx, normalizedName, models.RevisionRename, func(tx *sql.Tx, id int) error {
+		if err := sqlCheckVersion(ctx, tx, id, expectedVersion); err != nil {
+			return err
+		}
+
+		return sqlRenameIngredient(ctx, tx, id, normalizedNewName)
+	})
+}
+
+// UpdateMany applies every rename in order in one transaction, or none if any
+// rename fails.
+func (s *SQLStorage) UpdateMany(ctx context.Context, renames []IngredientRename) ([]*models.Ingredient, error) {
+	results := make([]*models.Ingredient, len(renames))
+	err := s.batch(ctx, len(renames), func(tx *sql.Tx, i int) error {
+		normalizedName, err := validateIngredientName(renames[i].Name)
+		if err != nil {
+			return err
+		}
+
+		normalizedNewName, err := validateIngredientName(renames[i].NewName)
+		if err != nil {
+			return err
+		}
+
+		results[i], err = sqlUpdateIngredient(ctx, tx, normalizedName, models.RevisionRename, func(tx *sql.Tx, id int) error {
+			return sqlRenameIngredient(ctx, tx, id, normalizedNewName)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// UpdateWithDetails renames the ingredient with the given name, unless newName
+// is empty, and sets the given details, all in one transaction, so either
+// every change is made or none is. Each change is recorded as its own revision.
+func (s *SQLStorage) UpdateWithDetails(ctx context.Context, name, newName string, details IngredientDetails, expectedVersion int) (*models.Ingredient, error) {
+	normalizedName, err := validateIngredientName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizedNewName := normalizedName
+	if newName != "" {
+		normalizedNewName, err = validateIngredientName(newName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	normalizedDetails, err := details.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id, err := sqlFindIngredientID(ctx, tx, normalizedName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sqlCheckVersion(ctx, tx, id, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	if newName != "" {
+		_, err := sqlUpdateIngredient(ctx, tx, normalizedName, models.RevisionRename, func(tx *sql.Tx, id int) error {
+			return sqlRenameIngredient(ctx, tx, id, normalizedNewName)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ingredient, err := sqlSetDetails(ctx, tx, normalizedNewName, normalizedDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return ingredient, nil
+}
+
+// Watch returns a channel receiving an event for every change committed from
+// now on, in order. It is closed once ctx is done, or if the watcher falls too
+// far behind, in which case it must read what it needs again and watch anew.
+// Changes committed by other processes sharing the database are announced
+// along with the next change committed by this storage.
+func (s *SQLStorage) Watch(ctx context.Context) <-chan models.IngredientEvent {
+	return s.feed.watch(ctx)
+}
+
+// update runs change in a transaction on the ingredient matching the given
+// name, bumps its update date, records the revision and returns the
+// ingredient as stored afterwards.
+func (s *SQLStorage) update(ctx context.Context, normalizedName string, kind models.RevisionChange, change func(tx *sql.Tx, id int) error) (*models.Ingredient, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ingredient, err := sqlUpdateIngredient(ctx, tx, normalizedName, kind, change)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.commit(ctx, tx); err != nil {
+		return nil, err
+	}
+	return ingredient, nil
+}
+
+// commit commits tx and announces the changes it made.
+func (s *SQLStorage) commit(ctx context.Context, tx *sql.Tx) error {
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.announce(ctx, nil)
+	return nil
+}
+
+// announce publishes the revisions recorded since the last ones announced,
+// then the given purged ingredients.
+func (s *SQLStorage) announce(ctx context.Context, purged []*models.Ingredient) {
+	s.announceMu.Lock()
+	defer s.announceMu.Unlock()
+
+	// the change is committed already, so revisions that cannot be read now
+	// are left for the next announcement
+	revisions, err := sqlListRevisions(context.WithoutCancel(ctx), s.db, "WHERE id > ? ORDER BY id", s.announced)
+	if err != nil {
+		revisions = nil
+	}
+
+	events := make([]models.IngredientEvent, 0, len(revisions)+len(purged))
+	for _, revision := range revisions {
+		events = append(events, revisionEvent(revision))
+		s.announced = revision.ID
+	}
+	for _, ingredient := range purged {
+		events = append(events, purgedEvent(ingredient))
+	}
+	s.feed.publish(events...)
+}
+
+// batch runs every item of a batch of n items in a single transaction, which
+// is committed only if every item succeeds.
+func (s *SQLStorage) batch(ctx context.Context, n int, apply func(tx *sql.Tx, i int) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = runBatch(ctx, n, func(i int) error {
+		return apply(tx, i)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.commit(ctx, tx)
+}
+
+func sqlCreateIngredient(ctx context.Context, tx *sql.Tx, normalizedName string) (*models.Ingredient, error) {
+	exists, err := sqlIngredientNameExists(ctx, tx, normalizedName)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrIngredientNameExists
+	}
+
+	ingredient := models.NewIngredient(0, normalizedName)
+	result, err := tx.ExecContext(ctx, `INSERT INTO ingredients (name, created_at, updated_at) VALUES (?, ?, ?)`,
+		ingredient.Name, formatSQLTime(ingredient.CreatedAt), formatSQLTime(ingredient.UpdatedAt))
+	if isSQLUniqueViolation(err) {
+		return nil, ErrIngredientNameExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	ingredient.ID = int(id)
+
+	err = sqlRecordRevision(ctx, tx, &models.Revision{IngredientID: ingredient.ID, Change: models.RevisionCreate, After: ingredient})
+	if err != nil {
+		return nil, err
+	}
+
+	return ingredient, nil
+}
+
+func sqlDeleteIngredient(ctx context.Context, tx *sql.Tx, normalizedName string, expectedVersion int) error {
+	id, err := sqlFindIngredientID(ctx, tx, normalizedName)
+	if err != nil {
+		return err
+	}
+
+	before, err := sqlGetIngredient(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := checkVersion(before, expectedVersion); err != nil {
+		return err
+	}
+
+	deletedAt := formatSQLTime(time.Now())
+	if _, err := tx.ExecContext(ctx, `UPDATE ingredients SET deleted_at = ?, version = version + 1 WHERE id = ?`, deletedAt, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE ingredient_aliases SET deleted_at = ? WHERE ingredient_id = ?`, deletedAt, id); err != nil {
+		return err
+	}
+
+	after, err := sqlGetIngredient(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	return sqlRecordRevision(ctx, tx, &models.Revision{IngredientID: id, Change: models.RevisionDelete, Before: before, After: after})
+}
+
+func sqlRenameIngredient(ctx context.Context, tx *sql.Tx, id int, normalizedNewName string) error {
+	exists, err := sqlIngredientNameExists(ctx, tx, normalizedNewName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrIngredientNameExists
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE ingredients SET name = ? WHERE id = ?`, normalizedNewName, id)
+	if isSQLUniqueViolation(err) {
+		return ErrIngredientNameExists
+	}
+	return err
+}
+
+// sqlUpdateIngredient runs change on the ingredient matching the given name,
+// bumps its update date, records the revision and returns the ingredient as
+// stored afterwards.
+func sqlUpdateIngredient(ctx context.Context, tx *sql.Tx, normalizedName string, kind models.RevisionChange, change func(tx *sql.Tx, id int) error) (*models.Ingredient, error) {
+	id, err := sqlFindIngredientID(ctx, tx, normalizedName)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := sqlGetIngredient(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := change(tx, id); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE ingredients SET updated_at = ?, version = version + 1 WHERE id = ?`, formatSQLTime(time.Now()), id); err != nil {
+		return nil, err
+	}
+
+	after, err := sqlGetIngredient(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = sqlRecordRevision(ctx, tx, &models.Revision{IngredientID: id, Change: kind, Before: before, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// sqlSetDetails sets the given details, already normalized, of the ingredient
+// with the given name, recording a revision for each of them, and returns the
+// ingredient as it is afterwards.
+func sqlSetDetails(ctx context.Context, tx *sql.Tx, normalizedName string, details IngredientDetails) (*models.Ingredient, error) {
+	id, err := sqlFindIngredientID(ctx, tx, normalizedName)
+	if err != nil {
+		return nil, err
+	}
+
+	if details.Nutrition != nil {
+		_, err := sqlUpdateIngredient(ctx, tx, normalizedName, models.RevisionSetNutrition, func(tx *sql.Tx, id int) error {
+			return sqlSetNutrition(ctx, tx, id, *details.Nutrition)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if details.UnitConversion != nil {
+		_, err := sqlUpdateIngredient(ctx, tx, normalizedName, models.RevisionSetUnitConversion, func(tx *sql.Tx, id int) error {
+			return sqlSetUnitConversion(ctx, tx, id, *details.UnitConversion)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if details.Category != nil {
+		_, err := sqlUpdateIngredient(ctx, tx, normalizedName, models.RevisionSetCategory, func(tx *sql.Tx, id int) error {
+			return sqlSetCategory(ctx, tx, id, *details.Category)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sqlGetIngredient(ctx, tx, id)
+}
+
+func sqlSetCategory(ctx context.Context, tx *sql.Tx, id int, category models.Category) error {
+	_, err := tx.ExecContext(ctx, `UPDATE ingredients SET category = ? WHERE id = ?`, string(category), id)
+	return err
+}
+
+func sqlSetNutrition(ctx context.Context, tx *sql.Tx, id int, facts models.NutritionFacts) error {
+	nutrition, err := json.Marshal(facts)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE ingredients SET nutrition = ? WHERE id = ?`, string(nutrition), id)
+	return err
+}
+
+func sqlSetUnitConversion(ctx context.Context, tx *sql.Tx, id int, conversion models.UnitConversion) error {
+	_, err := tx.ExecContext(ctx, `UPDATE ingredients SET piece_weight_grams = ?, piece_name = ?, density_g_per_ml = ? WHERE id = ?`,
+		conversion.PieceWeightGrams, conversion.PieceName, conversion.DensityGPerMl, id)
+	return err
+}
+
+// sqlCheckVersion returns ErrIngredientVersionConflict unless the ingredient
+// with the given ID is at the expected version, or any version is expected.
+func sqlCheckVersion(ctx context.Context, q sqlExecutor, id int, expectedVersion int) error {
+	if expectedVersion == AnyVersion {
+		return nil
+	}
+
+	var version int
+	if err := q.QueryRowContext(ctx, `SELECT version FROM ingredients WHERE id = ?`, id).Scan(&version); err != nil {
+		return err
+	}
+	if version != expectedVersion {
+		return ErrIngredientVersionConflict
+	}
+	return nil
+}
+
+// sqlGetIngredient returns the ingredient with the given ID, even if it is in
+// the trash.
+func sqlGetIngredient(ctx context.Context, q sqlExecutor, id int) (*models.Ingredient, error) {
+	ingredients, err := sqlListIngredients(ctx, q, "WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(ingredients) == 0 {
+		return nil, ErrIngredientNotFound
+	}
+
+	return ingredients[0], nil
+}
+
+// sqlWriteIngredient overwrites every stored field of an ingredient, its
+// aliases included, with the given ones.
+func sqlWriteIngredient(ctx context.Context, tx *sql.Tx, ingredient *models.Ingredient) error {
+	var dietary, nutrition, deletedAt sql.NullString
+	if ingredient.Dietary != nil {
+		data, err := json.Marshal(ingredient.Dietary)
+		if err != nil {
+			return err
+		}
+		dietary = sql.NullString{String: string(data), Valid: true}
+	}
+	if ingredient.Nutrition != nil {
+		data, err := json.Marshal(ingredient.Nutrition)
+		if err != nil {
+			return err
+		}
+		nutrition = sql.NullString{String: string(data), Valid: true}
+	}
+	if ingredient.IsDeleted() {
+		deletedAt = sql.NullString{String: formatSQLTime(*ingredient.DeletedAt), Valid: true}
+	}
+
+	// aliases go first, so the name can take one of the names they free
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ingredient_aliases WHERE ingredient_id = ?`, ingredient.ID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `UPDATE ingredients SET name = ?, category = ?, dietary = ?, nutrition = ?,
+		piece_weight_grams = ?, piece_name = ?, density_g_per_ml = ?, updated_at = ?, deleted_at = ?, version = ? WHERE id = ?`,
+		ingredient.Name, string(ingredient.Category), dietary, nutrition, ingredient.PieceWeightGrams, ingredient.PieceName,
+		ingredient.DensityGPerMl, formatSQLTime(ingredient.UpdatedAt), deletedAt, ingredient.Version, ingredient.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, alias := range ingredient.Aliases {
+		_, err := tx.ExecContext(ctx, `INSERT INTO ingredient_aliases (ingredient_id, name, locale, deleted_at) VALUES (?, ?, ?, ?)`,
+			ingredient.ID, alias.Name, alias.Locale, deletedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqlRecordRevision stores a revision of a change made in tx, filling in its
+// ID, session and date.
+func sqlRecordRevision(ctx context.Context, tx *sql.Tx, revision *models.Revision) error {
+	revision.SessionID = sessionFromContext(ctx)
+	revision.CreatedAt = time.Now()
+
+	var before sql.NullString
+	if revision.Before != nil {
+		data, err := json.Marshal(revision.Before)
+		if err != nil {
+			return err
+		}
+		before = sql.NullString{String: string(data), Valid: true}
+	}
+
+	after, err := json.Marshal(revision.After)
+	if err != nil {
+		return err
+	}
+
+	var reverts sql.NullInt64
+	if revision.Reverts != 0 {
+		reverts = sql.NullInt64{Int64: int64(revision.Reverts), Valid: true}
+	}
+
+	result, err := tx.ExecContext(ctx, `INSERT INTO ingredient_revisions
+		(ingredient_id, kind, old_ingredient, new_ingredient, session_id, reverts, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		revision.IngredientID, string(revision.Change), before, string(after), revision.SessionID, reverts, formatSQLTime(revision.CreatedAt))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	revision.ID = int(id)
+
+	return nil
+}
+
+// sqlListRevisions returns the revisions matching a clause that follows FROM,
+// such as a WHERE and an ORDER BY.
+func sqlListRevisions(ctx context.Context, q sqlExecutor, clause string, args ...any) ([]*models.Revision, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id, ingredient_id, kind, old_ingredient, new_ingredient, session_id, reverts,
+		created_at FROM ingredient_revisions `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]*models.Revision, 0)
+	for rows.Next() {
+		var revision models.Revision
+		var kind, after, createdAt string
+		var before sql.NullString
+		var reverts sql.NullInt64
+
+		err := rows.Scan(&revision.ID, &revision.IngredientID, &kind, &before, &after, &revision.SessionID, &reverts, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		revision.Change = models.RevisionChange(kind)
+		revision.Reverts = int(reverts.Int64)
+
+		if before.Valid {
+			revision.Before = &models.Ingredient{}
+			if err := json.Unmarshal([]byte(before.String), revision.Before); err != nil {
+				return nil, fmt.Errorf("revision %d has an invalid ingredient: %w", revision.ID, err)
+			}
+		}
+		revision.After = &models.Ingredient{}
+		if err := json.Unmarshal([]byte(after), revision.After); err != nil {
+			return nil, fmt.Errorf("revision %d has an invalid ingredient: %w", revision.ID, err)
+		}
+
+		if revision.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+			return nil, err
+		}
+		results = append(results, &revision)
+	}
+	return results, rows.Err()
+}
+
+// sqlFindIngredientID returns the ID of the ingredient whose name or alias
+// matches the given one.
+func sqlFindIngredientID(ctx context.Context, q sqlExecutor, normalizedName string) (int, error) {
+	var id int
+	err := q.QueryRowContext(ctx, `SELECT id FROM ingredients WHERE name = ? AND deleted_at IS NULL
+		UNION ALL SELECT ingredient_id FROM ingredient_aliases WHERE name = ? AND deleted_at IS NULL
+		LIMIT 1`, normalizedName, normalizedName).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrIngredientNotFound
+	}
+	return id, err
+}
+
+func sqlIngredientNameExists(ctx context.Context, q sqlExecutor, normalizedName string) (bool, error) {
+	_, err := sqlFindIngredientID(ctx, q, normalizedName)
+	if errors.Is(err, ErrIngredientNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// sqlListIngredients returns the ingredients matching an optional WHERE
+// clause, ordered by ID and along with their aliases.
+func sqlListIngredients(ctx context.Context, q sqlExecutor, where string, args ...any) ([]*models.Ingredient, error) {
+	return sqlQueryIngredients(ctx, q, where+" ORDER BY id", args...)
+}
+
+// sqlQueryIngredients returns the ingredients selected by a clause that follows
+// FROM, such as a WHERE, an ORDER BY and a LIMIT, in that order.
+func sqlQueryIngredients(ctx context.Context, q sqlExecutor, clause string, args ...any) ([]*models.Ingredient, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id, name, category, dietary, nutrition, piece_weight_grams, piece_name,
+		density_g_per_ml, created_at, updated_at, deleted_at, version FROM ingredients `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]*models.Ingredient, 0)
+	byID := make(map[int]*models.Ingredient)
+	for rows.Next() {
+		ingredient, err := scanSQLIngredient(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, ingredient)
+		byID[ingredient.ID] = ingredient
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	aliasWhere := ""
+	if clause != "" {
+		aliasWhere = "WHERE ingredient_id IN (SELECT id FROM ingredients " + clause + ")"
+	}
+	aliasRows, err := q.QueryContext(ctx, `SELECT ingredient_id, name, locale FROM ingredient_aliases `+aliasWhere+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer aliasRows.Close()
+
+	for aliasRows.Next() {
+		var ingredientID int
+		var alias models.Alias
+		if err := aliasRows.Scan(&ingredientID, &alias.Name, &alias.Locale); err != nil {
+			return nil, err
+		}
+		if ingredient, ok := byID[ingredientID]; ok {
+			ingredient.Aliases = append(ingredient.Aliases, alias)
+		}
+	}
+	return results, aliasRows.Err()
+}
+
+func scanSQLIngredient(rows *sql.Rows) (*models.Ingredient, error) {
+	var ingredient models.Ingredient
+	var category, createdAt, updatedAt string
+	var dietary, nutrition, deletedAt sql.NullString
+
+	err := rows.Scan(&ingredient.ID, &ingredient.Name, &category, &dietary, &nutrition,
+		&ingredient.PieceWeightGrams, &ingredient.PieceName, &ingredient.DensityGPerMl, &createdAt, &updatedAt, &deletedAt, &ingredient.Version)
+	if err != nil {
+		return nil, err
+	}
+	ingredient.Category = models.Category(category)
+
+	if dietary.Valid {
+		ingredient.Dietary = &models.DietaryInfo{}
+		if err := json.Unmarshal([]byte(dietary.String), ingredient.Dietary); err != nil {
+			return nil, fmt.Errorf("ingredient %d has invalid dietary info: %w", ingredient.ID, err)
+		}
+	}
+
+	if nutrition.Valid {
+		ingredient.Nutrition = &models.NutritionFacts{}
+		if err := json.Unmarshal([]byte(nutrition.String), ingredient.Nutrition); err != nil {
+			return nil, fmt.Errorf("ingredient %d has invalid nutrition facts: %w", ingredient.ID, err)
+		}
+	}
+
+	if ingredient.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, err
+	}
+	if ingredient.UpdatedAt, err = time.Parse(time.RFC3339Nano, updatedAt); err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		t, err := time.Parse(time.RFC3339Nano, deletedAt.String)
+		if err != nil {
+			return nil, err
+		}
+		ingredient.DeletedAt = &t
+	}
+
+	return &ingredient, nil
+}
+
+// formatSQLTime formats a time the way it is stored, which keeps the stored
+// times sortable as text.
+func formatSQLTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000000Z07:00")
+}
+
+// isSQLUniqueViolation reports whether err is a unique constraint violation,
+// either from a unique index or from the triggers that keep ingredient names
+// and aliases apart. Those are the only triggers of the schema, so any trigger
+// aborting a statement is taken for one.
+func isSQLUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_TRIGGER
+}
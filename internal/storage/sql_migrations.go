@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrSQLSchemaIsTooNew = errors.New("database schema is newer than this version supports")
+
+// sqlMigration is a versioned change to the database schema. Released
+// migrations are never edited, every change to the schema goes in a new one.
+type sqlMigration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+// sqlMigrations are applied in order by migrateSQL.
+var sqlMigrations = []sqlMigration{
+	{
+		version:     1,
+		description: "create ingredients and ingredient aliases",
+		statements: []string{
+			`CREATE TABLE ingredients (
+				id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+				name               TEXT NOT NULL COLLATE NOCASE,
+				category           TEXT NOT NULL DEFAULT '',
+				dietary            TEXT,
+				nutrition          TEXT,
+				piece_weight_grams REAL NOT NULL DEFAULT 0,
+				piece_name         TEXT NOT NULL DEFAULT '',
+				density_g_per_ml   REAL NOT NULL DEFAULT 0,
+				created_at         TEXT NOT NULL,
+				updated_at         TEXT NOT NULL
+			)`,
+			`CREATE UNIQUE INDEX ingredients_name_idx ON ingredients (name COLLATE NOCASE)`,
+			`CREATE TABLE ingredient_aliases (
+				id            INTEGER PRIMARY KEY AUTOINCREMENT,
+				ingredient_id INTEGER NOT NULL REFERENCES ingredients (id) ON DELETE CASCADE,
+				name          TEXT NOT NULL COLLATE NOCASE,
+				locale        TEXT NOT NULL DEFAULT ''
+			)`,
+			`CREATE UNIQUE INDEX ingredient_aliases_name_idx ON ingredient_aliases (name COLLATE NOCASE)`,
+			`CREATE INDEX ingredient_aliases_ingredient_id_idx ON ingredient_aliases (ingredient_id)`,
+			// names and aliases share one namespace, which the unique indexes
+			// cannot enforce across tables on their own
+			`CREATE TRIGGER ingredients_name_insert BEFORE INSERT ON ingredients
+			WHEN EXISTS (SELECT 1 FROM ingredient_aliases WHERE name = NEW.name)
+			BEGIN
+				SELECT RAISE(ABORT, 'UNIQUE constraint failed: ingredient_aliases.name');
+			END`,
+			`CREATE TRIGGER ingredients_name_update BEFORE UPDATE OF name ON ingredients
+			WHEN EXISTS (SELECT 1 FROM ingredient_aliases WHERE name = NEW.name)
+			BEGIN
+				SELECT RAISE(ABORT, 'UNIQUE constraint failed: ingredient_aliases.name');
+			END`,
+			`CREATE TRIGGER ingredient_aliases_name_insert BEFORE INSERT ON ingredient_aliases
+			WHEN EXISTS (SELECT 1 FROM ingredients WHERE name = NEW.name)
+			BEGIN
+				SELECT RAISE(ABORT, 'UNIQUE constraint failed: ingredients.name');
+			END`,
+		},
+	},
+	{
+		version:     2,
+		description: "add the ingredient trash",
+		statements: []string{
+			// aliases carry the deletion date of their ingredient, so the
+			// unique indexes can leave out the names in the trash
+			`ALTER TABLE ingredients ADD COLUMN deleted_at TEXT`,
+			`ALTER TABLE ingredient_aliases ADD COLUMN deleted_at TEXT`,
+			`DROP INDEX ingredients_name_idx`,
+			`CREATE UNIQUE INDEX ingredients_name_idx ON ingredients (name COLLATE NOCASE) WHERE deleted_at IS NULL`,
+			`DROP INDEX ingredient_aliases_name_idx`,
+			`CREATE UNIQUE INDEX ingredient_aliases_name_idx ON ingredient_aliases (name COLLATE NOCASE) WHERE deleted_at IS NULL`,
+			`CREATE INDEX ingredients_deleted_at_idx ON ingredients (deleted_at) WHERE deleted_at IS NOT NULL`,
+			`DROP TRIGGER ingredients_name_insert`,
+			`DROP TRIGGER ingredients_name_update`,
+			`DROP TRIGGER ingredient_aliases_name_insert`,
+			`CREATE TRIGGER ingredients_name_insert BEFORE INSERT ON ingredients
+			WHEN NEW.deleted_at IS NULL
+				AND EXISTS (SELECT 1 FROM ingredient_aliases WHERE name = NEW.name AND deleted_at IS NULL)
+			BEGIN
+				SELECT RAISE(ABORT, 'UNIQUE constraint failed: ingredient_aliases.name');
+			END`,
+			`CREATE TRIGGER ingredients_name_update BEFORE UPDATE OF name, deleted_at ON ingredients
+			WHEN NEW.deleted_at IS NULL
+				AND EXISTS (SELECT 1 FROM ingredient_aliases WHERE name = NEW.name AND deleted_at IS NULL)
+			BEGIN
+				SELECT RAISE(ABORT, 'UNIQUE constraint failed: ingredient_aliases.name');
+			END`,
+			`CREATE TRIGGER ingredient_aliases_name_insert BEFORE INSERT ON ingredient_aliases
+			WHEN NEW.deleted_at IS NULL
+				AND EXISTS (SELECT 1 FROM ingredients WHERE name = NEW.name AND deleted_at IS NULL)
+			BEGIN
+				SELECT RAISE(ABORT, 'UNIQUE constraint failed: ingredients.name');
+			END`,
+			`CREATE TRIGGER ingredient_aliases_name_update BEFORE UPDATE OF name, deleted_at ON ingredient_aliases
+			WHEN NEW.deleted_at IS NULL
+				AND EXISTS (SELECT 1 FROM ingredients WHERE name = NEW.name AND deleted_at IS NULL)
+			BEGIN
+				SELECT RAISE(ABORT, 'UNIQUE constraint failed: ingredients.name');
+			END`,
+		},
+	},
+	{
+		version:     3,
+		description: "add ingredient revisions",
+		statements: []string{
+			// revisions outlive purged ingredients, so ingredient_id is not a
+			// foreign key
+			`CREATE TABLE ingredient_revisions (
+				id             INTEGER PRIMARY KEY AUTOINCREMENT,
+				ingredient_id  INTEGER NOT NULL,
+				kind           TEXT NOT NULL,
+				old_ingredient TEXT,
+				new_ingredient TEXT NOT NULL,
+				session_id     TEXT NOT NULL DEFAULT '',
+				reverts        INTEGER REFERENCES ingredient_revisions (id),
+				created_at     TEXT NOT NULL
+			)`,
+			`CREATE INDEX ingredient_revisions_ingredient_id_idx ON ingredient_revisions (ingredient_id)`,
+			`CREATE INDEX ingredient_revisions_session_id_idx ON ingredient_revisions (session_id)`,
+			`CREATE UNIQUE INDEX ingredient_revisions_reverts_idx ON ingredient_revisions (reverts) WHERE reverts IS NOT NULL`,
+		},
+	},
+	{
+		version:     4,
+		description: "add ingredient versions",
+		statements: []string{
+			`ALTER TABLE ingredients ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+		},
+	},
+}
+
+// migrateSQL brings the database schema up to date, applying every migration
+// that has not been applied yet, each one in its own transaction.
+func migrateSQL(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create the schema_migrations table: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read the schema version: %w", err)
+	}
+
+	latest := sqlMigrations[len(sqlMigrations)-1].version
+	if current > latest {
+		return fmt.Errorf("%w: schema version %d, latest known %d", ErrSQLSchemaIsTooNew, current, latest)
+	}
+
+	for _, migration := range sqlMigrations {
+		if migration.version <= current {
+			continue
+		}
+		if err := applySQLMigration(ctx, db, migration); err != nil {
+			return fmt.Errorf("failed to apply migration %d (%s): %w", migration.version, migration.description, err)
+		}
+	}
+	return nil
+}
+
+func applySQLMigration(ctx context.Context, db *sql.DB, migration sqlMigration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// another process may have applied it since the version was read
+	var applied bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = ?)`, migration.version).Scan(&applied)
+	if err != nil || applied {
+		return err
+	}
+
+	for _, statement := range migration.statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+		migration.version, formatSQLTime(time.Now()))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+var _ IngredientStorage = (*SQLStorage)(nil)
+
+func newTestSQLStorage(t *testing.T) *SQLStorage {
+	t.Helper()
+
+	storage, err := OpenSQLiteStorage(t.Context(), filepath.Join(t.TempDir(), "ingredients.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+
+	return storage
+}
+
+func TestSQLStorageMigrations(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("migrations are applied once", func(t *testing.T) {
+		storage := newTestSQLStorage(t)
+
+		if err := migrateSQL(ctx, storage.db); err != nil {
+			t.Fatalf("unexpected error migrating twice: %v", err)
+		}
+
+		var applied int
+		storage.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied)
+		if applied != len(sqlMigrations) {
+			t.Errorf("expected %d applied migrations, got %d", len(sqlMigrations), applied)
+		}
+	})
+
+	t.Run("newer schema is refused", func(t *testing.T) {
+		storage := newTestSQLStorage(t)
+		storage.db.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (99, '')`)
+
+		if _, err := NewSQLStorage(ctx, storage.db); !errors.Is(err, ErrSQLSchemaIsTooNew) {
+			t.Errorf("expected %v, got %v", ErrSQLSchemaIsTooNew, err)
+		}
+	})
+
+	t.Run("upgrades keep the stored ingredients", func(t *testing.T) {
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "ingredients.db"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer db.Close()
+
+		db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`)
+		if err := applySQLMigration(ctx, db, sqlMigrations[0]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		db.Exec(`INSERT INTO ingredients (name, created_at, updated_at) VALUES ('sal', ?, ?)`,
+			formatSQLTime(time.Now()), formatSQLTime(time.Now()))
+
+		storage, err := NewSQLStorage(ctx, db)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ingredient, err := storage.GetByName(ctx, "sal")
+		if err != nil {
+			t.Fatalf("expected the ingredient to survive the upgrade, got %v", err)
+		}
+		if ingredient.Version != 1 {
+			t.Errorf("expected version 1, got %d", ingredient.Version)
+		}
+	})
+
+	t.Run("in-memory database", func(t *testing.T) {
+		storage, err := OpenSQLiteStorage(ctx, ":memory:")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer storage.Close()
+
+		if _, err := storage.Create(ctx, "sal"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestSQLStorageConstraints(t *testing.T) {
+	ctx := t.Context()
+
+	storage := newTestSQLStorage(t)
+	storage.Create(ctx, "pimienta negra")
+	storage.AddAlias(ctx, "pimienta negra", "black pepper", "en", AnyVersion)
+	storage.Create(ctx, "sal")
+	storage.AddAlias(ctx, "sal", "salt", "en", AnyVersion)
+	storage.Delete(ctx, "sal", AnyVersion)
+	storage.Create(ctx, "SAL")
+	storage.AddAlias(ctx, "sal", "Salt", "en", AnyVersion)
+
+	// writes that skip the checks of SQLStorage are still refused by the schema
+	testCases := []struct {
+		name      string
+		statement string
+	}{
+		{"name differing in case", `INSERT INTO ingredients (name, created_at, updated_at) VALUES ('PIMIENTA NEGRA', '', '')`},
+		{"name taken by an alias", `INSERT INTO ingredients (name, created_at, updated_at) VALUES ('Black Pepper', '', '')`},
+		{"rename onto an alias", `UPDATE ingredients SET name = 'black pepper'`},
+		{"alias taken by a name", `INSERT INTO ingredient_aliases (ingredient_id, name) VALUES (1, 'Pimienta Negra')`},
+		{"alias differing in case", `INSERT INTO ingredient_aliases (ingredient_id, name) VALUES (1, 'BLACK PEPPER')`},
+		{"restore onto a taken name", `UPDATE ingredients SET deleted_at = NULL WHERE name = 'sal'`},
+		{"restore onto a taken alias", `UPDATE ingredient_aliases SET deleted_at = NULL WHERE name = 'salt'`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := storage.db.Exec(tc.statement)
+			if !isSQLUniqueViolation(err) {
+				t.Errorf("expected a unique constraint violation, got %v", err)
+			}
+		})
+	}
+
+	t.Run("other constraint violations", func(t *testing.T) {
+		_, err := storage.db.Exec(`INSERT INTO ingredients (name) VALUES ('comino')`)
+		if err == nil || isSQLUniqueViolation(err) {
+			t.Errorf("expected a constraint violation other than a unique one, got %v", err)
+		}
+	})
+}
+
+func TestSQLStorageBatchCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	storage := newTestSQLStorage(t)
+	names := []string{"sal", "leche", "pimienta"}
+
+	// the batch of CreateMany, cancelled while its second item is applied
+	applied := 0
+	err := storage.batch(ctx, len(names), func(tx *sql.Tx, i int) error {
+		applied++
+		_, err := sqlCreateIngredient(ctx, tx, names[i])
+		if i == 1 {
+			cancel()
+		}
+		return err
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if applied != 2 {
+		t.Errorf("expected the batch to stop after 2 items, applied %d", applied)
+	}
+
+	ingredients, _ := storage.List(t.Context())
+	if len(ingredients) != 0 {
+		t.Errorf("expected the whole batch to be rolled back, got %v", ingredients)
+	}
+}
+
+func TestSQLStoragePersistence(t *testing.T) {
+	ctx := t.Context()
+
+	path := filepath.Join(t.TempDir(), "ingredients.db")
+	storage, err := OpenSQLiteStorage(ctx, path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	storage.Create(ctx, "sal")
+	storage.Create(ctx, "leche")
+	storage.Create(ctx, "pimienta")
+	storage.AddAlias(ctx, "leche", "milk", "en", AnyVersion)
+	storage.SetCategory(ctx, "leche", models.CategoryDairy, AnyVersion)
+	storage.SetNutrition(ctx, "leche", models.NutritionFacts{CaloriesPer100g: 64}, AnyVersion)
+	storage.SetUnitConversion(ctx, "leche", models.UnitConversion{DensityGPerMl: 1.03}, AnyVersion)
+	storage.SetDietaryInfo(ctx, "leche", models.DietaryInfo{Allergens: []models.Allergen{models.AllergenDairy}}, AnyVersion)
+	storage.Delete(ctx, "pimienta", AnyVersion)
+	storage.Close()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reopened, err := NewSQLStorage(ctx, db)
+	if err != nil {
+		t.Fatalf("unexpected error reopening the database: %v", err)
+	}
+	defer reopened.Close()
+
+	milk, err := reopened.GetByName(ctx, "milk")
+	if err != nil {
+		t.Fatalf("expected to find the ingredient by its alias, got %v", err)
+	}
+
+	if milk.Category != models.CategoryDairy || milk.Nutrition == nil || milk.DensityGPerMl != 1.03 || milk.Dietary == nil || milk.Version != 6 {
+		t.Errorf("expected every attribute to be persisted, got %+v", milk)
+	}
+
+	created, _ := reopened.Create(ctx, "pimienta")
+	if created.ID != 4 {
+		t.Errorf("expected deleted IDs not to be reused, got %d", created.ID)
+	}
+}
@@ -0,0 +1,2281 @@
+// Package storagetest provides a conformance suite for implementations of
+// storage.IngredientStorage, so every backend is verified the same way.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+	"github.com/victorcete/recipe-manager/internal/storage"
+)
+
+// Factory returns a new, empty storage for a single test. It may register
+// cleanups on t, such as closing the storage.
+type Factory func(t *testing.T) storage.IngredientStorage
+
+// tests are the behavioral tests every IngredientStorage implementation must pass.
+var tests = []struct {
+	name string
+	test func(t *testing.T, newStorage Factory)
+}{
+	{"CreateIngredient", testCreateIngredient},
+	{"DeleteIngredient", testDeleteIngredient},
+	{"GetIngredient", testGetIngredient},
+	{"UpdateIngredient", testUpdateIngredient},
+	{"ListIngredients", testListIngredients},
+	{"ListPage", testListPage},
+	{"SetNutrition", testSetNutrition},
+	{"SetUnitConversion", testSetUnitConversion},
+	{"IngredientAliases", testIngredientAliases},
+	{"SetCategory", testSetCategory},
+	{"SetDietaryInfo", testSetDietaryInfo},
+	{"IngredientDetails", testIngredientDetails},
+	{"BatchOperations", testBatchOperations},
+	{"Trash", testTrash},
+	{"Revisions", testRevisions},
+	{"Versions", testVersions},
+	{"Watch", testWatch},
+	{"ContextCancellation", testContextCancellation},
+	{"Concurrency", testConcurrency},
+}
+
+// Run runs the whole IngredientStorage contract against the storages returned
+// by newStorage, each test in its own subtest.
+func Run(t *testing.T, newStorage Factory) {
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newStorage)
+		})
+	}
+}
+
+func testCreateIngredient(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("successful creation", func(t *testing.T) {
+		store := newStorage(t)
+		ingredient, err := store.Create(ctx, "tomato")
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.ID != 1 {
+			t.Errorf("expected ID 1, got %d", ingredient.ID)
+		}
+
+		if ingredient.Name != "tomato" {
+			t.Errorf("expected name 'tomato', got '%s'", ingredient.Name)
+		}
+
+		if ingredient.CreatedAt.IsZero() {
+			t.Errorf("creation date should not be zero")
+		}
+
+		if ingredient.UpdatedAt.IsZero() {
+			t.Errorf("update date should not be zero")
+		}
+	})
+
+	t.Run("duplicated ingredient names", func(t *testing.T) {
+		store := newStorage(t)
+		ingredient1 := "tomato"
+		ingredient2 := "tomato"
+
+		_, err := store.Create(ctx, ingredient1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_, err = store.Create(ctx, ingredient2)
+		if err != storage.ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameExists, err)
+		}
+	})
+
+	t.Run("case insensitive duplicates", func(t *testing.T) {
+		store := newStorage(t)
+		ingredient1 := "tomato"
+		ingredient2 := " TOMATO "
+
+		_, err := store.Create(ctx, ingredient1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_, err = store.Create(ctx, ingredient2)
+		if err != storage.ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameExists, err)
+		}
+	})
+
+	t.Run("empty ingredient name", func(t *testing.T) {
+		store := newStorage(t)
+		ingredient := ""
+
+		_, err := store.Create(ctx, ingredient)
+		if err != storage.ErrIngredientNameCannotBeEmpty {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameCannotBeEmpty, err)
+		}
+	})
+
+	t.Run("ingredient name is too short", func(t *testing.T) {
+		store := newStorage(t)
+		ingredient := "XD"
+
+		_, err := store.Create(ctx, ingredient)
+		if err != storage.ErrIngredientNameIsTooShort {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameIsTooShort, err)
+		}
+	})
+
+	t.Run("ingredient name is too long", func(t *testing.T) {
+		store := newStorage(t)
+		ingredient := "Super-Ultra-Mega-Long-Ingredient-Name-That-Goes-On-Forever"
+
+		_, err := store.Create(ctx, ingredient)
+		if err != storage.ErrIngredientNameIsTooLong {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameIsTooLong, err)
+		}
+	})
+
+	t.Run("whitespace normalization", func(t *testing.T) {
+		store := newStorage(t)
+		name := "  ChickeN   breast   "
+		normalizedName := "chicken breast"
+
+		ingredient, err := store.Create(ctx, name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.Name != normalizedName {
+			t.Errorf("expected normalized name %q, got %q", normalizedName, ingredient.Name)
+		}
+	})
+
+	t.Run("character regexp validation", func(t *testing.T) {
+		store := newStorage(t)
+
+		testCases := []struct {
+			name        string
+			input       string
+			shouldError bool
+			expectedErr error
+		}{
+			// valid cases
+			{"simple ingredient", "tomato", false, nil},
+			{"with apostrophe", "Mom's Sauce", false, nil},
+			{"with accent", "jalapeño", false, nil},
+			{"with hyphen", "extra-virgin", false, nil},
+			{"with number", "7-Spice Blend", false, nil},
+			{"mixed case", "Chicken Breast", false, nil},
+
+			// invalid cases
+			{"with quotes", `"salt"`, true, storage.ErrIngredientNameContainsInvalidChars},
+			{"with at symbol", "tom@to", true, storage.ErrIngredientNameContainsInvalidChars},
+			{"with brackets", "salt<script>", true, storage.ErrIngredientNameContainsInvalidChars},
+			{"with emoji", "🍅", true, storage.ErrIngredientNameContainsInvalidChars},
+			{"with parentheses", "salt (sea)", true, storage.ErrIngredientNameContainsInvalidChars},
+			{"with period", "Dr. Pepper", true, storage.ErrIngredientNameContainsInvalidChars},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := store.Create(ctx, tc.input)
+
+				if tc.shouldError {
+					if err != tc.expectedErr {
+						t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+					}
+				} else {
+					if err != nil {
+						t.Errorf("expected no error, got %v", err)
+					}
+				}
+			})
+		}
+	})
+}
+
+func testDeleteIngredient(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("successful single delete", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "tomato")
+
+		err := store.Delete(ctx, "tomato", storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ingredients, _ := store.List(ctx)
+		for _, ingredient := range ingredients {
+			if ingredient.Name == "tomato" {
+				t.Errorf("tomato should have been deleted but was still found")
+			}
+		}
+	})
+
+	t.Run("successful multiple deletion", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "tomato")
+		store.Create(ctx, "basil")
+
+		err := store.Delete(ctx, "tomato", storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err = store.Delete(ctx, "basil", storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ingredients, _ := store.List(ctx)
+		for _, ingredient := range ingredients {
+			if ingredient.Name == "tomato" {
+				t.Errorf("tomato should have been deleted but was still found")
+			}
+		}
+		for _, ingredient := range ingredients {
+			if ingredient.Name == "basil" {
+				t.Errorf("basil should have been deleted but was still found")
+			}
+		}
+	})
+
+	t.Run("case insensitive delete", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "tomato")
+
+		err := store.Delete(ctx, "  TOMATO ", storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ingredients, _ := store.List(ctx)
+		for _, ingredient := range ingredients {
+			if ingredient.Name == "tomato" {
+				t.Errorf("tomato should have been deleted but was still found")
+			}
+		}
+	})
+
+	t.Run("ingredient not found", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "tomato")
+
+		err := store.Delete(ctx, "brotato", storage.AnyVersion)
+		if err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+
+	t.Run("delete from empty storage", func(t *testing.T) {
+		store := newStorage(t)
+
+		err := store.Delete(ctx, "brotato", storage.AnyVersion)
+		if err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+}
+
+func testGetIngredient(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("get by ID", func(t *testing.T) {
+		store := newStorage(t)
+		created, _ := store.Create(ctx, "tomato")
+
+		ingredient, err := store.Get(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.ID != created.ID || ingredient.Name != "tomato" {
+			t.Errorf("expected ingredient %d named tomato, got %d named %q", created.ID, ingredient.ID, ingredient.Name)
+		}
+
+		if !ingredient.CreatedAt.Equal(created.CreatedAt) || !ingredient.UpdatedAt.Equal(created.UpdatedAt) {
+			t.Errorf("expected timestamps %v and %v, got %v and %v", created.CreatedAt, created.UpdatedAt, ingredient.CreatedAt, ingredient.UpdatedAt)
+		}
+	})
+
+	t.Run("ID not found", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "tomato")
+
+		for _, id := range []int{0, -1, 2} {
+			if _, err := store.Get(ctx, id); err != storage.ErrIngredientNotFound {
+				t.Errorf("expected %v for ID %d, got %v", storage.ErrIngredientNotFound, id, err)
+			}
+		}
+	})
+
+	t.Run("normalized name lookup", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "chicken breast")
+
+		ingredient, err := store.GetByName(ctx, "  Chicken   BREAST ")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.Name != "chicken breast" {
+			t.Errorf("expected name %q, got %q", "chicken breast", ingredient.Name)
+		}
+	})
+
+	t.Run("lookup by alias", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "pechuga de pollo")
+		store.AddAlias(ctx, "pechuga de pollo", "chicken breast", "en", storage.AnyVersion)
+
+		ingredient, err := store.GetByName(ctx, "Chicken Breast")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.Name != "pechuga de pollo" {
+			t.Errorf("expected name %q, got %q", "pechuga de pollo", ingredient.Name)
+		}
+	})
+
+	t.Run("name not found", func(t *testing.T) {
+		store := newStorage(t)
+
+		_, err := store.GetByName(ctx, "brotato")
+		if err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+
+	t.Run("empty name", func(t *testing.T) {
+		store := newStorage(t)
+
+		_, err := store.GetByName(ctx, "   ")
+		if err != storage.ErrIngredientNameCannotBeEmpty {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameCannotBeEmpty, err)
+		}
+	})
+
+	t.Run("changing returned ingredients leaves the storage untouched", func(t *testing.T) {
+		store := newStorage(t)
+		created, _ := store.Create(ctx, "tomato")
+		store.AddAlias(ctx, "tomato", "tomate", "es", storage.AnyVersion)
+		store.SetNutrition(ctx, "tomato", models.NutritionFacts{CaloriesPer100g: 18}, storage.AnyVersion)
+
+		fetched, _ := store.Get(ctx, created.ID)
+		byName, _ := store.GetByName(ctx, "tomate")
+		listed, _ := store.List(ctx)
+		history, _ := store.History(ctx, created.ID)
+
+		changed := []*models.Ingredient{created, fetched, byName, listed[0]}
+		for _, revision := range history {
+			changed = append(changed, revision.After)
+		}
+		for _, ingredient := range changed {
+			ingredient.Name = "changed"
+			if len(ingredient.Aliases) > 0 {
+				ingredient.Aliases[0].Name = "changed"
+			}
+			if ingredient.Nutrition != nil {
+				ingredient.Nutrition.CaloriesPer100g = 0
+			}
+		}
+
+		ingredient, err := store.GetByName(ctx, "tomate")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ingredient.Name != "tomato" || ingredient.Aliases[0].Name != "tomate" || ingredient.Nutrition.CaloriesPer100g != 18 {
+			t.Errorf("expected the stored ingredient to be unchanged, got %+v", ingredient)
+		}
+
+		history, _ = store.History(ctx, created.ID)
+		for _, revision := range history {
+			if revision.After.Name != "tomato" {
+				t.Errorf("expected revision %d to be unchanged, got name %q", revision.ID, revision.After.Name)
+			}
+		}
+	})
+}
+
+func testUpdateIngredient(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("successful update", func(t *testing.T) {
+		store := newStorage(t)
+
+		originalName := "tomato"
+		store.Create(ctx, originalName)
+		newName := "potato"
+
+		time.Sleep(5 * time.Millisecond)
+
+		ingredient, err := store.Update(ctx, originalName, newName, storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ingredient.Name != newName {
+			t.Errorf("expected name %q, got %q", ingredient.Name, newName)
+		}
+		if !ingredient.UpdatedAt.After(ingredient.CreatedAt) {
+			t.Errorf("UpdatedAt should be after CreatedAt")
+		}
+	})
+
+	t.Run("ingredient not found", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "tomato")
+
+		_, err := store.Update(ctx, "brotato", "potato", storage.AnyVersion)
+		if err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+
+	t.Run("replaced names are released", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "tomato")
+		store.Create(ctx, "pimienta negra")
+		store.AddAlias(ctx, "pimienta negra", "black pepper", "en", storage.AnyVersion)
+
+		store.Update(ctx, "tomato", "potato", storage.AnyVersion)
+		store.RemoveAlias(ctx, "pimienta negra", "black pepper", storage.AnyVersion)
+		store.Delete(ctx, "pimienta negra", storage.AnyVersion)
+
+		if _, err := store.GetByName(ctx, "tomato"); err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+
+		for _, name := range []string{"tomato", "black pepper", "pimienta negra"} {
+			if _, err := store.Create(ctx, name); err != nil {
+				t.Errorf("expected %q to be free, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		store := newStorage(t)
+		originalName := "tomato"
+		store.Create(ctx, originalName)
+
+		_, err := store.Update(ctx, originalName, "tomato", storage.AnyVersion)
+		if err != storage.ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameExists, err)
+		}
+
+		_, err = store.Update(ctx, originalName, " tomato ", storage.AnyVersion)
+		if err != storage.ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameExists, err)
+		}
+
+		_, err = store.Update(ctx, originalName, "ToMaTo   ", storage.AnyVersion)
+		if err != storage.ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameExists, err)
+		}
+
+		_, err = store.Update(ctx, originalName, "", storage.AnyVersion)
+		if err != storage.ErrIngredientNameCannotBeEmpty {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameCannotBeEmpty, err)
+		}
+
+		_, err = store.Update(ctx, originalName, "a", storage.AnyVersion)
+		if err != storage.ErrIngredientNameIsTooShort {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameIsTooShort, err)
+		}
+
+		_, err = store.Update(ctx, originalName, "Super-Ultra-Mega-Long-Ingredient-Name-That-Goes-On-Forever", storage.AnyVersion)
+		if err != storage.ErrIngredientNameIsTooLong {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameIsTooLong, err)
+		}
+
+		_, err = store.Update(ctx, originalName, "<!!tomato>", storage.AnyVersion)
+		if err != storage.ErrIngredientNameContainsInvalidChars {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameContainsInvalidChars, err)
+		}
+	})
+
+	t.Run("updating a different existing ingredient name", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "tomato")
+		store.Create(ctx, "basil")
+
+		_, err := store.Update(ctx, "tomato", "basil", storage.AnyVersion)
+		if err != storage.ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameExists, err)
+		}
+	})
+}
+
+func testListIngredients(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("list returns valid count and names", func(t *testing.T) {
+		store := newStorage(t)
+
+		store.Create(ctx, "tomato")
+		store.Create(ctx, "basil")
+		store.Create(ctx, "cheese")
+		expectedItems := 3
+		expectedNames := []string{"tomato", "basil", "cheese"}
+
+		results, _ := store.List(ctx)
+
+		if len(results) != expectedItems {
+			t.Errorf("expected %d ingredients, got %d", expectedItems, len(results))
+		}
+
+		// build a map of resulted ingredient names
+		foundNames := make(map[string]bool)
+		for _, ingredient := range results {
+			foundNames[ingredient.Name] = true
+		}
+
+		// check each expected name exists
+		for _, expectedName := range expectedNames {
+			if !foundNames[expectedName] {
+				t.Errorf("expected ingredient %q not found", expectedName)
+			}
+		}
+	})
+
+	t.Run("list returns valid IDs", func(t *testing.T) {
+		store := newStorage(t)
+
+		store.Create(ctx, "tomato")
+		store.Create(ctx, "basil")
+		store.Create(ctx, "cheese")
+		expectedIDs := []int{1, 2, 3}
+
+		results, _ := store.List(ctx)
+
+		// build a map of resulted ingredient IDs
+		foundIDs := make(map[int]bool)
+		for _, ingredient := range results {
+			foundIDs[ingredient.ID] = true
+		}
+
+		// check each expected ID exists
+		for _, expectedID := range expectedIDs {
+			if !foundIDs[expectedID] {
+				t.Errorf("expected ingredient %d not found", expectedID)
+			}
+		}
+	})
+
+	t.Run("list returns valid timestamps", func(t *testing.T) {
+		store := newStorage(t)
+
+		store.Create(ctx, "tomato")
+		store.Create(ctx, "basil")
+		store.Create(ctx, "cheese")
+
+		results, _ := store.List(ctx)
+
+		// check all timestamps are not zero
+		for _, ingredient := range results {
+			if ingredient.CreatedAt.IsZero() {
+				t.Errorf("ingredient %q CreatedAt value is zero", ingredient.Name)
+			}
+			if ingredient.UpdatedAt.IsZero() {
+				t.Errorf("ingredient %q UpdatedAt value is zero", ingredient.Name)
+			}
+		}
+
+	})
+
+	t.Run("empty storage returns empty slice", func(t *testing.T) {
+		store := newStorage(t)
+		results, _ := store.List(ctx)
+
+		if len(results) != 0 {
+			t.Errorf("expected empty storage, got %v", results)
+		}
+
+		if results == nil {
+			t.Errorf("expected non-nil slice, got %v", results)
+		}
+	})
+}
+
+func testListPage(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	// newListStorage returns a storage holding basil, apple, cheese and apricot,
+	// created in that order, with cheese updated last
+	newListStorage := func(t *testing.T) storage.IngredientStorage {
+		store := newStorage(t)
+		for _, name := range []string{"basil", "apple", "cheese", "apricot"} {
+			if _, err := store.Create(ctx, name); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if _, err := store.SetCategory(ctx, "cheese", models.CategoryDairy, storage.AnyVersion); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return store
+	}
+
+	listNames := func(ingredients []*models.Ingredient) []string {
+		names := make([]string, 0, len(ingredients))
+		for _, ingredient := range ingredients {
+			names = append(names, ingredient.Name)
+		}
+		return names
+	}
+
+	t.Run("sorts by each field in both directions", func(t *testing.T) {
+		store := newListStorage(t)
+
+		cases := []struct {
+			options  storage.ListOptions
+			expected []string
+		}{
+			{storage.ListOptions{}, []string{"basil", "apple", "cheese", "apricot"}},
+			{storage.ListOptions{SortBy: storage.SortByID, Descending: true}, []string{"apricot", "cheese", "apple", "basil"}},
+			{storage.ListOptions{SortBy: storage.SortByName}, []string{"apple", "apricot", "basil", "cheese"}},
+			{storage.ListOptions{SortBy: storage.SortByName, Descending: true}, []string{"cheese", "basil", "apricot", "apple"}},
+			{storage.ListOptions{SortBy: storage.SortByCreatedAt}, []string{"basil", "apple", "cheese", "apricot"}},
+			{storage.ListOptions{SortBy: storage.SortByCreatedAt, Descending: true}, []string{"apricot", "cheese", "apple", "basil"}},
+			{storage.ListOptions{SortBy: storage.SortByUpdatedAt}, []string{"basil", "apple", "apricot", "cheese"}},
+			{storage.ListOptions{SortBy: storage.SortByUpdatedAt, Descending: true}, []string{"cheese", "apricot", "apple", "basil"}},
+		}
+
+		for _, tc := range cases {
+			page, err := store.ListPage(ctx, tc.options)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if names := listNames(page.Ingredients); fmt.Sprint(names) != fmt.Sprint(tc.expected) {
+				t.Errorf("sorting by %q (descending %v): expected %v, got %v", tc.options.SortBy, tc.options.Descending, tc.expected, names)
+			}
+			if page.Total != 4 || page.Offset != 0 || page.NextCursor != "" {
+				t.Errorf("expected a single page of 4, got total %d, offset %d and cursor %q", page.Total, page.Offset, page.NextCursor)
+			}
+		}
+	})
+
+	t.Run("ties are broken by ID", func(t *testing.T) {
+		store := newStorage(t)
+		store.CreateMany(ctx, []string{"tomato", "basil", "cheese"})
+
+		// every ingredient is uncategorized, so only the ID orders them
+		page, _ := store.ListPage(ctx, storage.ListOptions{SortBy: storage.SortByName, Descending: true, Limit: 2})
+		if names := listNames(page.Ingredients); fmt.Sprint(names) != "[tomato cheese]" {
+			t.Errorf("expected [tomato cheese], got %v", names)
+		}
+	})
+
+	t.Run("cursors page through every ingredient once", func(t *testing.T) {
+		store := newListStorage(t)
+
+		for _, descending := range []bool{false, true} {
+			options := storage.ListOptions{SortBy: storage.SortByName, Descending: descending, Limit: 3}
+			all, _ := store.ListPage(ctx, storage.ListOptions{SortBy: storage.SortByName, Descending: descending})
+
+			var names []string
+			for pages := 0; ; pages++ {
+				page, err := store.ListPage(ctx, options)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if page.Offset != len(names) {
+					t.Errorf("expected offset %d, got %d", len(names), page.Offset)
+				}
+				names = append(names, listNames(page.Ingredients)...)
+
+				if page.NextCursor == "" {
+					if pages != 1 {
+						t.Errorf("expected 2 pages, got %d", pages+1)
+					}
+					break
+				}
+				options.Cursor = page.NextCursor
+			}
+
+			if fmt.Sprint(names) != fmt.Sprint(listNames(all.Ingredients)) {
+				t.Errorf("expected %v, got %v", listNames(all.Ingredients), names)
+			}
+		}
+	})
+
+	t.Run("a full last page has no cursor", func(t *testing.T) {
+		store := newListStorage(t)
+
+		page, _ := store.ListPage(ctx, storage.ListOptions{Limit: 4})
+		if len(page.Ingredients) != 4 || page.NextCursor != "" {
+			t.Errorf("expected 4 ingredients and no cursor, got %d and %q", len(page.Ingredients), page.NextCursor)
+		}
+	})
+
+	t.Run("changes between pages neither skip nor repeat ingredients", func(t *testing.T) {
+		store := newListStorage(t)
+
+		options := storage.ListOptions{SortBy: storage.SortByName, Limit: 2}
+		first, _ := store.ListPage(ctx, options)
+
+		// apple and apricot were listed; remove one and add one on each side
+		store.Delete(ctx, "apple", storage.AnyVersion)
+		store.Create(ctx, "almond")
+		store.Create(ctx, "banana")
+
+		options.Cursor = first.NextCursor
+		second, err := store.ListPage(ctx, options)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if names := listNames(second.Ingredients); fmt.Sprint(names) != "[banana basil]" {
+			t.Errorf("expected [banana basil], got %v", names)
+		}
+		if second.Offset != 2 || second.Total != 5 {
+			t.Errorf("expected offset 2 of 5, got %d of %d", second.Offset, second.Total)
+		}
+	})
+
+	t.Run("filters by category", func(t *testing.T) {
+		store := newListStorage(t)
+
+		dairy := models.CategoryDairy
+		page, _ := store.ListPage(ctx, storage.ListOptions{Category: &dairy})
+		if names := listNames(page.Ingredients); fmt.Sprint(names) != "[cheese]" || page.Total != 1 {
+			t.Errorf("expected only cheese, got %v of %d", names, page.Total)
+		}
+
+		uncategorized := models.CategoryUncategorized
+		page, _ = store.ListPage(ctx, storage.ListOptions{Category: &uncategorized, Limit: 2})
+		if names := listNames(page.Ingredients); fmt.Sprint(names) != "[basil apple]" || page.Total != 3 {
+			t.Errorf("expected [basil apple] of 3, got %v of %d", names, page.Total)
+		}
+	})
+
+	t.Run("empty storage returns an empty page", func(t *testing.T) {
+		store := newStorage(t)
+
+		page, err := store.ListPage(ctx, storage.ListOptions{Limit: 10})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if page.Ingredients == nil || len(page.Ingredients) != 0 || page.Total != 0 || page.NextCursor != "" {
+			t.Errorf("expected an empty page, got %+v", page)
+		}
+	})
+
+	t.Run("trashed ingredients are not listed", func(t *testing.T) {
+		store := newListStorage(t)
+		store.Delete(ctx, "basil", storage.AnyVersion)
+
+		page, _ := store.ListPage(ctx, storage.ListOptions{})
+		if names := listNames(page.Ingredients); fmt.Sprint(names) != "[apple cheese apricot]" {
+			t.Errorf("expected [apple cheese apricot], got %v", names)
+		}
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		store := newListStorage(t)
+		first, _ := store.ListPage(ctx, storage.ListOptions{SortBy: storage.SortByName, Limit: 1})
+		unknown := models.Category("snacks")
+
+		cases := []struct {
+			name     string
+			options  storage.ListOptions
+			expected error
+		}{
+			{"unknown sort field", storage.ListOptions{SortBy: "calories"}, storage.ErrListSortInvalid},
+			{"negative limit", storage.ListOptions{Limit: -1}, storage.ErrListLimitInvalid},
+			{"unknown category", storage.ListOptions{Category: &unknown}, storage.ErrIngredientCategoryInvalid},
+			{"malformed cursor", storage.ListOptions{Cursor: "not a cursor"}, storage.ErrListCursorInvalid},
+			{"cursor of another sort", storage.ListOptions{SortBy: storage.SortByID, Cursor: first.NextCursor}, storage.ErrListCursorInvalid},
+			{"cursor of another direction", storage.ListOptions{SortBy: storage.SortByName, Descending: true, Cursor: first.NextCursor}, storage.ErrListCursorInvalid},
+		}
+
+		for _, tc := range cases {
+			if _, err := store.ListPage(ctx, tc.options); err != tc.expected {
+				t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, err)
+			}
+		}
+	})
+}
+
+func testSetNutrition(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("successful update", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "pollo")
+
+		time.Sleep(5 * time.Millisecond)
+
+		facts := models.NutritionFacts{CaloriesPer100g: 120, ProteinPer100g: 22, FatPer100g: 3}
+		ingredient, err := store.SetNutrition(ctx, " POLLO ", facts, storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.Nutrition == nil || *ingredient.Nutrition != facts {
+			t.Errorf("expected nutrition %+v, got %+v", facts, ingredient.Nutrition)
+		}
+
+		if !ingredient.UpdatedAt.After(ingredient.CreatedAt) {
+			t.Errorf("UpdatedAt should be after CreatedAt")
+		}
+	})
+
+	t.Run("invalid facts", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "pollo")
+
+		_, err := store.SetNutrition(ctx, "pollo", models.NutritionFacts{CaloriesPer100g: -5}, storage.AnyVersion)
+		if err != storage.ErrNutritionValueIsNegative {
+			t.Errorf("expected %v, got %v", storage.ErrNutritionValueIsNegative, err)
+		}
+	})
+
+	t.Run("ingredient not found", func(t *testing.T) {
+		store := newStorage(t)
+
+		_, err := store.SetNutrition(ctx, "brotato", models.NutritionFacts{}, storage.AnyVersion)
+		if err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+}
+
+func testSetUnitConversion(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("successful update", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "ajo fresco")
+
+		ingredient, err := store.SetUnitConversion(ctx, "ajo fresco", models.UnitConversion{PieceWeightGrams: 5, PieceName: "Diente"}, storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.PieceWeightGrams != 5 || ingredient.PieceName != "diente" {
+			t.Errorf("unexpected unit conversion %+v", ingredient.UnitConversion)
+		}
+	})
+
+	t.Run("invalid conversion", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "ajo fresco")
+
+		_, err := store.SetUnitConversion(ctx, "ajo fresco", models.UnitConversion{PieceName: "diente"}, storage.AnyVersion)
+		if err != storage.ErrPieceNameRequiresWeight {
+			t.Errorf("expected %v, got %v", storage.ErrPieceNameRequiresWeight, err)
+		}
+	})
+
+	t.Run("ingredient not found", func(t *testing.T) {
+		store := newStorage(t)
+
+		_, err := store.SetUnitConversion(ctx, "brotato", models.UnitConversion{}, storage.AnyVersion)
+		if err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+}
+
+func testIngredientAliases(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("add alias", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "pimienta negra")
+
+		ingredient, err := store.AddAlias(ctx, "pimienta negra", "  Black   Pepper ", "EN_us", storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(ingredient.Aliases) != 1 {
+			t.Fatalf("expected 1 alias, got %d", len(ingredient.Aliases))
+		}
+
+		alias := ingredient.Aliases[0]
+		if alias.Name != "black pepper" || alias.Locale != "en-US" {
+			t.Errorf("expected alias black pepper (en-US), got %s (%s)", alias.Name, alias.Locale)
+		}
+	})
+
+	t.Run("aliases participate in uniqueness", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "pimienta negra")
+		store.Create(ctx, "pimienta blanca")
+		store.AddAlias(ctx, "pimienta negra", "black pepper", "en", storage.AnyVersion)
+
+		_, err := store.Create(ctx, "Black Pepper")
+		if err != storage.ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameExists, err)
+		}
+
+		_, err = store.Update(ctx, "pimienta blanca", "black pepper", storage.AnyVersion)
+		if err != storage.ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameExists, err)
+		}
+
+		_, err = store.AddAlias(ctx, "pimienta blanca", "black pepper", "en", storage.AnyVersion)
+		if err != storage.ErrIngredientAliasExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientAliasExists, err)
+		}
+
+		_, err = store.AddAlias(ctx, "pimienta blanca", "pimienta negra", "", storage.AnyVersion)
+		if err != storage.ErrIngredientAliasExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientAliasExists, err)
+		}
+	})
+
+	t.Run("update and delete resolve aliases", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "pimienta negra")
+		store.AddAlias(ctx, "pimienta negra", "black pepper", "en", storage.AnyVersion)
+
+		ingredient, err := store.Update(ctx, "black pepper", "pimienta molida", storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.Name != "pimienta molida" {
+			t.Errorf("expected name %q, got %q", "pimienta molida", ingredient.Name)
+		}
+
+		if _, err := store.GetByName(ctx, "black pepper"); err != nil {
+			t.Errorf("expected alias to survive a rename")
+		}
+
+		err = store.Delete(ctx, "BLACK PEPPER", storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ingredients, _ := store.List(ctx)
+		if len(ingredients) != 0 {
+			t.Errorf("expected ingredient to be deleted through its alias, got %d ingredients", len(ingredients))
+		}
+	})
+
+	t.Run("remove alias", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "pimienta negra")
+		store.AddAlias(ctx, "pimienta negra", "black pepper", "en", storage.AnyVersion)
+
+		ingredient, err := store.RemoveAlias(ctx, "pimienta negra", "Black Pepper", storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(ingredient.Aliases) != 0 {
+			t.Errorf("expected no aliases, got %v", ingredient.Aliases)
+		}
+
+		_, err = store.RemoveAlias(ctx, "pimienta negra", "black pepper", storage.AnyVersion)
+		if err != storage.ErrIngredientAliasNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientAliasNotFound, err)
+		}
+	})
+
+	t.Run("alias validation errors", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "pimienta negra")
+
+		_, err := store.AddAlias(ctx, "pimienta negra", "xd", "en", storage.AnyVersion)
+		if err != storage.ErrIngredientNameIsTooShort {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameIsTooShort, err)
+		}
+
+		_, err = store.AddAlias(ctx, "pimienta negra", "black pepper", "english", storage.AnyVersion)
+		if err != storage.ErrIngredientAliasLocaleInvalid {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientAliasLocaleInvalid, err)
+		}
+
+		_, err = store.AddAlias(ctx, "brotato", "black pepper", "en", storage.AnyVersion)
+		if err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+
+	t.Run("too many aliases", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "pimienta negra")
+
+		for i := 0; i < storage.IngredientMaxAliases; i++ {
+			if _, err := store.AddAlias(ctx, "pimienta negra", fmt.Sprintf("pepper %d", i), "", storage.AnyVersion); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		_, err := store.AddAlias(ctx, "pimienta negra", "one pepper too many", "", storage.AnyVersion)
+		if err != storage.ErrIngredientTooManyAliases {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientTooManyAliases, err)
+		}
+	})
+
+	t.Run("seed data ships english aliases", func(t *testing.T) {
+		store := newStorage(t)
+		store.SeedTestData(ctx)
+
+		if _, err := store.GetByName(ctx, "black pepper"); err != nil {
+			t.Errorf("expected seeded ingredients to be reachable by their english name")
+		}
+	})
+}
+
+func testSetCategory(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("successful update", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "comino")
+
+		ingredient, err := store.SetCategory(ctx, "comino", " Spices ", storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.Category != models.CategorySpices {
+			t.Errorf("expected category %q, got %q", models.CategorySpices, ingredient.Category)
+		}
+
+		ingredient, err = store.SetCategory(ctx, "comino", models.CategoryUncategorized, storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.Category != models.CategoryUncategorized {
+			t.Errorf("expected category to be cleared, got %q", ingredient.Category)
+		}
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "comino")
+
+		_, err := store.SetCategory(ctx, "comino", "snacks", storage.AnyVersion)
+		if err != storage.ErrIngredientCategoryInvalid {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientCategoryInvalid, err)
+		}
+	})
+
+	t.Run("ingredient not found", func(t *testing.T) {
+		store := newStorage(t)
+
+		_, err := store.SetCategory(ctx, "brotato", models.CategoryProduce, storage.AnyVersion)
+		if err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+
+	t.Run("seed data ships categorized", func(t *testing.T) {
+		store := newStorage(t)
+		seeded, _ := store.SeedTestData(ctx)
+
+		for _, ingredient := range seeded {
+			if ingredient.Category == models.CategoryUncategorized {
+				t.Errorf("expected seeded ingredient %q to have a category", ingredient.Name)
+			}
+		}
+	})
+}
+
+func testSetDietaryInfo(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("successful update", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "harina")
+
+		ingredient, err := store.SetDietaryInfo(ctx, "harina", models.DietaryInfo{
+			Allergens: []models.Allergen{models.AllergenGluten},
+			Diets:     []models.Diet{models.DietVegan},
+		}, storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.Dietary == nil || !ingredient.Dietary.Contains(models.AllergenGluten) {
+			t.Errorf("expected gluten to be declared, got %+v", ingredient.Dietary)
+		}
+
+		if !ingredient.Dietary.SuitableFor(models.DietVegetarian) {
+			t.Errorf("expected vegan ingredient to be vegetarian too, got %+v", ingredient.Dietary)
+		}
+	})
+
+	t.Run("invalid info", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "leche")
+
+		_, err := store.SetDietaryInfo(ctx, "leche", models.DietaryInfo{
+			Allergens: []models.Allergen{models.AllergenDairy},
+			Diets:     []models.Diet{models.DietVegan},
+		}, storage.AnyVersion)
+		if err != storage.ErrDietaryDietConflict {
+			t.Errorf("expected %v, got %v", storage.ErrDietaryDietConflict, err)
+		}
+	})
+
+	t.Run("ingredient not found", func(t *testing.T) {
+		store := newStorage(t)
+
+		_, err := store.SetDietaryInfo(ctx, "brotato", models.DietaryInfo{}, storage.AnyVersion)
+		if err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+}
+
+func testIngredientDetails(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	category := models.Category(" Dairy ")
+	facts := models.NutritionFacts{CaloriesPer100g: 64, FatPer100g: 3.6}
+	conversion := models.UnitConversion{DensityGPerMl: 1.03, PieceWeightGrams: 1000, PieceName: " Brick "}
+	details := storage.IngredientDetails{Category: &category, Nutrition: &facts, UnitConversion: &conversion}
+
+	t.Run("create with details", func(t *testing.T) {
+		store := newStorage(t)
+
+		ingredient, err := store.CreateWithDetails(ctx, " Leche ", details)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.Name != "leche" || ingredient.Category != models.CategoryDairy || ingredient.Nutrition == nil || *ingredient.Nutrition != facts {
+			t.Errorf("expected every detail to be set, got %+v", ingredient)
+		}
+		if ingredient.UnitConversion.PieceName != "brick" || ingredient.UnitConversion.DensityGPerMl != 1.03 {
+			t.Errorf("expected the normalized unit conversion, got %+v", ingredient.UnitConversion)
+		}
+
+		// the create and each detail are recorded as revisions of their own
+		history, _ := store.History(ctx, ingredient.ID)
+		if len(history) != 4 || ingredient.Version != 4 {
+			t.Errorf("expected 4 revisions and version 4, got %d and %d", len(history), ingredient.Version)
+		}
+	})
+
+	t.Run("create without details", func(t *testing.T) {
+		store := newStorage(t)
+
+		ingredient, err := store.CreateWithDetails(ctx, "sal", storage.IngredientDetails{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.Version != 1 || ingredient.Category != models.CategoryUncategorized {
+			t.Errorf("expected a plain new ingredient, got %+v", ingredient)
+		}
+	})
+
+	t.Run("invalid details create nothing", func(t *testing.T) {
+		store := newStorage(t)
+
+		invalid := models.NutritionFacts{CaloriesPer100g: -5}
+		_, err := store.CreateWithDetails(ctx, "leche", storage.IngredientDetails{Category: &category, Nutrition: &invalid})
+		if err != storage.ErrNutritionValueIsNegative {
+			t.Errorf("expected %v, got %v", storage.ErrNutritionValueIsNegative, err)
+		}
+
+		if _, err := store.GetByName(ctx, "leche"); err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+
+	t.Run("update with a new name and details", func(t *testing.T) {
+		store := newStorage(t)
+		created, _ := store.Create(ctx, "leche")
+
+		ingredient, err := store.UpdateWithDetails(ctx, "leche", "leche entera", details, created.Version)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.Name != "leche entera" || ingredient.Category != models.CategoryDairy || ingredient.Version != 5 {
+			t.Errorf("expected the rename and every detail, got %+v", ingredient)
+		}
+	})
+
+	t.Run("update keeping the name", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "leche")
+
+		ingredient, err := store.UpdateWithDetails(ctx, "leche", "", storage.IngredientDetails{Category: &category}, storage.AnyVersion)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredient.Name != "leche" || ingredient.Category != models.CategoryDairy || ingredient.Version != 2 {
+			t.Errorf("expected only the category to change, got %+v", ingredient)
+		}
+	})
+
+	t.Run("a refused update changes nothing", func(t *testing.T) {
+		store := newStorage(t)
+		created, _ := store.Create(ctx, "leche")
+		store.Create(ctx, "nata")
+
+		if _, err := store.UpdateWithDetails(ctx, "leche", "nata", details, storage.AnyVersion); err != storage.ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameExists, err)
+		}
+
+		if _, err := store.UpdateWithDetails(ctx, "leche", "leche entera", details, created.Version+1); err != storage.ErrIngredientVersionConflict {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientVersionConflict, err)
+		}
+
+		ingredient, _ := store.Get(ctx, created.ID)
+		if ingredient.Name != "leche" || ingredient.Category != models.CategoryUncategorized || ingredient.Nutrition != nil || ingredient.Version != 1 {
+			t.Errorf("expected the ingredient to be untouched, got %+v", ingredient)
+		}
+	})
+
+	t.Run("ingredient not found", func(t *testing.T) {
+		store := newStorage(t)
+
+		_, err := store.UpdateWithDetails(ctx, "brotato", "", details, storage.AnyVersion)
+		if err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+}
+
+func testBatchOperations(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("create many", func(t *testing.T) {
+		store := newStorage(t)
+
+		ingredients, err := store.CreateMany(ctx, []string{"sal", "  Pimienta  Negra ", "leche"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(ingredients) != 3 || ingredients[1].Name != "pimienta negra" {
+			t.Fatalf("expected the created ingredients in order, got %v", ingredients)
+		}
+
+		listed, _ := store.List(ctx)
+		if len(listed) != 3 {
+			t.Errorf("expected 3 ingredients, got %d", len(listed))
+		}
+	})
+
+	t.Run("create many reports every failed item", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "sal")
+
+		_, err := store.CreateMany(ctx, []string{"leche", "SAL", "xd", "pimienta", "Leche"})
+
+		var batchErr *storage.BatchError
+		if !errors.As(err, &batchErr) {
+			t.Fatalf("expected a batch error, got %v", err)
+		}
+
+		expected := []storage.BatchItemError{
+			{Index: 1, Err: storage.ErrIngredientNameExists},
+			{Index: 2, Err: storage.ErrIngredientNameIsTooShort},
+			{Index: 4, Err: storage.ErrIngredientNameExists},
+		}
+		if len(batchErr.Items) != len(expected) {
+			t.Fatalf("expected %v, got %v", expected, batchErr.Items)
+		}
+		for i, item := range batchErr.Items {
+			if item != expected[i] {
+				t.Errorf("expected %v, got %v", expected[i], item)
+			}
+		}
+
+		if !errors.Is(err, storage.ErrIngredientNameIsTooShort) {
+			t.Errorf("expected the batch error to wrap the item errors")
+		}
+
+		listed, _ := store.List(ctx)
+		if len(listed) != 1 {
+			t.Errorf("expected no ingredient to be created, got %d ingredients", len(listed))
+		}
+
+		// IDs handed out by the failed batch are not skipped
+		created, _ := store.Create(ctx, "leche")
+		if created.ID != 2 {
+			t.Errorf("expected ID 2, got %d", created.ID)
+		}
+	})
+
+	t.Run("update many", func(t *testing.T) {
+		store := newStorage(t)
+		store.CreateMany(ctx, []string{"sal", "pimienta"})
+
+		// a rename frees its old name for the renames after it
+		ingredients, err := store.UpdateMany(ctx, []storage.IngredientRename{
+			{Name: "sal", NewName: "sal marina"},
+			{Name: "pimienta", NewName: "sal"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if ingredients[0].Name != "sal marina" || ingredients[1].Name != "sal" || ingredients[1].ID != 2 {
+			t.Errorf("expected both renames to be applied, got %v", ingredients)
+		}
+	})
+
+	t.Run("update many is all or nothing", func(t *testing.T) {
+		store := newStorage(t)
+		store.CreateMany(ctx, []string{"sal", "pimienta", "leche"})
+
+		_, err := store.UpdateMany(ctx, []storage.IngredientRename{
+			{Name: "sal", NewName: "sal marina"},
+			{Name: "pimienta", NewName: "leche"},
+			{Name: "brotato", NewName: "potato"},
+		})
+
+		var batchErr *storage.BatchError
+		if !errors.As(err, &batchErr) {
+			t.Fatalf("expected a batch error, got %v", err)
+		}
+
+		expected := []storage.BatchItemError{
+			{Index: 1, Err: storage.ErrIngredientNameExists},
+			{Index: 2, Err: storage.ErrIngredientNotFound},
+		}
+		if len(batchErr.Items) != len(expected) || batchErr.Items[0] != expected[0] || batchErr.Items[1] != expected[1] {
+			t.Errorf("expected %v, got %v", expected, batchErr.Items)
+		}
+
+		if _, err := store.GetByName(ctx, "sal"); err != nil {
+			t.Errorf("expected the successful rename to be rolled back, got %v", err)
+		}
+		if _, err := store.GetByName(ctx, "sal marina"); err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+
+	t.Run("delete many", func(t *testing.T) {
+		store := newStorage(t)
+		store.CreateMany(ctx, []string{"sal", "pimienta", "leche"})
+
+		if err := store.DeleteMany(ctx, []string{"SAL", "leche"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		listed, _ := store.List(ctx)
+		if len(listed) != 1 || listed[0].Name != "pimienta" {
+			t.Errorf("expected only pimienta to remain, got %v", listed)
+		}
+	})
+
+	t.Run("delete many is all or nothing", func(t *testing.T) {
+		store := newStorage(t)
+		store.CreateMany(ctx, []string{"sal", "pimienta"})
+		store.AddAlias(ctx, "pimienta", "pepper", "en", storage.AnyVersion)
+
+		// deleting an ingredient twice fails the second time
+		err := store.DeleteMany(ctx, []string{"pimienta", "sal", "pepper"})
+
+		var batchErr *storage.BatchError
+		if !errors.As(err, &batchErr) {
+			t.Fatalf("expected a batch error, got %v", err)
+		}
+		if len(batchErr.Items) != 1 || batchErr.Items[0] != (storage.BatchItemError{Index: 2, Err: storage.ErrIngredientNotFound}) {
+			t.Errorf("expected item 2 to fail, got %v", batchErr.Items)
+		}
+
+		pepper, err := store.GetByName(ctx, "pepper")
+		if err != nil {
+			t.Fatalf("expected the ingredient and its alias to be restored, got %v", err)
+		}
+		if pepper.Name != "pimienta" {
+			t.Errorf("expected %q, got %q", "pimienta", pepper.Name)
+		}
+
+		listed, _ := store.List(ctx)
+		if len(listed) != 2 {
+			t.Errorf("expected 2 ingredients, got %d", len(listed))
+		}
+	})
+
+	t.Run("empty batches", func(t *testing.T) {
+		store := newStorage(t)
+
+		if ingredients, err := store.CreateMany(ctx, nil); err != nil || len(ingredients) != 0 {
+			t.Errorf("expected no ingredients and no error, got %v, %v", ingredients, err)
+		}
+		if ingredients, err := store.UpdateMany(ctx, nil); err != nil || len(ingredients) != 0 {
+			t.Errorf("expected no ingredients and no error, got %v, %v", ingredients, err)
+		}
+		if err := store.DeleteMany(ctx, nil); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func testTrash(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("deleted ingredients go to the trash", func(t *testing.T) {
+		store := newStorage(t)
+		created, _ := store.Create(ctx, "pimienta negra")
+		store.AddAlias(ctx, "pimienta negra", "black pepper", "en", storage.AnyVersion)
+		store.Create(ctx, "sal")
+
+		if err := store.Delete(ctx, "black pepper", storage.AnyVersion); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := store.Get(ctx, created.ID); err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+		if _, err := store.GetByName(ctx, "pimienta negra"); err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+		if _, err := store.SetCategory(ctx, "pimienta negra", models.CategorySpices, storage.AnyVersion); err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+
+		ingredients, _ := store.List(ctx)
+		if len(ingredients) != 1 || ingredients[0].Name != "sal" {
+			t.Errorf("expected only sal to be listed, got %v", ingredients)
+		}
+
+		trash, err := store.ListDeleted(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(trash) != 1 || trash[0].ID != created.ID || !trash[0].IsDeleted() {
+			t.Fatalf("expected the deleted ingredient in the trash, got %v", trash)
+		}
+		if len(trash[0].Aliases) != 1 {
+			t.Errorf("expected the aliases to be kept in the trash, got %v", trash[0].Aliases)
+		}
+	})
+
+	t.Run("deleted names are free", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "pimienta negra")
+		store.AddAlias(ctx, "pimienta negra", "black pepper", "en", storage.AnyVersion)
+		store.Delete(ctx, "pimienta negra", storage.AnyVersion)
+
+		if _, err := store.Create(ctx, "pimienta negra"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, err := store.Create(ctx, "black pepper"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		// the same name can be in the trash more than once
+		store.Delete(ctx, "pimienta negra", storage.AnyVersion)
+		trash, _ := store.ListDeleted(ctx)
+		if len(trash) != 2 {
+			t.Errorf("expected 2 ingredients in the trash, got %d", len(trash))
+		}
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		store := newStorage(t)
+		created, _ := store.Create(ctx, "pimienta negra")
+		store.AddAlias(ctx, "pimienta negra", "black pepper", "en", storage.AnyVersion)
+		store.Delete(ctx, "pimienta negra", storage.AnyVersion)
+
+		restored, err := store.Restore(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if restored.IsDeleted() || restored.Name != "pimienta negra" {
+			t.Errorf("expected the ingredient to be restored, got %+v", restored)
+		}
+
+		if _, err := store.GetByName(ctx, "black pepper"); err != nil {
+			t.Errorf("expected the alias to be restored, got %v", err)
+		}
+
+		trash, _ := store.ListDeleted(ctx)
+		if len(trash) != 0 {
+			t.Errorf("expected an empty trash, got %v", trash)
+		}
+
+		if _, err := store.Create(ctx, "Pimienta Negra"); err != storage.ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameExists, err)
+		}
+	})
+
+	t.Run("restore errors", func(t *testing.T) {
+		store := newStorage(t)
+		pepper, _ := store.Create(ctx, "pimienta negra")
+		store.AddAlias(ctx, "pimienta negra", "black pepper", "en", storage.AnyVersion)
+		salt, _ := store.Create(ctx, "sal")
+		store.Delete(ctx, "pimienta negra", storage.AnyVersion)
+		store.Delete(ctx, "sal", storage.AnyVersion)
+
+		store.Create(ctx, "sal")
+		if _, err := store.Restore(ctx, salt.ID); err != storage.ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameExists, err)
+		}
+
+		store.Create(ctx, "black pepper")
+		if _, err := store.Restore(ctx, pepper.ID); err != storage.ErrIngredientAliasExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientAliasExists, err)
+		}
+
+		live, _ := store.GetByName(ctx, "sal")
+		if _, err := store.Restore(ctx, live.ID); err != storage.ErrIngredientNotInTrash {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotInTrash, err)
+		}
+
+		if _, err := store.Restore(ctx, 999); err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+
+		trash, _ := store.ListDeleted(ctx)
+		if len(trash) != 2 {
+			t.Errorf("expected failed restores to leave the trash untouched, got %v", trash)
+		}
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		store := newStorage(t)
+		salt, _ := store.Create(ctx, "sal")
+		store.Create(ctx, "pimienta")
+		store.Create(ctx, "leche")
+		store.AddAlias(ctx, "sal", "salt", "en", storage.AnyVersion)
+
+		store.Delete(ctx, "sal", storage.AnyVersion)
+		time.Sleep(5 * time.Millisecond)
+		cutoff := time.Now()
+		time.Sleep(5 * time.Millisecond)
+		store.Delete(ctx, "pimienta", storage.AnyVersion)
+
+		purged, err := store.Purge(ctx, cutoff)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(purged) != 1 || purged[0].ID != salt.ID {
+			t.Fatalf("expected only sal to be purged, got %v", purged)
+		}
+
+		if _, err := store.Restore(ctx, salt.ID); err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+
+		purged, _ = store.Purge(ctx, time.Time{})
+		if len(purged) != 1 || purged[0].Name != "pimienta" {
+			t.Errorf("expected pimienta to be purged, got %v", purged)
+		}
+
+		trash, _ := store.ListDeleted(ctx)
+		ingredients, _ := store.List(ctx)
+		if len(trash) != 0 || len(ingredients) != 1 {
+			t.Errorf("expected an empty trash and 1 ingredient, got %v and %v", trash, ingredients)
+		}
+
+		// purged names and aliases are free as well
+		if _, err := store.Create(ctx, "salt"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func testRevisions(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("every change is recorded", func(t *testing.T) {
+		store := newStorage(t)
+		sessionCtx := storage.WithSession(ctx, "session-1")
+		created, _ := store.Create(sessionCtx, "sal")
+		store.AddAlias(sessionCtx, "sal", "salt", "en", storage.AnyVersion)
+		store.SetCategory(sessionCtx, "sal", models.CategorySpices, storage.AnyVersion)
+		store.Update(sessionCtx, "sal", "sal marina", storage.AnyVersion)
+		store.Delete(sessionCtx, "sal marina", storage.AnyVersion)
+		store.Restore(sessionCtx, created.ID)
+		store.Create(sessionCtx, "pimienta")
+
+		history, err := store.History(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := []models.RevisionChange{
+			models.RevisionCreate,
+			models.RevisionAddAlias,
+			models.RevisionSetCategory,
+			models.RevisionRename,
+			models.RevisionDelete,
+			models.RevisionRestore,
+		}
+		if len(history) != len(expected) {
+			t.Fatalf("expected %d revisions, got %d", len(expected), len(history))
+		}
+
+		for i, revision := range history {
+			if revision.Change != expected[i] {
+				t.Errorf("revision %d: expected %q, got %q", i, expected[i], revision.Change)
+			}
+			if revision.IngredientID != created.ID || revision.SessionID != "session-1" || revision.CreatedAt.IsZero() {
+				t.Errorf("revision %d: unexpected %+v", i, revision)
+			}
+			if i > 0 && revision.ID <= history[i-1].ID {
+				t.Errorf("revision %d: expected IDs to increase, got %d after %d", i, revision.ID, history[i-1].ID)
+			}
+		}
+
+		if history[0].Before != nil || history[0].After.Name != "sal" {
+			t.Errorf("expected the creation to have no previous value, got %+v", history[0])
+		}
+		if history[3].Before.Name != "sal" || history[3].After.Name != "sal marina" {
+			t.Errorf("expected the rename from sal to sal marina, got %q to %q", history[3].Before.Name, history[3].After.Name)
+		}
+		if history[4].Before.IsDeleted() || !history[4].After.IsDeleted() {
+			t.Errorf("expected the delete to move the ingredient to the trash, got %+v", history[4])
+		}
+	})
+
+	t.Run("history of unknown ingredients", func(t *testing.T) {
+		store := newStorage(t)
+
+		if _, err := store.History(ctx, 99); err != storage.ErrIngredientNotFound {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNotFound, err)
+		}
+	})
+
+	t.Run("purged ingredients keep their history", func(t *testing.T) {
+		store := newStorage(t)
+		created, _ := store.Create(ctx, "sal")
+		store.Delete(ctx, "sal", storage.AnyVersion)
+		store.Purge(ctx, time.Time{})
+
+		history, err := store.History(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(history) != 2 {
+			t.Errorf("expected 2 revisions, got %d", len(history))
+		}
+	})
+
+	t.Run("undo walks back the changes of the session", func(t *testing.T) {
+		store := newStorage(t)
+		sessionCtx := storage.WithSession(ctx, "session-1")
+		created, _ := store.Create(sessionCtx, "sal")
+		store.AddAlias(sessionCtx, "sal", "salt", "en", storage.AnyVersion)
+		store.Update(sessionCtx, "sal", "sal marina", storage.AnyVersion)
+		store.Delete(sessionCtx, "sal marina", storage.AnyVersion)
+
+		undone, err := store.UndoLastChange(sessionCtx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if undone.Change != models.RevisionUndo || undone.After.IsDeleted() || undone.SessionID != "session-1" {
+			t.Errorf("expected the delete to be undone, got %+v", undone)
+		}
+
+		if _, err := store.GetByName(ctx, "sal marina"); err != nil {
+			t.Errorf("expected the ingredient out of the trash, got %v", err)
+		}
+
+		store.UndoLastChange(sessionCtx)
+		if _, err := store.GetByName(ctx, "sal"); err != nil {
+			t.Errorf("expected the rename to be undone, got %v", err)
+		}
+
+		store.UndoLastChange(sessionCtx)
+		if _, err := store.GetByName(ctx, "salt"); err != storage.ErrIngredientNotFound {
+			t.Errorf("expected the alias to be removed, got %v", err)
+		}
+
+		store.UndoLastChange(sessionCtx)
+		if _, err := store.Get(ctx, created.ID); err != storage.ErrIngredientNotFound {
+			t.Errorf("expected the creation to be undone, got %v", err)
+		}
+
+		if _, err := store.UndoLastChange(sessionCtx); err != storage.ErrNothingToUndo {
+			t.Errorf("expected %v, got %v", storage.ErrNothingToUndo, err)
+		}
+
+		// undoing is a change of its own
+		history, _ := store.History(ctx, created.ID)
+		if len(history) != 8 || history[7].Change != models.RevisionUndo || history[7].Reverts != history[0].ID {
+			t.Errorf("expected the undos to be recorded, got %d revisions", len(history))
+		}
+	})
+
+	t.Run("undo is limited to the session", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(storage.WithSession(ctx, "session-1"), "sal")
+
+		if _, err := store.UndoLastChange(storage.WithSession(ctx, "session-2")); err != storage.ErrNothingToUndo {
+			t.Errorf("expected %v, got %v", storage.ErrNothingToUndo, err)
+		}
+	})
+
+	t.Run("undo conflicts with later changes of other sessions", func(t *testing.T) {
+		store := newStorage(t)
+		first := storage.WithSession(ctx, "session-1")
+		second := storage.WithSession(ctx, "session-2")
+		store.Create(first, "sal")
+		store.Update(first, "sal", "sal marina", storage.AnyVersion)
+		store.SetCategory(second, "sal marina", models.CategorySpices, storage.AnyVersion)
+
+		if _, err := store.UndoLastChange(first); err != storage.ErrUndoConflict {
+			t.Errorf("expected %v, got %v", storage.ErrUndoConflict, err)
+		}
+
+		// once the other session undoes its change, the rename can be undone
+		store.UndoLastChange(second)
+		if _, err := store.UndoLastChange(first); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, err := store.GetByName(ctx, "sal"); err != nil {
+			t.Errorf("expected the rename to be undone, got %v", err)
+		}
+	})
+
+	t.Run("undo onto a taken name", func(t *testing.T) {
+		store := newStorage(t)
+		first := storage.WithSession(ctx, "session-1")
+		store.Create(first, "sal")
+		store.Update(first, "sal", "sal marina", storage.AnyVersion)
+		store.Create(storage.WithSession(ctx, "session-2"), "sal")
+
+		if _, err := store.UndoLastChange(first); err != storage.ErrIngredientNameExists {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientNameExists, err)
+		}
+
+		if _, err := store.GetByName(ctx, "sal marina"); err != nil {
+			t.Errorf("expected a failed undo to change nothing, got %v", err)
+		}
+	})
+}
+
+func testVersions(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	t.Run("every change bumps the version", func(t *testing.T) {
+		store := newStorage(t)
+		created, _ := store.Create(ctx, "sal")
+		if created.Version != 1 {
+			t.Errorf("expected version 1, got %d", created.Version)
+		}
+
+		store.AddAlias(ctx, "sal", "salt", "en", storage.AnyVersion)
+		updated, _ := store.Update(ctx, "sal", "sal marina", 2)
+		if updated == nil || updated.Version != 3 {
+			t.Fatalf("expected version 3, got %+v", updated)
+		}
+
+		store.Delete(ctx, "sal marina", 3)
+		store.Restore(ctx, created.ID)
+		store.UndoLastChange(ctx)
+		store.Restore(ctx, created.ID)
+
+		ingredient, _ := store.Get(ctx, created.ID)
+		if ingredient.Version != 7 {
+			t.Errorf("expected version 7, got %d", ingredient.Version)
+		}
+	})
+
+	t.Run("stale updates are refused", func(t *testing.T) {
+		store := newStorage(t)
+		created, _ := store.Create(ctx, "sal")
+		store.Update(ctx, "sal", "sal marina", created.Version)
+
+		if _, err := store.Update(ctx, "sal marina", "sal gorda", created.Version); err != storage.ErrIngredientVersionConflict {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientVersionConflict, err)
+		}
+
+		ingredient, _ := store.Get(ctx, created.ID)
+		if ingredient.Name != "sal marina" || ingredient.Version != 2 {
+			t.Errorf("expected the stale update to change nothing, got %+v", ingredient)
+		}
+
+		if _, err := store.Update(ctx, "sal marina", "sal gorda", storage.AnyVersion); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("stale deletes are refused", func(t *testing.T) {
+		store := newStorage(t)
+		created, _ := store.Create(ctx, "sal")
+		store.SetCategory(ctx, "sal", models.CategorySpices, storage.AnyVersion)
+
+		if err := store.Delete(ctx, "sal", created.Version); err != storage.ErrIngredientVersionConflict {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientVersionConflict, err)
+		}
+		if _, err := store.Get(ctx, created.ID); err != nil {
+			t.Errorf("expected the stale delete to change nothing, got %v", err)
+		}
+
+		if err := store.Delete(ctx, "sal", created.Version+1); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("interleaved attribute updates", func(t *testing.T) {
+		store := newStorage(t)
+		created, _ := store.Create(ctx, "leche")
+
+		// two clients read version 1, then each sets a field expecting it
+		first, err := store.SetCategory(ctx, "leche", models.CategoryDairy, created.Version)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		facts := models.NutritionFacts{CaloriesPer100g: 64}
+		if _, err := store.SetNutrition(ctx, "leche", facts, created.Version); err != storage.ErrIngredientVersionConflict {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientVersionConflict, err)
+		}
+		conversion := models.UnitConversion{DensityGPerMl: 1.03}
+		if _, err := store.SetUnitConversion(ctx, "leche", conversion, created.Version); err != storage.ErrIngredientVersionConflict {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientVersionConflict, err)
+		}
+		if _, err := store.SetCategory(ctx, "leche", models.CategoryCondiments, created.Version); err != storage.ErrIngredientVersionConflict {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientVersionConflict, err)
+		}
+		info := models.DietaryInfo{Allergens: []models.Allergen{models.AllergenDairy}}
+		if _, err := store.SetDietaryInfo(ctx, "leche", info, created.Version); err != storage.ErrIngredientVersionConflict {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientVersionConflict, err)
+		}
+		if _, err := store.AddAlias(ctx, "leche", "milk", "en", created.Version); err != storage.ErrIngredientVersionConflict {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientVersionConflict, err)
+		}
+
+		ingredient, _ := store.Get(ctx, created.ID)
+		if ingredient.Category != models.CategoryDairy || ingredient.Nutrition != nil || ingredient.UnitConversion.DensityGPerMl != 0 || ingredient.Dietary != nil || len(ingredient.Aliases) != 0 || ingredient.Version != 2 {
+			t.Errorf("expected the stale updates to change nothing, got %+v", ingredient)
+		}
+
+		// the second client fetches the ingredient again and retries
+		second, err := store.AddAlias(ctx, "leche", "milk", "en", first.Version)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := store.RemoveAlias(ctx, "leche", "milk", first.Version); err != storage.ErrIngredientVersionConflict {
+			t.Errorf("expected %v, got %v", storage.ErrIngredientVersionConflict, err)
+		}
+		if _, err := store.RemoveAlias(ctx, "leche", "milk", second.Version); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func testWatch(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	// receive returns the next event, which every storage publishes before the
+	// change it announces returns
+	receive := func(t *testing.T, events <-chan models.IngredientEvent) models.IngredientEvent {
+		t.Helper()
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("expected an event, the channel was closed")
+			}
+			return event
+		default:
+			t.Fatal("expected an event, got none")
+			return models.IngredientEvent{}
+		}
+	}
+
+	t.Run("every change is announced", func(t *testing.T) {
+		store := newStorage(t)
+		events := store.Watch(ctx)
+
+		created, _ := store.Create(ctx, "sal")
+		store.Update(ctx, "sal", "sal marina", storage.AnyVersion)
+		store.SetCategory(ctx, "sal marina", models.CategorySpices, storage.AnyVersion)
+		store.Delete(ctx, "sal marina", storage.AnyVersion)
+		store.Restore(ctx, created.ID)
+		store.UndoLastChange(ctx)
+		store.Purge(ctx, time.Time{})
+
+		expected := []models.IngredientEventKind{
+			models.IngredientCreated,
+			models.IngredientRenamed,
+			models.IngredientUpdated,
+			models.IngredientDeleted,
+			models.IngredientRestored,
+			models.IngredientDeleted,
+			models.IngredientPurged,
+		}
+		for i, kind := range expected {
+			event := receive(t, events)
+			if event.Kind != kind || event.Ingredient == nil || event.Ingredient.ID != created.ID {
+				t.Errorf("event %d: expected %q of ingredient %d, got %+v", i, kind, created.ID, event)
+			}
+			if kind == models.IngredientRenamed && (event.OldName != "sal" || event.Ingredient.Name != "sal marina") {
+				t.Errorf("expected the rename from sal to sal marina, got %q to %q", event.OldName, event.Ingredient.Name)
+			}
+		}
+
+		select {
+		case event := <-events:
+			t.Errorf("unexpected event %+v", event)
+		default:
+		}
+	})
+
+	t.Run("failed changes are not announced", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "sal")
+		events := store.Watch(ctx)
+
+		store.Create(ctx, "sal")
+		store.CreateMany(ctx, []string{"pimienta", "sal"})
+		store.Update(ctx, "sal", "sal marina", 99)
+
+		select {
+		case event := <-events:
+			t.Errorf("unexpected event %+v", event)
+		default:
+		}
+
+		store.CreateMany(ctx, []string{"pimienta", "leche"})
+		for _, name := range []string{"pimienta", "leche"} {
+			if event := receive(t, events); event.Kind != models.IngredientCreated || event.Ingredient.Name != name {
+				t.Errorf("expected %s to be created, got %+v", name, event)
+			}
+		}
+	})
+
+	t.Run("watching stops with the context", func(t *testing.T) {
+		store := newStorage(t)
+		watchCtx, cancel := context.WithCancel(ctx)
+		events := store.Watch(watchCtx)
+		cancel()
+
+		deadline := time.After(time.Second)
+		for {
+			select {
+			case _, ok := <-events:
+				if !ok {
+					return
+				}
+			case <-deadline:
+				t.Fatal("expected the channel to be closed")
+			}
+		}
+	})
+
+	t.Run("watchers falling behind are dropped", func(t *testing.T) {
+		store := newStorage(t)
+		events := store.Watch(ctx)
+
+		names := make([]string, 200)
+		for i := range names {
+			names[i] = fmt.Sprintf("ingredient %d", i)
+		}
+		store.CreateMany(ctx, names)
+
+		received := 0
+		for range events {
+			received++
+		}
+		if received >= len(names) {
+			t.Errorf("expected the watcher to be dropped before %d events, got %d", len(names), received)
+		}
+
+		// a dropped watcher catches up by watching anew
+		events = store.Watch(ctx)
+		store.Create(ctx, "sal")
+		if event := receive(t, events); event.Ingredient.Name != "sal" {
+			t.Errorf("expected sal to be created, got %+v", event)
+		}
+	})
+}
+
+func testContextCancellation(t *testing.T, newStorage Factory) {
+	store := newStorage(t)
+	store.Create(t.Context(), "tomato")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if _, err := store.Create(ctx, "basil"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	if _, err := store.Update(ctx, "tomato", "potato", storage.AnyVersion); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := store.Delete(ctx, "tomato", storage.AnyVersion); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	if _, err := store.CreateMany(ctx, []string{"basil", "oregano"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	if _, err := store.SeedTestData(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	ingredients, _ := store.List(t.Context())
+	if len(ingredients) != 1 || ingredients[0].Name != "tomato" {
+		t.Errorf("expected cancelled calls to leave the storage untouched, got %v", ingredients)
+	}
+}
+
+func testConcurrency(t *testing.T, newStorage Factory) {
+	ctx := t.Context()
+
+	const workers = 16
+
+	t.Run("concurrent creates get distinct IDs", func(t *testing.T) {
+		store := newStorage(t)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := store.Create(ctx, fmt.Sprintf("ingredient %d", i)); err != nil {
+					errs <- err
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		ingredients, _ := store.List(ctx)
+		if len(ingredients) != workers {
+			t.Fatalf("expected %d ingredients, got %d", workers, len(ingredients))
+		}
+
+		ids := make(map[int]bool)
+		for _, ingredient := range ingredients {
+			if ids[ingredient.ID] {
+				t.Errorf("ID %d assigned twice", ingredient.ID)
+			}
+			ids[ingredient.ID] = true
+		}
+	})
+
+	t.Run("concurrent creates of one name", func(t *testing.T) {
+		store := newStorage(t)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// differently written names still collide once normalized
+				_, err := store.Create(ctx, strings.Repeat(" ", i)+"Tomato")
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		created := 0
+		for err := range errs {
+			switch err {
+			case nil:
+				created++
+			case storage.ErrIngredientNameExists:
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+
+		if created != 1 {
+			t.Errorf("expected exactly 1 successful create, got %d", created)
+		}
+	})
+
+	t.Run("concurrent renames onto one name", func(t *testing.T) {
+		store := newStorage(t)
+		for i := range workers {
+			store.Create(ctx, fmt.Sprintf("ingredient %d", i))
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := store.Update(ctx, fmt.Sprintf("ingredient %d", i), "potato", storage.AnyVersion)
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		renamed := 0
+		for err := range errs {
+			switch err {
+			case nil:
+				renamed++
+			case storage.ErrIngredientNameExists:
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+
+		if renamed != 1 {
+			t.Errorf("expected exactly 1 successful rename, got %d", renamed)
+		}
+
+		ingredients, _ := store.List(ctx)
+		if len(ingredients) != workers {
+			t.Errorf("expected %d ingredients, got %d", workers, len(ingredients))
+		}
+	})
+
+	t.Run("concurrent updates of one version", func(t *testing.T) {
+		store := newStorage(t)
+		created, _ := store.Create(ctx, "sal")
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := store.Update(ctx, "sal", fmt.Sprintf("sal %d", i), created.Version)
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		// the losers either find the new version or no longer find the old name
+		updated := 0
+		for err := range errs {
+			switch err {
+			case nil:
+				updated++
+			case storage.ErrIngredientVersionConflict, storage.ErrIngredientNotFound:
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+
+		if updated != 1 {
+			t.Errorf("expected exactly 1 successful update, got %d", updated)
+		}
+	})
+
+	t.Run("readers changing what they get while others write", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "tomato")
+		store.SetNutrition(ctx, "tomato", models.NutritionFacts{CaloriesPer100g: 18}, storage.AnyVersion)
+
+		// scribble changes every field of an ingredient that storages read
+		scribble := func(ingredient *models.Ingredient) {
+			ingredient.Name = "scribbled"
+			ingredient.Version = 0
+			for i := range ingredient.Aliases {
+				ingredient.Aliases[i].Name = "scribbled"
+			}
+			if ingredient.Nutrition != nil {
+				ingredient.Nutrition.CaloriesPer100g = 0
+			}
+		}
+
+		watchCtx, stopWatching := context.WithCancel(ctx)
+		var watchers sync.WaitGroup
+		for range 2 {
+			events := store.Watch(watchCtx)
+			watchers.Add(1)
+			go func() {
+				defer watchers.Done()
+				for event := range events {
+					scribble(event.Ingredient)
+				}
+			}()
+		}
+
+		var wg sync.WaitGroup
+		for i := range workers {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				updated, err := store.AddAlias(ctx, "tomato", fmt.Sprintf("alias %d", i), "", storage.AnyVersion)
+				if err != nil && err != storage.ErrIngredientTooManyAliases {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if updated != nil {
+					scribble(updated)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				if ingredient, err := store.GetByName(ctx, "tomato"); err == nil {
+					scribble(ingredient)
+				} else {
+					t.Errorf("unexpected error: %v", err)
+				}
+				ingredients, _ := store.List(ctx)
+				for _, ingredient := range ingredients {
+					scribble(ingredient)
+				}
+				page, _ := store.ListPage(ctx, storage.ListOptions{SortBy: storage.SortByName})
+				for _, ingredient := range page.Ingredients {
+					scribble(ingredient)
+				}
+				history, _ := store.History(ctx, 1)
+				for _, revision := range history {
+					scribble(revision.After)
+				}
+			}()
+		}
+		wg.Wait()
+		stopWatching()
+		watchers.Wait()
+
+		ingredient, err := store.Get(ctx, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ingredient.Name != "tomato" || ingredient.Nutrition.CaloriesPer100g != 18 || ingredient.Version < 2 {
+			t.Errorf("expected the stored ingredient to be unchanged by readers, got %+v", ingredient)
+		}
+		for _, alias := range ingredient.Aliases {
+			if alias.Name == "scribbled" {
+				t.Errorf("expected aliases to be unchanged by readers, got %v", ingredient.Aliases)
+			}
+		}
+	})
+
+	t.Run("concurrent reads and writes", func(t *testing.T) {
+		store := newStorage(t)
+		store.Create(ctx, "tomato")
+
+		var wg sync.WaitGroup
+		for i := range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				alias := fmt.Sprintf("alias %d", i)
+				if _, err := store.AddAlias(ctx, "tomato", alias, "", storage.AnyVersion); err != nil && err != storage.ErrIngredientTooManyAliases {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if _, err := store.GetByName(ctx, "tomato"); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if _, err := store.List(ctx); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
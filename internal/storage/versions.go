@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// AnyVersion makes Update, Delete, AddAlias, RemoveAlias and the attribute
+// setters apply whatever the current version of the ingredient is.
+const AnyVersion = 0
+
+var ErrIngredientVersionConflict = errors.New("ingredient has been changed since the expected version")
+
+// checkVersion returns ErrIngredientVersionConflict unless the ingredient is
+// at the expected version, or any version is expected.
+func checkVersion(ingredient *models.Ingredient, expectedVersion int) error {
+	if expectedVersion != AnyVersion && ingredient.Version != expectedVersion {
+		return ErrIngredientVersionConflict
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// watchBuffer is how many events a watcher can fall behind before it is
+// dropped.
+const watchBuffer = 64
+
+// changeFeed fans ingredient events out to the watchers of a storage. Events
+// are never blocked on a slow watcher: one that falls watchBuffer events behind
+// has its channel closed, and must read what it needs again and watch anew.
+// The zero value is ready to use.
+type changeFeed struct {
+	mu       sync.Mutex
+	watchers map[chan models.IngredientEvent]struct{}
+	// held collects the events published while holding is set, so a change
+	// that may still be rolled back is only announced once it is kept.
+	held    []models.IngredientEvent
+	holding bool
+}
+
+// watch returns a channel receiving every event published from now on. It is
+// closed once ctx is done, or when the watcher falls behind.
+func (f *changeFeed) watch(ctx context.Context) <-chan models.IngredientEvent {
+	events := make(chan models.IngredientEvent, watchBuffer)
+
+	f.mu.Lock()
+	if f.watchers == nil {
+		f.watchers = make(map[chan models.IngredientEvent]struct{})
+	}
+	f.watchers[events] = struct{}{}
+	f.mu.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.dropLocked(events)
+	})
+
+	return events
+}
+
+// publish sends events to every watcher, in order.
+func (f *changeFeed) publish(events ...models.IngredientEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.holding {
+		f.held = append(f.held, events...)
+		return
+	}
+	f.sendLocked(events)
+}
+
+// hold keeps the events published from now on until release or discard.
+func (f *changeFeed) hold() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.holding = true
+}
+
+// release publishes the held events.
+func (f *changeFeed) release() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sendLocked(f.held)
+	f.held, f.holding = nil, false
+}
+
+// discard forgets the held events.
+func (f *changeFeed) discard() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.held, f.holding = nil, false
+}
+
+func (f *changeFeed) sendLocked(events []models.IngredientEvent) {
+	for watcher := range f.watchers {
+		if !trySend(watcher, events) {
+			f.dropLocked(watcher)
+		}
+	}
+}
+
+// trySend sends events to a watcher without blocking, reporting whether they
+// all fit in its buffer. Every watcher gets its own copy of the ingredients.
+func trySend(watcher chan<- models.IngredientEvent, events []models.IngredientEvent) bool {
+	for _, event := range events {
+		event.Ingredient = event.Ingredient.Clone()
+		select {
+		case watcher <- event:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (f *changeFeed) dropLocked(watcher chan models.IngredientEvent) {
+	if _, ok := f.watchers[watcher]; ok {
+		delete(f.watchers, watcher)
+		close(watcher)
+	}
+}
+
+// revisionEvent returns the event announcing the change a revision records.
+func revisionEvent(revision *models.Revision) models.IngredientEvent {
+	event := models.IngredientEvent{
+		Kind:       models.IngredientUpdated,
+		Ingredient: revision.After,
+		RevisionID: revision.ID,
+	}
+
+	// undoing is described by its outcome, like any other change
+	before, after := revision.Before, revision.After
+	switch {
+	case before == nil:
+		event.Kind = models.IngredientCreated
+	case !before.IsDeleted() && after.IsDeleted():
+		event.Kind = models.IngredientDeleted
+	case before.IsDeleted() && !after.IsDeleted():
+		event.Kind = models.IngredientRestored
+	case before.Name != after.Name:
+		event.Kind = models.IngredientRenamed
+		event.OldName = before.Name
+	}
+
+	return event
+}
+
+// purgedEvent returns the event announcing an ingredient was purged.
+func purgedEvent(ingredient *models.Ingredient) models.IngredientEvent {
+	return models.IngredientEvent{Kind: models.IngredientPurged, Ingredient: ingredient}
+}
@@ -0,0 +1,199 @@
+package units
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+// Dimension is the kind of amount a unit measures.
+type Dimension string
+
+const (
+	Mass   Dimension = "mass"
+	Volume Dimension = "volume"
+	Count  Dimension = "count"
+)
+
+// Unit is a measurement unit. Factor expresses one unit in the base unit of its
+// dimension: grams for mass, milliliters for volume and pieces for count.
+type Unit struct {
+	Symbol    string
+	Dimension Dimension
+	Factor    float64
+}
+
+var (
+	ErrUnknownUnit            = errors.New("unknown unit")
+	ErrDensityRequired        = errors.New("ingredient density is required to convert between mass and volume")
+	ErrPieceWeightRequired    = errors.New("ingredient piece weight is required to convert pieces")
+	ErrPieceNameMismatch      = errors.New("unit does not match the ingredient piece name")
+	ErrQuantityMustBePositive = errors.New("quantity must be greater than zero")
+)
+
+// Piece is the generic count unit, used when a recipe line has no unit at all.
+const Piece = "pieza"
+
+var units = map[string]Unit{
+	"mg":     {"mg", Mass, 0.001},
+	"g":      {"g", Mass, 1},
+	"kg":     {"kg", Mass, 1000},
+	"oz":     {"oz", Mass, 28.349523125},
+	"lb":     {"lb", Mass, 453.59237},
+	"ml":     {"ml", Volume, 1},
+	"l":      {"l", Volume, 1000},
+	"cup":    {"cup", Volume, 240},
+	"tbsp":   {"tbsp", Volume, 15},
+	"tsp":    {"tsp", Volume, 5},
+	"pizca":  {"pizca", Volume, 5.0 / 16},
+	"diente": {"diente", Count, 1},
+	Piece:    {Piece, Count, 1},
+}
+
+// aliases maps alternative spellings, plurals and Spanish names onto unit symbols.
+var aliases = map[string]string{
+	"":             Piece,
+	"gr":           "g",
+	"gram":         "g",
+	"grams":        "g",
+	"gramo":        "g",
+	"gramos":       "g",
+	"kilo":         "kg",
+	"kilos":        "kg",
+	"kilogramo":    "kg",
+	"kilogramos":   "kg",
+	"ounce":        "oz",
+	"ounces":       "oz",
+	"onza":         "oz",
+	"onzas":        "oz",
+	"lbs":          "lb",
+	"pound":        "lb",
+	"pounds":       "lb",
+	"libra":        "lb",
+	"libras":       "lb",
+	"mililitro":    "ml",
+	"mililitros":   "ml",
+	"litro":        "l",
+	"litros":       "l",
+	"cups":         "cup",
+	"taza":         "cup",
+	"tazas":        "cup",
+	"cucharada":    "tbsp",
+	"cucharadas":   "tbsp",
+	"cucharadita":  "tsp",
+	"cucharaditas": "tsp",
+	"pizcas":       "pizca",
+	"pinch":        "pizca",
+	"dientes":      "diente",
+	"clove":        "diente",
+	"cloves":       "diente",
+	"piezas":       Piece,
+	"piece":        Piece,
+	"pieces":       Piece,
+	"unidad":       Piece,
+	"unidades":     Piece,
+}
+
+// Lookup returns the unit matching the given symbol, name or alias.
+func Lookup(symbol string) (Unit, bool) {
+	normalized := normalize(symbol)
+	if canonical, ok := aliases[normalized]; ok {
+		normalized = canonical
+	}
+	unit, ok := units[normalized]
+	return unit, ok
+}
+
+// resolve looks up a unit, also accepting the piece name of the ingredient as
+// a count unit (e.g. "huevo" for eggs or "rebanada" for bread).
+func resolve(symbol string, conversion models.UnitConversion) (Unit, error) {
+	if unit, ok := Lookup(symbol); ok {
+		if unit.Dimension == Count && unit.Symbol != Piece && conversion.PieceName != "" && conversion.PieceName != unit.Symbol {
+			return Unit{}, fmt.Errorf("%w: %q is measured in %q", ErrPieceNameMismatch, symbol, conversion.PieceName)
+		}
+		return unit, nil
+	}
+
+	normalized := normalize(symbol)
+	if conversion.PieceName != "" && (normalized == conversion.PieceName || normalized == conversion.PieceName+"s") {
+		return Unit{conversion.PieceName, Count, 1}, nil
+	}
+
+	return Unit{}, fmt.Errorf("%w: %q", ErrUnknownUnit, symbol)
+}
+
+// Convert converts a quantity between two units using the conversion fields of
+// an ingredient to bridge mass, volume and count when needed.
+func Convert(quantity float64, from, to string, conversion models.UnitConversion) (float64, error) {
+	if quantity <= 0 {
+		return 0, ErrQuantityMustBePositive
+	}
+
+	fromUnit, err := resolve(from, conversion)
+	if err != nil {
+		return 0, err
+	}
+	toUnit, err := resolve(to, conversion)
+	if err != nil {
+		return 0, err
+	}
+
+	if fromUnit.Dimension == toUnit.Dimension {
+		return quantity * fromUnit.Factor / toUnit.Factor, nil
+	}
+
+	grams, err := baseToGrams(quantity*fromUnit.Factor, fromUnit.Dimension, conversion)
+	if err != nil {
+		return 0, err
+	}
+	base, err := gramsToBase(grams, toUnit.Dimension, conversion)
+	if err != nil {
+		return 0, err
+	}
+	return base / toUnit.Factor, nil
+}
+
+// ToGrams converts a quantity of an ingredient to grams.
+func ToGrams(quantity float64, unit string, conversion models.UnitConversion) (float64, error) {
+	return Convert(quantity, unit, "g", conversion)
+}
+
+func baseToGrams(amount float64, dimension Dimension, conversion models.UnitConversion) (float64, error) {
+	switch dimension {
+	case Volume:
+		if conversion.DensityGPerMl <= 0 {
+			return 0, ErrDensityRequired
+		}
+		return amount * conversion.DensityGPerMl, nil
+	case Count:
+		if conversion.PieceWeightGrams <= 0 {
+			return 0, ErrPieceWeightRequired
+		}
+		return amount * conversion.PieceWeightGrams, nil
+	default:
+		return amount, nil
+	}
+}
+
+func gramsToBase(grams float64, dimension Dimension, conversion models.UnitConversion) (float64, error) {
+	switch dimension {
+	case Volume:
+		if conversion.DensityGPerMl <= 0 {
+			return 0, ErrDensityRequired
+		}
+		return grams / conversion.DensityGPerMl, nil
+	case Count:
+		if conversion.PieceWeightGrams <= 0 {
+			return 0, ErrPieceWeightRequired
+		}
+		return grams / conversion.PieceWeightGrams, nil
+	default:
+		return grams, nil
+	}
+}
+
+func normalize(symbol string) string {
+	return strings.ToLower(strings.Join(strings.Fields(symbol), " "))
+}
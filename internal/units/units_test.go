@@ -0,0 +1,111 @@
+package units
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/victorcete/recipe-manager/internal/models"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestLookup(t *testing.T) {
+	testCases := []struct {
+		input     string
+		symbol    string
+		dimension Dimension
+	}{
+		{"g", "g", Mass},
+		{" Gramos ", "g", Mass},
+		{"KG", "kg", Mass},
+		{"lbs", "lb", Mass},
+		{"litro", "l", Volume},
+		{"cucharadas", "tbsp", Volume},
+		{"tsp", "tsp", Volume},
+		{"pizca", "pizca", Volume},
+		{"dientes", "diente", Count},
+		{"", Piece, Count},
+		{"unidad", Piece, Count},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			unit, ok := Lookup(tc.input)
+			if !ok {
+				t.Fatalf("expected %q to be a known unit", tc.input)
+			}
+			if unit.Symbol != tc.symbol || unit.Dimension != tc.dimension {
+				t.Errorf("expected %s (%s), got %s (%s)", tc.symbol, tc.dimension, unit.Symbol, unit.Dimension)
+			}
+		})
+	}
+
+	t.Run("unknown unit", func(t *testing.T) {
+		if _, ok := Lookup("furlong"); ok {
+			t.Errorf("expected furlong to be unknown")
+		}
+	})
+}
+
+func TestConvert(t *testing.T) {
+	oil := models.UnitConversion{DensityGPerMl: 0.92}
+	garlic := models.UnitConversion{PieceWeightGrams: 5, PieceName: "diente"}
+	egg := models.UnitConversion{PieceWeightGrams: 60, PieceName: "huevo", DensityGPerMl: 1.03}
+
+	testCases := []struct {
+		name       string
+		quantity   float64
+		from, to   string
+		conversion models.UnitConversion
+		expected   float64
+	}{
+		{"mass to mass", 1.5, "kg", "g", models.UnitConversion{}, 1500},
+		{"pounds to grams", 1, "lb", "g", models.UnitConversion{}, 453.59237},
+		{"volume to volume", 1, "cup", "tbsp", models.UnitConversion{}, 16},
+		{"liters to milliliters", 0.25, "l", "ml", models.UnitConversion{}, 250},
+		{"volume to mass with density", 2, "tbsp", "g", oil, 27.6},
+		{"mass to volume with density", 92, "g", "ml", oil, 100},
+		{"named pieces to mass", 3, "dientes", "g", garlic, 15},
+		{"generic pieces to mass", 3, "", "g", garlic, 15},
+		{"mass to pieces", 120, "g", "huevos", egg, 2},
+		{"pieces to volume", 1, "huevo", "ml", egg, 60 / 1.03},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Convert(tc.quantity, tc.from, tc.to, tc.conversion)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !almostEqual(result, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+
+	errorCases := []struct {
+		name        string
+		quantity    float64
+		from, to    string
+		conversion  models.UnitConversion
+		expectedErr error
+	}{
+		{"non positive quantity", 0, "g", "kg", models.UnitConversion{}, ErrQuantityMustBePositive},
+		{"unknown unit", 1, "furlong", "g", models.UnitConversion{}, ErrUnknownUnit},
+		{"missing density", 1, "cup", "g", models.UnitConversion{}, ErrDensityRequired},
+		{"missing piece weight", 2, "diente", "g", models.UnitConversion{}, ErrPieceWeightRequired},
+		{"piece name mismatch", 2, "diente", "g", egg, ErrPieceNameMismatch},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Convert(tc.quantity, tc.from, tc.to, tc.conversion)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}